@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	protov2 "google.golang.org/protobuf/proto"
+)
+
+const (
+	ContentEncodingHeader = "Content-Encoding"
+	GzipEncoding          = "gzip"
+)
+
+// Entry is a cached response along with the routing decisions the graph made to produce it, so a cached
+// response reports the same routing as the call that was cached.
+type Entry struct {
+	Response payload.SeldonPayload
+	Routing  map[string]int32
+}
+
+// Store holds cached responses. Implementations backed by an external store allow executor replicas
+// to share cached responses.
+type Store interface {
+	// Get returns the entry cached under key and whether it was found.
+	Get(ctx context.Context, key string) (*Entry, bool, error)
+	// Set caches an entry under key. A zero ttl means the entry does not expire.
+	Set(ctx context.Context, key string, value *Entry, ttl time.Duration) error
+}
+
+// Key returns a cache key for a request made of the prefix, a hash of the decompressed payload and the
+// values of the given headers. JSON payloads are hashed in canonical form so formatting and key
+// order do not affect the key.
+func Key(prefix string, msg payload.SeldonPayload, meta map[string][]string, headers []string) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(prefix))
+	h.Write([]byte{0})
+
+	if pm, ok := msg.GetPayload().(proto.Message); ok {
+		// Include the message type so requests to different gRPC methods never share responses
+		h.Write([]byte(proto.MessageName(pm)))
+		h.Write([]byte{0})
+		b, err := protov2.MarshalOptions{Deterministic: true}.Marshal(proto.MessageV2(pm))
+		if err != nil {
+			return "", err
+		}
+		h.Write(b)
+	} else {
+		b, err := msg.GetBytes()
+		if err != nil {
+			return "", err
+		}
+		if msg.GetContentEncoding() == GzipEncoding || getHeader(meta, ContentEncodingHeader) == GzipEncoding {
+			if b, err = gunzip(b); err != nil {
+				return "", err
+			}
+		}
+		h.Write(canonicalJson(b))
+	}
+
+	names := make([]string, len(headers))
+	for i, name := range headers {
+		names[i] = strings.ToLower(name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.Write([]byte{0})
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(getHeader(meta, name)))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Clone copies a payload so cached entries are not changed by later processing of a response.
+func Clone(msg payload.SeldonPayload) payload.SeldonPayload {
+	switch m := msg.(type) {
+	case *payload.ProtoPayload:
+		return &payload.ProtoPayload{Msg: proto.Clone(m.Msg)}
+	case *payload.BytesPayload:
+		b := make([]byte, len(m.Msg))
+		copy(b, m.Msg)
+		return &payload.BytesPayload{Msg: b, ContentType: m.ContentType, ContentEncoding: m.ContentEncoding}
+	}
+	return msg
+}
+
+// CloneEntry copies an entry so it is not changed by later processing of a response.
+func CloneEntry(entry *Entry) *Entry {
+	var routing map[string]int32
+	if entry.Routing != nil {
+		routing = make(map[string]int32, len(entry.Routing))
+		for name, route := range entry.Routing {
+			routing[name] = route
+		}
+	}
+	return &Entry{Response: Clone(entry.Response), Routing: routing}
+}
+
+// getHeader matches header names case insensitively as gRPC metadata keys are lower case.
+func getHeader(meta map[string][]string, name string) string {
+	for k, v := range meta {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return strings.Join(v, ",")
+		}
+	}
+	return ""
+}
+
+func gunzip(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// canonicalJson re-encodes JSON with sorted keys and no insignificant whitespace. Payloads that are
+// not valid JSON are returned unchanged.
+func canonicalJson(b []byte) []byte {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return b
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return canonical
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+func gzipped(g *GomegaWithT, data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	g.Expect(err).To(BeNil())
+	g.Expect(w.Close()).To(BeNil())
+	return buf.Bytes()
+}
+
+func TestKeyCanonicalJson(t *testing.T) {
+	g := NewGomegaWithT(t)
+	k1, err := Key("model", &payload.BytesPayload{Msg: []byte(`{"data":{"names":["a"],"ndarray":[[1.0]]}}`)}, nil, nil)
+	g.Expect(err).To(BeNil())
+	k2, err := Key("model", &payload.BytesPayload{Msg: []byte(`{ "data": { "ndarray": [[1.0]], "names": ["a"] } }`)}, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(k1).To(Equal(k2))
+
+	k3, err := Key("model", &payload.BytesPayload{Msg: gzipped(g, `{"data":{"ndarray":[[1.0]],"names":["a"]}}`), ContentEncoding: "gzip"}, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(k3).To(Equal(k1))
+
+	k4, err := Key("other", &payload.BytesPayload{Msg: []byte(`{"data":{"names":["a"],"ndarray":[[1.0]]}}`)}, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(k4).ToNot(Equal(k1))
+}
+
+func TestKeyHeaders(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msg := &payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1]}}`)}
+	headers := []string{"X-User-Id"}
+	k1, err := Key("model", msg, map[string][]string{"X-User-Id": {"alice"}, "Seldon-Puid": {"1"}}, headers)
+	g.Expect(err).To(BeNil())
+	k2, err := Key("model", msg, map[string][]string{"x-user-id": {"alice"}, "Seldon-Puid": {"2"}}, headers)
+	g.Expect(err).To(BeNil())
+	k3, err := Key("model", msg, map[string][]string{"X-User-Id": {"bob"}}, headers)
+	g.Expect(err).To(BeNil())
+	g.Expect(k1).To(Equal(k2))
+	g.Expect(k1).ToNot(Equal(k3))
+}
+
+func TestKeyProto(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var sm1, sm2 proto.SeldonMessage
+	g.Expect(jsonpb.UnmarshalString(`{"meta":{"tags":{"a":1,"b":2}},"data":{"ndarray":[1]}}`, &sm1)).To(BeNil())
+	g.Expect(jsonpb.UnmarshalString(`{"meta":{"tags":{"b":2,"a":1}},"data":{"ndarray":[1]}}`, &sm2)).To(BeNil())
+	k1, err := Key("model", &payload.ProtoPayload{Msg: &sm1}, nil, nil)
+	g.Expect(err).To(BeNil())
+	k2, err := Key("model", &payload.ProtoPayload{Msg: &sm2}, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(k1).To(Equal(k2))
+}
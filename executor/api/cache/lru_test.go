@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+func TestLRUEviction(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	store := NewLRUStore(2)
+	g.Expect(store.Set(ctx, "a", bytesEntry("a"), 0)).To(BeNil())
+	g.Expect(store.Set(ctx, "b", bytesEntry("b"), 0)).To(BeNil())
+	_, found, _ := store.Get(ctx, "a")
+	g.Expect(found).To(BeTrue())
+	g.Expect(store.Set(ctx, "c", bytesEntry("c"), 0)).To(BeNil())
+
+	g.Expect(store.Len()).To(Equal(2))
+	_, found, _ = store.Get(ctx, "b")
+	g.Expect(found).To(BeFalse())
+	res, found, _ := store.Get(ctx, "a")
+	g.Expect(found).To(BeTrue())
+	g.Expect(res.Response.GetPayload()).To(Equal([]byte("a")))
+}
+
+func TestLRUExpiry(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	now := time.Now()
+	store := NewLRUStore(10)
+	store.now = func() time.Time { return now }
+	g.Expect(store.Set(ctx, "a", bytesEntry("a"), time.Second)).To(BeNil())
+
+	_, found, _ := store.Get(ctx, "a")
+	g.Expect(found).To(BeTrue())
+	now = now.Add(2 * time.Second)
+	_, found, _ = store.Get(ctx, "a")
+	g.Expect(found).To(BeFalse())
+	g.Expect(store.Len()).To(Equal(0))
+}
+
+func TestLRUReturnsCopies(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+	store := NewLRUStore(10)
+	msg := &payload.BytesPayload{Msg: []byte("a")}
+	routing := map[string]int32{"router": 1}
+	g.Expect(store.Set(ctx, "a", &Entry{Response: msg, Routing: routing}, 0)).To(BeNil())
+	msg.Msg[0] = 'b'
+	routing["router"] = 0
+
+	res, _, _ := store.Get(ctx, "a")
+	g.Expect(res.Response.GetPayload()).To(Equal([]byte("a")))
+	g.Expect(res.Routing).To(Equal(map[string]int32{"router": 1}))
+	res.Routing["router"] = 2
+
+	res, _, _ = store.Get(ctx, "a")
+	g.Expect(res.Routing).To(Equal(map[string]int32{"router": 1}))
+}
+
+func bytesEntry(s string) *Entry {
+	return &Entry{Response: &payload.BytesPayload{Msg: []byte(s)}}
+}
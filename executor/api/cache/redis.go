@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/protobuf/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ENV_CACHE_REDIS_URL is the Redis server, e.g. redis://:password@host:6379/0, that graph and node caches keep
+// their responses in so they are shared between executor replicas.
+const ENV_CACHE_REDIS_URL = "CACHE_REDIS_URL"
+
+// redisEntry is the JSON encoding of an entry in Redis. Proto responses are stored in the wire format along
+// with their message name.
+type redisEntry struct {
+	MessageName     string           `json:"messageName,omitempty"`
+	Msg             []byte           `json:"msg"`
+	ContentType     string           `json:"contentType,omitempty"`
+	ContentEncoding string           `json:"contentEncoding,omitempty"`
+	Routing         map[string]int32 `json:"routing,omitempty"`
+}
+
+// RedisStore is a Store keeping responses in Redis, under keys starting with a prefix, so executor replicas
+// share them. Redis evicts entries by their ttl and its own maxmemory policy so MaxEntries does not apply.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore returns a store keeping responses in the Redis server at redisUrl.
+func NewRedisStore(redisUrl string, prefix string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(redisUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: redis.NewClient(opts), prefix: prefix}, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, bool, error) {
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	entry, err := decodeEntry(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value *Entry, ttl time.Duration) error {
+	data, err := encodeEntry(value)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, data, ttl).Err()
+}
+
+func encodeEntry(entry *Entry) ([]byte, error) {
+	re := redisEntry{Routing: entry.Routing}
+	switch m := entry.Response.(type) {
+	case *payload.ProtoPayload:
+		b, err := proto.Marshal(m.Msg)
+		if err != nil {
+			return nil, err
+		}
+		re.MessageName = proto.MessageName(m.Msg)
+		re.Msg = b
+	default:
+		b, err := m.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		re.Msg = b
+		re.ContentType = m.GetContentType()
+		re.ContentEncoding = m.GetContentEncoding()
+	}
+	return json.Marshal(re)
+}
+
+func decodeEntry(data []byte) (*Entry, error) {
+	re := redisEntry{}
+	if err := json.Unmarshal(data, &re); err != nil {
+		return nil, err
+	}
+	if re.MessageName == "" {
+		return &Entry{
+			Response: &payload.BytesPayload{Msg: re.Msg, ContentType: re.ContentType, ContentEncoding: re.ContentEncoding},
+			Routing:  re.Routing,
+		}, nil
+	}
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(re.MessageName))
+	if err != nil {
+		return nil, fmt.Errorf("unknown cached message %s: %w", re.MessageName, err)
+	}
+	msg := mt.New().Interface()
+	if err := protov2.Unmarshal(re.Msg, msg); err != nil {
+		return nil, err
+	}
+	return &Entry{Response: &payload.ProtoPayload{Msg: proto.MessageV1(msg)}, Routing: re.Routing}, nil
+}
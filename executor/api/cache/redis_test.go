@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	golangproto "github.com/golang/protobuf/proto"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+func TestRedisEntryEncoding(t *testing.T) {
+	g := NewGomegaWithT(t)
+	sm := &proto.SeldonMessage{}
+	g.Expect(jsonpb.UnmarshalString(`{"meta":{"puid":"a"},"data":{"ndarray":[1,2]}}`, sm)).To(BeNil())
+	entries := []*Entry{
+		{Response: &payload.ProtoPayload{Msg: sm}, Routing: map[string]int32{"router": 1}},
+		{Response: &payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1,2]}}`), ContentType: "application/json", ContentEncoding: GzipEncoding}},
+	}
+	for _, entry := range entries {
+		data, err := encodeEntry(entry)
+		g.Expect(err).To(BeNil())
+		decoded, err := decodeEntry(data)
+		g.Expect(err).To(BeNil())
+		g.Expect(decoded.Routing).To(Equal(entry.Routing))
+		g.Expect(decoded.Response.GetContentType()).To(Equal(entry.Response.GetContentType()))
+		g.Expect(decoded.Response.GetContentEncoding()).To(Equal(entry.Response.GetContentEncoding()))
+		if pm, ok := entry.Response.GetPayload().(golangproto.Message); ok {
+			g.Expect(golangproto.Equal(decoded.Response.GetPayload().(golangproto.Message), pm)).To(BeTrue())
+		} else {
+			g.Expect(decoded.Response.GetPayload()).To(Equal(entry.Response.GetPayload()))
+		}
+	}
+}
+
+func TestNewRedisStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	_, err := NewRedisStore("redis://:pass@localhost:6379/1", "seldon:cache:")
+	g.Expect(err).To(BeNil())
+	_, err = NewRedisStore("http://localhost", "seldon:cache:")
+	g.Expect(err).ToNot(BeNil())
+}
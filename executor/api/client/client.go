@@ -2,10 +2,14 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"io"
+	"net/http"
 )
 
 const (
@@ -17,6 +21,10 @@ const (
 	SeldonFeedbackPath        = "/send-feedback"
 	SeldonStatusPath          = "/health/status"
 	SeldonMetadataPath        = "/metadata"
+	SeldonModelConfigPath     = "/config"
+	SeldonRepositoryIndexPath = "/repository/index"
+	SeldonModelLoadPath       = "/load"
+	SeldonModelUnloadPath     = "/unload"
 )
 
 type SeldonApiClient interface {
@@ -38,6 +46,48 @@ type SeldonApiClient interface {
 	IsGrpc() bool
 }
 
+// SeldonRepositoryApiClient is implemented by clients which can call the V2 model configuration and model
+// repository extensions of graph nodes. The model name of RepositoryIndex is the graph node asked for its index.
+type SeldonRepositoryApiClient interface {
+	ModelConfig(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error)
+	RepositoryIndex(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error)
+	RepositoryModelLoad(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error)
+	RepositoryModelUnload(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error)
+}
+
+type nodeNameKey struct{}
+
+// WithNodeName returns a context for calls to the named graph node. The model name a node is called with is
+// overridden by the model named in V2 and Tensorflow requests, so clients find the node's settings by this name.
+func WithNodeName(ctx context.Context, nodeName string) context.Context {
+	return context.WithValue(ctx, nodeNameKey{}, nodeName)
+}
+
+// NodeName returns the name of the graph node a call is for, or modelName if the context does not name one.
+func NodeName(ctx context.Context, modelName string) string {
+	if nodeName, ok := ctx.Value(nodeNameKey{}).(string); ok {
+		return nodeName
+	}
+	return modelName
+}
+
+// IsRejected returns whether the graph rejected a request with a client error, so it would fail again however
+// often it was processed.
+func IsRejected(err error) bool {
+	var statusErr interface{ HTTPStatus() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatus() >= http.StatusBadRequest && statusErr.HTTPStatus() < http.StatusInternalServerError
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied, codes.FailedPrecondition,
+			codes.OutOfRange, codes.Unimplemented, codes.Unauthenticated:
+			return true
+		}
+	}
+	return false
+}
+
 type SeldonApiError struct {
 	Message string
 	Code    int
@@ -0,0 +1,285 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	protoV1 "github.com/golang/protobuf/proto"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// SeldonNodeTransportClient is implemented by clients which call each graph node over the transport of its
+// endpoint rather than that of the client.
+type SeldonNodeTransportClient interface {
+	IsGrpcNode(node *v1.PredictiveUnit) bool
+}
+
+// MixedTransportClient calls each graph node over the transport set in its endpoint, so a graph can mix REST
+// and gRPC nodes. Payloads are converted between JSON and protobuf when a node's transport differs from that
+// of the server, whose client is used for everything but calls to nodes.
+//
+// Predict, transform, route, combine and feedback calls go over the node's transport. Status, metadata and
+// model repository calls go over the transport of the server.
+type MixedTransportClient struct {
+	server    SeldonApiClient
+	rest      SeldonApiClient
+	grpc      SeldonApiClient
+	protocol  string
+	endpoints map[string]*v1.Endpoint
+}
+
+// HasMixedTransports returns true if the predictor's graph has nodes with both REST and gRPC endpoints.
+func HasMixedTransports(predictor *v1.PredictorSpec) bool {
+	types := map[v1.EndpointType]bool{}
+	for _, node := range v1.GetPredictiveUnitListForPredictor(predictor) {
+		if node.Endpoint != nil && node.Endpoint.Type != "" {
+			types[node.Endpoint.Type] = true
+		}
+	}
+	return len(types) > 1
+}
+
+// NewMixedTransportClient creates a client for the server using the server client and the client of the
+// other transport.
+func NewMixedTransportClient(server SeldonApiClient, other SeldonApiClient, protocol string, predictor *v1.PredictorSpec) (*MixedTransportClient, error) {
+	if protocol != api.ProtocolSeldon && protocol != api.ProtocolKFServing {
+		return nil, fmt.Errorf("mixed REST and gRPC graphs are not supported for protocol %s", protocol)
+	}
+	c := &MixedTransportClient{
+		server:    server,
+		rest:      server,
+		grpc:      other,
+		protocol:  protocol,
+		endpoints: map[string]*v1.Endpoint{},
+	}
+	if server.IsGrpc() {
+		c.rest, c.grpc = other, server
+	}
+	for _, node := range v1.GetPredictiveUnitListForPredictor(predictor) {
+		if node.Endpoint == nil {
+			continue
+		}
+		port := node.Endpoint.HttpPort
+		if c.IsGrpcNode(node) {
+			port = node.Endpoint.GrpcPort
+		}
+		c.endpoints[net.JoinHostPort(node.Endpoint.ServiceHost, strconv.Itoa(int(port)))] = node.Endpoint
+	}
+	return c, nil
+}
+
+func (c *MixedTransportClient) IsGrpcNode(node *v1.PredictiveUnit) bool {
+	if node.Endpoint != nil && node.Endpoint.Type != "" {
+		return node.Endpoint.Type == v1.GRPC
+	}
+	return c.server.IsGrpc()
+}
+
+// nodeClient returns the client for the node with the given address.
+func (c *MixedTransportClient) nodeClient(host string, port int32) SeldonApiClient {
+	endpoint, ok := c.endpoints[net.JoinHostPort(host, strconv.Itoa(int(port)))]
+	if !ok || endpoint.Type == "" {
+		return c.server
+	}
+	if endpoint.Type == v1.GRPC {
+		return c.grpc
+	}
+	return c.rest
+}
+
+// serverPort returns the port of the node with the given address for the transport of the server.
+func (c *MixedTransportClient) serverPort(host string, port int32) int32 {
+	endpoint, ok := c.endpoints[net.JoinHostPort(host, strconv.Itoa(int(port)))]
+	if !ok {
+		return port
+	}
+	if c.server.IsGrpc() {
+		return endpoint.GrpcPort
+	}
+	return endpoint.HttpPort
+}
+
+func (c *MixedTransportClient) newMessage(response bool) protoV1.Message {
+	if c.protocol == api.ProtocolKFServing {
+		if response {
+			return &inference.ModelInferResponse{}
+		}
+		return &inference.ModelInferRequest{}
+	}
+	return &proto.SeldonMessage{}
+}
+
+// convert changes msg to the form used by client, where pb is its gRPC message.
+func convert(client SeldonApiClient, msg payload.SeldonPayload, pb protoV1.Message) (payload.SeldonPayload, error) {
+	if msg == nil {
+		return nil, nil
+	}
+	if client.IsGrpc() {
+		return payload.JSONToProto(msg, pb)
+	}
+	return payload.ProtoToJSON(msg)
+}
+
+// call sends msg to the node with the given address using fn, converting the request into the form of the
+// node's client and the response into the form of the server's client.
+func (c *MixedTransportClient) call(host string, port int32, msg payload.SeldonPayload, fn func(SeldonApiClient, payload.SeldonPayload) (payload.SeldonPayload, error)) (payload.SeldonPayload, error) {
+	nc := c.nodeClient(host, port)
+	if nc == c.server {
+		return fn(nc, msg)
+	}
+	req, err := convert(nc, msg, c.newMessage(false))
+	if err != nil {
+		return nil, err
+	}
+	res, err := fn(nc, req)
+	if err != nil {
+		return nil, err
+	}
+	return convert(c.server, res, c.newMessage(true))
+}
+
+func (c *MixedTransportClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return c.call(host, port, msg, func(nc SeldonApiClient, req payload.SeldonPayload) (payload.SeldonPayload, error) {
+		return nc.Predict(ctx, modelName, host, port, req, meta)
+	})
+}
+
+func (c *MixedTransportClient) TransformInput(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return c.call(host, port, msg, func(nc SeldonApiClient, req payload.SeldonPayload) (payload.SeldonPayload, error) {
+		return nc.TransformInput(ctx, modelName, host, port, req, meta)
+	})
+}
+
+func (c *MixedTransportClient) TransformOutput(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return c.call(host, port, msg, func(nc SeldonApiClient, req payload.SeldonPayload) (payload.SeldonPayload, error) {
+		return nc.TransformOutput(ctx, modelName, host, port, req, meta)
+	})
+}
+
+func (c *MixedTransportClient) Route(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (int, error) {
+	nc := c.nodeClient(host, port)
+	req, err := convert(nc, msg, c.newMessage(false))
+	if err != nil {
+		return 0, err
+	}
+	return nc.Route(ctx, modelName, host, port, req, meta)
+}
+
+func (c *MixedTransportClient) Combine(ctx context.Context, modelName string, host string, port int32, msgs []payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	nc := c.nodeClient(host, port)
+	if nc == c.server {
+		return nc.Combine(ctx, modelName, host, port, msgs, meta)
+	}
+	reqs := make([]payload.SeldonPayload, len(msgs))
+	for i, msg := range msgs {
+		req, err := convert(nc, msg, c.newMessage(true))
+		if err != nil {
+			return nil, err
+		}
+		reqs[i] = req
+	}
+	res, err := nc.Combine(ctx, modelName, host, port, reqs, meta)
+	if err != nil {
+		return nil, err
+	}
+	return convert(c.server, res, c.newMessage(true))
+}
+
+func (c *MixedTransportClient) Feedback(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	nc := c.nodeClient(host, port)
+	if nc == c.server {
+		return nc.Feedback(ctx, modelName, host, port, msg, meta)
+	}
+	if c.protocol != api.ProtocolSeldon {
+		// As with the REST client, feedback is only sent to nodes for the Seldon protocol
+		return msg, nil
+	}
+	req, err := convert(nc, msg, &proto.Feedback{})
+	if err != nil {
+		return nil, err
+	}
+	res, err := nc.Feedback(ctx, modelName, host, port, req, meta)
+	if err != nil {
+		return nil, err
+	}
+	return convert(c.server, res, &proto.SeldonMessage{})
+}
+
+func (c *MixedTransportClient) Chain(ctx context.Context, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	return c.server.Chain(ctx, modelName, msg)
+}
+
+func (c *MixedTransportClient) Status(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return c.server.Status(ctx, modelName, host, c.serverPort(host, port), msg, meta)
+}
+
+func (c *MixedTransportClient) Metadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return c.server.Metadata(ctx, modelName, host, c.serverPort(host, port), msg, meta)
+}
+
+func (c *MixedTransportClient) ModelMetadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.ModelMetadata, error) {
+	return c.server.ModelMetadata(ctx, modelName, host, c.serverPort(host, port), msg, meta)
+}
+
+func (c *MixedTransportClient) repositoryClient() (SeldonRepositoryApiClient, error) {
+	rc, ok := c.server.(SeldonRepositoryApiClient)
+	if !ok {
+		return nil, fmt.Errorf("model repository calls are not supported by the %T client", c.server)
+	}
+	return rc, nil
+}
+
+func (c *MixedTransportClient) ModelConfig(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	rc, err := c.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	return rc.ModelConfig(ctx, modelName, host, c.serverPort(host, port), msg, meta)
+}
+
+func (c *MixedTransportClient) RepositoryIndex(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	rc, err := c.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	return rc.RepositoryIndex(ctx, modelName, host, c.serverPort(host, port), msg, meta)
+}
+
+func (c *MixedTransportClient) RepositoryModelLoad(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	rc, err := c.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	return rc.RepositoryModelLoad(ctx, modelName, host, c.serverPort(host, port), msg, meta)
+}
+
+func (c *MixedTransportClient) RepositoryModelUnload(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	rc, err := c.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	return rc.RepositoryModelUnload(ctx, modelName, host, c.serverPort(host, port), msg, meta)
+}
+
+func (c *MixedTransportClient) Unmarshall(msg []byte, contentType string) (payload.SeldonPayload, error) {
+	return c.server.Unmarshall(msg, contentType)
+}
+
+func (c *MixedTransportClient) Marshall(out io.Writer, msg payload.SeldonPayload) error {
+	return c.server.Marshall(out, msg)
+}
+
+func (c *MixedTransportClient) CreateErrorPayload(err error) payload.SeldonPayload {
+	return c.server.CreateErrorPayload(err)
+}
+
+func (c *MixedTransportClient) IsGrpc() bool {
+	return c.server.IsGrpc()
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+type transportTestClient struct {
+	test.SeldonMessageTestClient
+	grpc     bool
+	received []payload.SeldonPayload
+	ports    []int32
+}
+
+func (c *transportTestClient) IsGrpc() bool {
+	return c.grpc
+}
+
+func (c *transportTestClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	c.received = append(c.received, msg)
+	c.ports = append(c.ports, port)
+	return msg, nil
+}
+
+func (c *transportTestClient) Status(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	c.ports = append(c.ports, port)
+	return msg, nil
+}
+
+func mixedTestPredictor() *v1.PredictorSpec {
+	return &v1.PredictorSpec{
+		Graph: v1.PredictiveUnit{
+			Name:     "rest",
+			Endpoint: &v1.Endpoint{ServiceHost: "rest", HttpPort: 9000, GrpcPort: 9500, Type: v1.REST},
+			Children: []v1.PredictiveUnit{
+				{
+					Name:     "grpc",
+					Endpoint: &v1.Endpoint{ServiceHost: "grpc", HttpPort: 9001, GrpcPort: 9501, Type: v1.GRPC},
+				},
+			},
+		},
+	}
+}
+
+func TestHasMixedTransports(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	predictor := mixedTestPredictor()
+	g.Expect(HasMixedTransports(predictor)).To(BeTrue())
+
+	predictor.Graph.Children[0].Endpoint.Type = v1.REST
+	g.Expect(HasMixedTransports(predictor)).To(BeFalse())
+
+	predictor.Graph.Children[0].Endpoint.Type = ""
+	g.Expect(HasMixedTransports(predictor)).To(BeFalse())
+}
+
+func TestMixedTransportClient(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rest := &transportTestClient{}
+	grpc := &transportTestClient{grpc: true}
+	predictor := mixedTestPredictor()
+	c, err := NewMixedTransportClient(rest, grpc, api.ProtocolSeldon, predictor)
+	g.Expect(err).To(BeNil())
+	g.Expect(c.IsGrpc()).To(BeFalse())
+	g.Expect(c.IsGrpcNode(&predictor.Graph)).To(BeFalse())
+	g.Expect(c.IsGrpcNode(&predictor.Graph.Children[0])).To(BeTrue())
+
+	msg := &payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[1,2]]}}`), ContentType: "application/json"}
+
+	// Nodes on the server's transport receive the payload unchanged
+	res, err := c.Predict(context.Background(), "rest", "rest", 9000, msg, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(res).To(Equal(msg))
+	g.Expect(rest.received).To(Equal([]payload.SeldonPayload{msg}))
+
+	// Nodes on the other transport receive a converted payload and return one in the server's form
+	res, err = c.Predict(context.Background(), "grpc", "grpc", 9501, msg, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(grpc.received).To(HaveLen(1))
+	sm, ok := grpc.received[0].GetPayload().(*proto.SeldonMessage)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(sm.GetData().GetNdarray().GetValues()).To(HaveLen(1))
+	b, ok := res.GetPayload().([]byte)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(string(b)).To(MatchJSON(`{"data":{"ndarray":[[1,2]]}}`))
+
+	// Status calls go to the server's transport port of the node
+	_, err = c.Status(context.Background(), "grpc", "grpc", 9501, msg, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(rest.ports).To(Equal([]int32{9000, 9001}))
+}
+
+func TestMixedTransportClientProtocol(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := NewMixedTransportClient(&transportTestClient{}, &transportTestClient{grpc: true}, api.ProtocolTensorflow, mixedTestPredictor())
+	g.Expect(err).ToNot(BeNil())
+}
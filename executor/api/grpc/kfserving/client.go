@@ -185,6 +185,90 @@ func (s *KFServingGrpcClient) Metadata(ctx context.Context, modelName string, ho
 	return &resPayload, nil
 }
 
+func (s *KFServingGrpcClient) ModelConfig(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	conn, err := s.getConnection(host, port, modelName)
+	if err != nil {
+		return nil, err
+	}
+	grpcClient := inference.NewGRPCInferenceServiceClient(conn)
+	ctx = grpc2.AddMetadataToOutgoingGrpcContext(ctx, meta)
+	var resp *inference.ModelConfigResponse
+	switch v := msg.GetPayload().(type) {
+	case *inference.ModelConfigRequest:
+		resp, err = grpcClient.ModelConfig(ctx, v, s.callOptions...)
+	default:
+		return nil, errors.Errorf("Invalid type %v", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resPayload := payload.ProtoPayload{Msg: resp}
+	return &resPayload, nil
+}
+
+func (s *KFServingGrpcClient) RepositoryIndex(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	conn, err := s.getConnection(host, port, modelName)
+	if err != nil {
+		return nil, err
+	}
+	grpcClient := inference.NewGRPCInferenceServiceClient(conn)
+	ctx = grpc2.AddMetadataToOutgoingGrpcContext(ctx, meta)
+	var resp *inference.RepositoryIndexResponse
+	switch v := msg.GetPayload().(type) {
+	case *inference.RepositoryIndexRequest:
+		resp, err = grpcClient.RepositoryIndex(ctx, v, s.callOptions...)
+	default:
+		return nil, errors.Errorf("Invalid type %v", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resPayload := payload.ProtoPayload{Msg: resp}
+	return &resPayload, nil
+}
+
+func (s *KFServingGrpcClient) RepositoryModelLoad(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	conn, err := s.getConnection(host, port, modelName)
+	if err != nil {
+		return nil, err
+	}
+	grpcClient := inference.NewGRPCInferenceServiceClient(conn)
+	ctx = grpc2.AddMetadataToOutgoingGrpcContext(ctx, meta)
+	var resp *inference.RepositoryModelLoadResponse
+	switch v := msg.GetPayload().(type) {
+	case *inference.RepositoryModelLoadRequest:
+		resp, err = grpcClient.RepositoryModelLoad(ctx, v, s.callOptions...)
+	default:
+		return nil, errors.Errorf("Invalid type %v", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resPayload := payload.ProtoPayload{Msg: resp}
+	return &resPayload, nil
+}
+
+func (s *KFServingGrpcClient) RepositoryModelUnload(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	conn, err := s.getConnection(host, port, modelName)
+	if err != nil {
+		return nil, err
+	}
+	grpcClient := inference.NewGRPCInferenceServiceClient(conn)
+	ctx = grpc2.AddMetadataToOutgoingGrpcContext(ctx, meta)
+	var resp *inference.RepositoryModelUnloadResponse
+	switch v := msg.GetPayload().(type) {
+	case *inference.RepositoryModelUnloadRequest:
+		resp, err = grpcClient.RepositoryModelUnload(ctx, v, s.callOptions...)
+	default:
+		return nil, errors.Errorf("Invalid type %v", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resPayload := payload.ProtoPayload{Msg: resp}
+	return &resPayload, nil
+}
+
 func (s *KFServingGrpcClient) Unmarshall(msg []byte, contentType string) (payload.SeldonPayload, error) {
 	panic("implement me")
 }
@@ -2,7 +2,9 @@ package kfserving
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-logr/logr"
+	"github.com/golang/protobuf/proto"
 	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/grpc"
 	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
@@ -10,35 +12,44 @@ import (
 	"github.com/seldonio/seldon-core/executor/predictor"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	protoGrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	protoGrpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"net/url"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type GrpcKFServingServer struct {
-	Client    client.SeldonApiClient
-	predictor *v1.PredictorSpec
-	Log       logr.Logger
-	ServerUrl *url.URL
-	Namespace string
+	Client       client.SeldonApiClient
+	predictor    *v1.PredictorSpec
+	Log          logr.Logger
+	ServerUrl    *url.URL
+	Namespace    string
+	StreamWindow int
 }
 
 func NewGrpcKFServingServer(predictor *v1.PredictorSpec, client client.SeldonApiClient, serverUrl *url.URL, namespace string) *GrpcKFServingServer {
 	return &GrpcKFServingServer{
-		Client:    client,
-		predictor: predictor,
-		Log:       logf.Log.WithName("KFServingGrpcApi"),
-		ServerUrl: serverUrl,
-		Namespace: namespace,
+		Client:       client,
+		predictor:    predictor,
+		Log:          logf.Log.WithName("KFServingGrpcApi"),
+		ServerUrl:    serverUrl,
+		Namespace:    namespace,
+		StreamWindow: grpc.DefaultStreamWindow,
 	}
 }
 
 func (g GrpcKFServingServer) ServerLive(ctx context.Context, request *inference.ServerLiveRequest) (*inference.ServerLiveResponse, error) {
-	panic("Not Implemented")
+	return &inference.ServerLiveResponse{Live: true}, nil
 }
 
+// ServerReady reports whether all nodes of the graph are accepting connections.
 func (g GrpcKFServingServer) ServerReady(ctx context.Context, request *inference.ServerReadyRequest) (*inference.ServerReadyResponse, error) {
-	panic("Not Implemented")
+	if err := predictor.ReadyPredictor(g.predictor); err != nil {
+		g.Log.Error(err, "Ready check failed")
+		return &inference.ServerReadyResponse{Ready: false}, nil
+	}
+	return &inference.ServerReadyResponse{Ready: true}, nil
 }
 
 func (g GrpcKFServingServer) ModelReady(ctx context.Context, request *inference.ModelReadyRequest) (*inference.ModelReadyResponse, error) {
@@ -48,7 +59,7 @@ func (g GrpcKFServingServer) ModelReady(ctx context.Context, request *inference.
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, md, request.GetName())
 	reqPayload := payload.ProtoPayload{Msg: request}
-	resPayload, err := seldonPredictorProcess.Status(&g.predictor.Graph, request.Name, &reqPayload)
+	resPayload, err := seldonPredictorProcess.Status(g.predictor, request.Name, &reqPayload)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +67,13 @@ func (g GrpcKFServingServer) ModelReady(ctx context.Context, request *inference.
 }
 
 func (g GrpcKFServingServer) ServerMetadata(ctx context.Context, request *inference.ServerMetadataRequest) (*inference.ServerMetadataResponse, error) {
-	panic("Not Implemented")
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), "")
+	metadata := seldonPredictorProcess.ServerMetadata(g.predictor)
+	return &inference.ServerMetadataResponse{
+		Name:       metadata.Name,
+		Version:    metadata.Version,
+		Extensions: metadata.Extensions,
+	}, nil
 }
 
 func (g GrpcKFServingServer) ModelMetadata(ctx context.Context, request *inference.ModelMetadataRequest) (*inference.ModelMetadataResponse, error) {
@@ -66,7 +83,7 @@ func (g GrpcKFServingServer) ModelMetadata(ctx context.Context, request *inferen
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, md, request.GetName())
 	reqPayload := payload.ProtoPayload{Msg: request}
-	resPayload, err := seldonPredictorProcess.Metadata(&g.predictor.Graph, request.Name, &reqPayload)
+	resPayload, err := seldonPredictorProcess.Metadata(g.predictor, request.Name, &reqPayload)
 	if err != nil {
 		return nil, err
 	}
@@ -80,35 +97,134 @@ func (g GrpcKFServingServer) ModelInfer(ctx context.Context, request *inference.
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, md, request.GetModelName())
 	reqPayload := payload.ProtoPayload{Msg: request}
-	resPayload, err := seldonPredictorProcess.Predict(&g.predictor.Graph, &reqPayload)
+	resPayload, err := seldonPredictorProcess.PredictGraph(g.predictor, &reqPayload)
 	if err != nil {
 		return nil, err
 	}
 	return resPayload.GetPayload().(*inference.ModelInferResponse), nil
 }
 
-func (g GrpcKFServingServer) ModelStreamInfer(server inference.GRPCInferenceService_ModelStreamInferServer) error {
-	panic("Not Implemented")
+// ModelStreamInfer runs each request of a stream through the graph with its own puid. Responses without an id
+// are given the id of their request or, if that is empty, the puid. Failed requests are returned as responses
+// with an error message so the stream stays open.
+func (g GrpcKFServingServer) ModelStreamInfer(stream inference.GRPCInferenceService_ModelStreamInferServer) error {
+	ctx := stream.Context()
+	md := grpc.CollectMetadata(ctx)
+	return grpc.ProcessStream(ctx, g.StreamWindow,
+		func() (interface{}, error) {
+			return stream.Recv()
+		},
+		func(req interface{}) interface{} {
+			return g.streamInfer(ctx, md, req.(*inference.ModelInferRequest))
+		},
+		func(res interface{}) error {
+			return stream.Send(res.(*inference.ModelStreamInferResponse))
+		})
+}
+
+func (g GrpcKFServingServer) streamInfer(ctx context.Context, md protoGrpcMetadata.MD, request *inference.ModelInferRequest) *inference.ModelStreamInferResponse {
+	md = grpc.StreamMessageMetadata(md, "")
+	puid := md.Get(payload.SeldonPUIDHeader)[0]
+	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, puid)
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, md, request.GetModelName())
+	reqPayload := payload.ProtoPayload{Msg: request}
+	resPayload, err := seldonPredictorProcess.PredictGraph(g.predictor, &reqPayload)
+	if err != nil {
+		g.Log.Error(err, "Failed to call infer", "puid", puid)
+		return &inference.ModelStreamInferResponse{ErrorMessage: err.Error()}
+	}
+	res, ok := resPayload.GetPayload().(*inference.ModelInferResponse)
+	if !ok {
+		return &inference.ModelStreamInferResponse{ErrorMessage: fmt.Sprintf("unexpected response type %T", resPayload.GetPayload())}
+	}
+	if res.GetId() == "" {
+		res = proto.Clone(res).(*inference.ModelInferResponse)
+		res.Id = request.GetId()
+		if res.Id == "" {
+			res.Id = puid
+		}
+	}
+	return &inference.ModelStreamInferResponse{InferResponse: res}
 }
 
 func (g GrpcKFServingServer) ModelConfig(ctx context.Context, request *inference.ModelConfigRequest) (*inference.ModelConfigResponse, error) {
-	panic("Not Implemented")
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), request.GetName())
+	reqPayload := payload.ProtoPayload{Msg: request}
+	resPayload, err := seldonPredictorProcess.ModelConfig(g.predictor, request.GetName(), &reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := resPayload.GetPayload().(*inference.ModelConfigResponse)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected response type %T", resPayload.GetPayload())
+	}
+	return res, nil
 }
 
+// ModelStatistics returns the statistics kept by the executor for its calls to the graph nodes.
 func (g GrpcKFServingServer) ModelStatistics(ctx context.Context, request *inference.ModelStatisticsRequest) (*inference.ModelStatisticsResponse, error) {
-	panic("Not Implemented")
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), request.GetName())
+	stats, err := seldonPredictorProcess.ModelStatistics(g.predictor, request.GetName())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	res := &inference.ModelStatisticsResponse{}
+	for _, s := range stats {
+		res.ModelStats = append(res.ModelStats, &inference.ModelStatistics{
+			Name:           s.Name,
+			Version:        s.Version,
+			LastInference:  s.LastInference,
+			InferenceCount: s.InferenceCount,
+			ExecutionCount: s.ExecutionCount,
+			InferenceStats: &inference.InferStatistics{
+				Success: &inference.StatisticDuration{Count: s.InferenceStats.Success.Count, Ns: s.InferenceStats.Success.Ns},
+				Fail:    &inference.StatisticDuration{Count: s.InferenceStats.Fail.Count, Ns: s.InferenceStats.Fail.Ns},
+			},
+		})
+	}
+	return res, nil
 }
 
 func (g GrpcKFServingServer) RepositoryIndex(ctx context.Context, request *inference.RepositoryIndexRequest) (*inference.RepositoryIndexResponse, error) {
-	panic("Not Implemented")
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), "")
+	reqPayload := payload.ProtoPayload{Msg: request}
+	resPayload, err := seldonPredictorProcess.RepositoryIndex(g.predictor, &reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := resPayload.GetPayload().(*inference.RepositoryIndexResponse)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected response type %T", resPayload.GetPayload())
+	}
+	return res, nil
 }
 
 func (g GrpcKFServingServer) RepositoryModelLoad(ctx context.Context, request *inference.RepositoryModelLoadRequest) (*inference.RepositoryModelLoadResponse, error) {
-	panic("Not Implemented")
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), request.GetModelName())
+	reqPayload := payload.ProtoPayload{Msg: request}
+	resPayload, err := seldonPredictorProcess.RepositoryModelLoad(g.predictor, request.GetModelName(), &reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := resPayload.GetPayload().(*inference.RepositoryModelLoadResponse)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected response type %T", resPayload.GetPayload())
+	}
+	return res, nil
 }
 
 func (g GrpcKFServingServer) RepositoryModelUnload(ctx context.Context, request *inference.RepositoryModelUnloadRequest) (*inference.RepositoryModelUnloadResponse, error) {
-	panic("Not Implemented")
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), request.GetModelName())
+	reqPayload := payload.ProtoPayload{Msg: request}
+	resPayload, err := seldonPredictorProcess.RepositoryModelUnload(g.predictor, request.GetModelName(), &reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := resPayload.GetPayload().(*inference.RepositoryModelUnloadResponse)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unexpected response type %T", resPayload.GetPayload())
+	}
+	return res, nil
 }
 
 func (g GrpcKFServingServer) SystemSharedMemoryStatus(ctx context.Context, request *inference.SystemSharedMemoryStatusRequest) (*inference.SystemSharedMemoryStatusResponse, error) {
@@ -3,6 +3,7 @@ package seldon
 import (
 	"context"
 	"github.com/go-logr/logr"
+	proto2 "github.com/golang/protobuf/proto"
 	empty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/grpc"
@@ -12,25 +13,28 @@ import (
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	protoGrpc "google.golang.org/grpc"
 	protoGrpcMetadata "google.golang.org/grpc/metadata"
+	"net/http"
 	"net/url"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type GrpcSeldonServer struct {
-	Client    client.SeldonApiClient
-	predictor *v1.PredictorSpec
-	Log       logr.Logger
-	ServerUrl *url.URL
-	Namespace string
+	Client       client.SeldonApiClient
+	predictor    *v1.PredictorSpec
+	Log          logr.Logger
+	ServerUrl    *url.URL
+	Namespace    string
+	StreamWindow int
 }
 
 func NewGrpcSeldonServer(predictor *v1.PredictorSpec, client client.SeldonApiClient, serverUrl *url.URL, namespace string) *GrpcSeldonServer {
 	return &GrpcSeldonServer{
-		Client:    client,
-		predictor: predictor,
-		Log:       logf.Log.WithName("SeldonGrpcApi"),
-		ServerUrl: serverUrl,
-		Namespace: namespace,
+		Client:       client,
+		predictor:    predictor,
+		Log:          logf.Log.WithName("SeldonGrpcApi"),
+		ServerUrl:    serverUrl,
+		Namespace:    namespace,
+		StreamWindow: grpc.DefaultStreamWindow,
 	}
 }
 
@@ -41,7 +45,7 @@ func (g GrpcSeldonServer) Predict(ctx context.Context, req *proto.SeldonMessage)
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, md, "")
 	reqPayload := payload.ProtoPayload{Msg: req}
-	resPayload, err := seldonPredictorProcess.Predict(&g.predictor.Graph, &reqPayload)
+	resPayload, err := seldonPredictorProcess.PredictGraph(g.predictor, &reqPayload)
 	if err != nil {
 		g.Log.Error(err, "Failed to call predict")
 		return payloadToMessage(resPayload), err
@@ -49,13 +53,54 @@ func (g GrpcSeldonServer) Predict(ctx context.Context, req *proto.SeldonMessage)
 	return payloadToMessage(resPayload), nil
 }
 
+// StreamPredict runs each message of a stream through the graph. Every message is given its own puid, taken
+// from its meta if set, which is returned in the meta of its response. Failed predictions are returned as
+// responses with a failure status so the stream stays open.
+func (g GrpcSeldonServer) StreamPredict(stream proto.Seldon_StreamPredictServer) error {
+	ctx := stream.Context()
+	md := grpc.CollectMetadata(ctx)
+	return grpc.ProcessStream(ctx, g.StreamWindow,
+		func() (interface{}, error) {
+			return stream.Recv()
+		},
+		func(req interface{}) interface{} {
+			return g.streamPredict(ctx, md, req.(*proto.SeldonMessage))
+		},
+		func(res interface{}) error {
+			return stream.Send(res.(*proto.SeldonMessage))
+		})
+}
+
+func (g GrpcSeldonServer) streamPredict(ctx context.Context, md protoGrpcMetadata.MD, req *proto.SeldonMessage) *proto.SeldonMessage {
+	md = grpc.StreamMessageMetadata(md, req.GetMeta().GetPuid())
+	puid := md.Get(payload.SeldonPUIDHeader)[0]
+	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, puid)
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, md, "")
+	reqPayload := payload.ProtoPayload{Msg: req}
+	resPayload, err := seldonPredictorProcess.PredictGraph(g.predictor, &reqPayload)
+	var res *proto.SeldonMessage
+	if err != nil {
+		g.Log.Error(err, "Failed to call predict", "puid", puid)
+		res = &proto.SeldonMessage{Status: &proto.Status{Code: http.StatusInternalServerError, Info: err.Error(), Status: proto.Status_FAILURE}}
+	} else if m := payloadToMessage(resPayload); m != nil {
+		res = proto2.Clone(m).(*proto.SeldonMessage)
+	} else {
+		res = &proto.SeldonMessage{}
+	}
+	if res.Meta == nil {
+		res.Meta = &proto.Meta{}
+	}
+	res.Meta.Puid = puid
+	return res
+}
+
 func (g GrpcSeldonServer) SendFeedback(ctx context.Context, req *proto.Feedback) (*proto.SeldonMessage, error) {
 	md := grpc.CollectMetadata(ctx)
 	header := protoGrpcMetadata.Pairs(payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	protoGrpc.SetHeader(ctx, header)
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, md, "")
 	reqPayload := payload.ProtoPayload{Msg: req}
-	resPayload, err := seldonPredictorProcess.Feedback(&g.predictor.Graph, &reqPayload)
+	resPayload, err := seldonPredictorProcess.FeedbackGraph(g.predictor, &reqPayload)
 	if err != nil {
 		g.Log.Error(err, "Failed to call feedback")
 		return payloadToMessage(resPayload), err
@@ -65,7 +110,7 @@ func (g GrpcSeldonServer) SendFeedback(ctx context.Context, req *proto.Feedback)
 
 func (g GrpcSeldonServer) ModelMetadata(ctx context.Context, req *proto.SeldonModelMetadataRequest) (*proto.SeldonModelMetadata, error) {
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), req.GetName())
-	resPayload, err := seldonPredictorProcess.Metadata(&g.predictor.Graph, req.GetName(), nil)
+	resPayload, err := seldonPredictorProcess.Metadata(g.predictor, req.GetName(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package seldon
 
 import (
 	"context"
+	"fmt"
 	"github.com/golang/protobuf/jsonpb"
 	empty "github.com/golang/protobuf/ptypes/empty"
 	. "github.com/onsi/gomega"
@@ -9,6 +10,8 @@ import (
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/test"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	protoGrpc "google.golang.org/grpc"
+	"io"
 	"net/url"
 	"testing"
 )
@@ -45,6 +48,71 @@ func TestPredict(t *testing.T) {
 	g.Expect(res.GetData().GetNdarray().Values[0].GetListValue().Values[1].GetNumberValue()).Should(Equal(2.0))
 }
 
+type testStreamPredictServer struct {
+	protoGrpc.ServerStream
+	reqs []*proto.SeldonMessage
+	res  []*proto.SeldonMessage
+}
+
+func (s *testStreamPredictServer) Context() context.Context {
+	return context.TODO()
+}
+
+func (s *testStreamPredictServer) Recv() (*proto.SeldonMessage, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *testStreamPredictServer) Send(m *proto.SeldonMessage) error {
+	s.res = append(s.res, m)
+	return nil
+}
+
+func TestStreamPredict(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	url, _ := url.Parse("http://localhost")
+	server := NewGrpcSeldonServer(&p, &test.SeldonMessageTestClient{}, url, "default")
+
+	stream := &testStreamPredictServer{}
+	for i := 0; i < 5; i++ {
+		var sm proto.SeldonMessage
+		err := jsonpb.UnmarshalString(fmt.Sprintf(`{"data":{"ndarray":[[%d]]}}`, i), &sm)
+		g.Expect(err).Should(BeNil())
+		stream.reqs = append(stream.reqs, &sm)
+	}
+	stream.reqs[2].Meta = &proto.Meta{Puid: "puid-2"}
+
+	err := server.StreamPredict(stream)
+	g.Expect(err).To(BeNil())
+	g.Expect(stream.res).Should(HaveLen(5))
+	puids := map[string]bool{}
+	for i, res := range stream.res {
+		g.Expect(res.GetData().GetNdarray().Values[0].GetListValue().Values[0].GetNumberValue()).Should(Equal(float64(i)))
+		g.Expect(res.GetMeta().GetPuid()).ShouldNot(BeEmpty())
+		puids[res.GetMeta().GetPuid()] = true
+	}
+	g.Expect(puids).Should(HaveLen(5))
+	g.Expect(stream.res[2].GetMeta().GetPuid()).Should(Equal("puid-2"))
+}
+
 func TestFeedback(t *testing.T) {
 	t.Logf("Started")
 	g := NewGomegaWithT(t)
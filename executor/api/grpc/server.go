@@ -12,8 +12,10 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/k8s"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
@@ -55,8 +57,13 @@ func CreateGrpcServer(spec *v1.PredictorSpec, deploymentName string, annotations
 	}
 
 	interceptors := []grpc.UnaryServerInterceptor{metric.NewServerMetrics(spec, deploymentName).UnaryServerInterceptor()}
-	if opentracing.IsGlobalTracerRegistered() {
+	// OpenTelemetry registers a bridge as the OpenTracing tracer, so is checked first
+	if tracing.OpenTelemetryEnabled() {
+		interceptors = append(interceptors, otelgrpc.UnaryServerInterceptor())
+		opts = append(opts, grpc.StreamInterceptor(otelgrpc.StreamServerInterceptor()))
+	} else if opentracing.IsGlobalTracerRegistered() {
 		interceptors = append(interceptors, grpc_opentracing.UnaryServerInterceptor())
+		opts = append(opts, grpc.StreamInterceptor(grpc_opentracing.StreamServerInterceptor()))
 	}
 	opts = append(opts, grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(interceptors...)))
 
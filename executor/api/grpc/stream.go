@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	guuid "github.com/google/uuid"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultStreamWindow is the number of messages of a stream which are processed concurrently. No further
+// messages are read from a stream until the oldest response has been sent, so slow graphs push back on
+// clients through gRPC flow control.
+const DefaultStreamWindow = 16
+
+// GetStreamWindowFromAnnotations returns the stream window set by annotation or DefaultStreamWindow.
+func GetStreamWindowFromAnnotations(annotations map[string]string) (int, error) {
+	val := annotations[k8s.ANNOTATION_GRPC_STREAM_WINDOW]
+	if val == "" {
+		return DefaultStreamWindow, nil
+	}
+	window, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, err
+	}
+	if window < 1 {
+		window = 1
+	}
+	return window, nil
+}
+
+// ProcessStream reads messages with recv until the client closes the stream, processes up to window of them
+// concurrently and sends their responses in the order the messages were received.
+func ProcessStream(ctx context.Context, window int, recv func() (interface{}, error), process func(interface{}) interface{}, send func(interface{}) error) error {
+	if window < 1 {
+		window = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make(chan chan interface{}, window-1)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(responses)
+		for {
+			req, err := recv()
+			if err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			res := make(chan interface{}, 1)
+			select {
+			case responses <- res:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				res <- process(req)
+			}()
+		}
+	}()
+
+	for res := range responses {
+		if err := send(<-res); err != nil {
+			return err
+		}
+	}
+	select {
+	case err := <-recvErr:
+		return err
+	default:
+		return nil
+	}
+}
+
+// StreamMessageMetadata copies the metadata of a stream for one of its messages, which is given its own puid.
+// A new puid is created if puid is empty.
+func StreamMessageMetadata(md metadata.MD, puid string) metadata.MD {
+	if puid == "" {
+		puid = guuid.New().String()
+	}
+	msgMd := md.Copy()
+	msgMd.Set(payload.SeldonPUIDHeader, puid)
+	return msgMd
+}
@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestProcessStreamOrderAndWindow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	const messages = 20
+	const window = 4
+	next := 0
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+	var sent []int
+	err := ProcessStream(context.Background(), window,
+		func() (interface{}, error) {
+			if next == messages {
+				return nil, io.EOF
+			}
+			next++
+			return next, nil
+		},
+		func(req interface{}) interface{} {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			// Later messages finish first
+			time.Sleep(time.Duration(messages-req.(int)) * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return req
+		},
+		func(res interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, res.(int))
+			return nil
+		})
+	g.Expect(err).Should(BeNil())
+	g.Expect(sent).Should(HaveLen(messages))
+	for i, n := range sent {
+		g.Expect(n).Should(Equal(i + 1))
+	}
+	g.Expect(atomic.LoadInt32(&maxInFlight)).Should(BeNumerically("<=", window))
+}
+
+func TestProcessStreamErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	recvErr := fmt.Errorf("connection reset")
+	err := ProcessStream(context.Background(), 2,
+		func() (interface{}, error) { return nil, recvErr },
+		func(req interface{}) interface{} { return req },
+		func(res interface{}) error { return nil })
+	g.Expect(err).Should(Equal(recvErr))
+
+	sendErr := fmt.Errorf("client gone")
+	err = ProcessStream(context.Background(), 2,
+		func() (interface{}, error) { return 1, nil },
+		func(req interface{}) interface{} { return req },
+		func(res interface{}) error { return sendErr })
+	g.Expect(err).Should(Equal(sendErr))
+}
+
+func TestStreamMessageMetadata(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	md := metadata.Pairs(payload.SeldonPUIDHeader, "stream", "x-user", "a")
+	msgMd := StreamMessageMetadata(md, "")
+	g.Expect(msgMd.Get(payload.SeldonPUIDHeader)[0]).ShouldNot(Equal("stream"))
+	g.Expect(msgMd.Get("x-user")).Should(Equal([]string{"a"}))
+	g.Expect(md.Get(payload.SeldonPUIDHeader)).Should(Equal([]string{"stream"}))
+	g.Expect(StreamMessageMetadata(md, "msg").Get(payload.SeldonPUIDHeader)).Should(Equal([]string{"msg"}))
+}
+
+func TestGetStreamWindowFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	window, err := GetStreamWindowFromAnnotations(map[string]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(window).Should(Equal(DefaultStreamWindow))
+	window, err = GetStreamWindowFromAnnotations(map[string]string{k8s.ANNOTATION_GRPC_STREAM_WINDOW: "3"})
+	g.Expect(err).Should(BeNil())
+	g.Expect(window).Should(Equal(3))
+	_, err = GetStreamWindowFromAnnotations(map[string]string{k8s.ANNOTATION_GRPC_STREAM_WINDOW: "x"})
+	g.Expect(err).ShouldNot(BeNil())
+}
@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"strings"
+	"sync"
+
+	guuid "github.com/google/uuid"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+// KeyCorrelationId identifies a full graph call to a node, so the node's reply can be matched to the call. The
+// proxy copies it into the reply.
+const KeyCorrelationId = "seldon-correlation-id"
+
+type rpcReply struct {
+	msg payload.SeldonPayload
+	err error
+}
+
+type rpcReceiver struct {
+	puid  string
+	reply chan rpcReply
+}
+
+// receivers holds the calls waiting for a reply, by correlation id. A call removes its receiver whenever it
+// returns, so receivers of calls that timed out or were cancelled aren't left behind.
+type receivers struct {
+	mu sync.Mutex
+	// replica prefixes the correlation ids of this replica's calls, as every replica consumes the replies of all
+	replica string
+	calls   map[string]*rpcReceiver
+}
+
+func newReceivers(replica string) *receivers {
+	return &receivers{
+		replica: replica,
+		calls:   make(map[string]*rpcReceiver),
+	}
+}
+
+// add registers a call, returning its correlation id and the channel its reply will be sent to.
+func (r *receivers) add(puid string) (string, <-chan rpcReply) {
+	id := r.replica + "/" + guuid.New().String()
+	rec := &rpcReceiver{puid: puid, reply: make(chan rpcReply, 1)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[id] = rec
+	return id, rec.reply
+}
+
+func (r *receivers) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.calls, id)
+}
+
+// owns returns whether a reply is for a call of this replica. Replies without a correlation id, from proxies of
+// earlier versions, may be.
+func (r *receivers) owns(id string) bool {
+	return id == "" || strings.HasPrefix(id, r.replica+"/")
+}
+
+// deliver passes a reply to the call waiting for it, matching replies without a correlation id by puid. It returns
+// false if no call is waiting for the reply.
+func (r *receivers) deliver(id, puid string, reply rpcReply) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == "" {
+		for callId, rec := range r.calls {
+			if rec.puid == puid {
+				id = callId
+				break
+			}
+		}
+	}
+	rec, ok := r.calls[id]
+	if !ok {
+		return false
+	}
+	delete(r.calls, id)
+	// The channel is buffered and only sent to once, so this never blocks
+	rec.reply <- reply
+	return true
+}
+
+func (r *receivers) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls)
+}
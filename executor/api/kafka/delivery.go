@@ -0,0 +1,264 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/seldonio/seldon-core/executor/api/client"
+)
+
+const (
+	KeyError           = "seldon-error"
+	KeyErrorStage      = "seldon-error-stage"
+	KeySourceTopic     = "seldon-source-topic"
+	KeySourcePartition = "seldon-source-partition"
+	KeySourceOffset    = "seldon-source-offset"
+
+	ErrorStageUnmarshal = "unmarshal"
+	ErrorStagePredict   = "predict"
+	// ErrorStageDeliver counts messages whose results couldn't be produced, which aren't dead lettered
+	ErrorStageDeliver = "deliver"
+)
+
+const (
+	DefaultDrainTimeout = 30 * time.Second
+	transactionInterval = 100 * time.Millisecond
+	transactionTimeout  = 30 * time.Second
+)
+
+// kafkaResult holds the messages to produce for a consumed message, its response or dead letter.
+type kafkaResult struct {
+	source   kafka.TopicPartition
+	messages []*kafka.Message
+}
+
+// flushRequest asks for the processed messages to be committed, and the results of revoked partitions to be
+// dropped.
+type flushRequest struct {
+	revoked []kafka.TopicPartition
+	done    chan struct{}
+}
+
+// predictFailed returns the messages to produce for a request whose prediction failed. Requests the graph rejected
+// are dead lettered. Other failures may not happen again, so with at-least-once or exactly-once delivery the server
+// stops without committing the request, which is consumed again on restart, and predictFailed returns true.
+func (ks *SeldonKafkaServer) predictFailed(msg *kafka.Message, err error) ([]*kafka.Message, bool) {
+	if client.IsRejected(err) {
+		return ks.deadLetter(msg, ErrorStagePredict, err), false
+	}
+	ks.Log.Error(err, "Failed to predict", "partition", msg.TopicPartition.Partition, "offset", msg.TopicPartition.Offset)
+	ks.metrics.FailedCounter.WithLabelValues(ks.TopicIn, ErrorStagePredict).Inc()
+	if ks.Delivery == DeliveryAtMostOnce {
+		return nil, false
+	}
+	ks.fail(fmt.Errorf("failed to predict message at partition %d offset %s: %w", msg.TopicPartition.Partition, msg.TopicPartition.Offset, err))
+	return nil, true
+}
+
+// deadLetter returns the message to send to the dead letter topic for a request that can't be processed. The
+// request is sent unchanged, with headers describing the failure and where the request came from.
+func (ks *SeldonKafkaServer) deadLetter(msg *kafka.Message, stage string, err error) []*kafka.Message {
+	ks.Log.Error(err, "Failed to process message", "stage", stage, "partition", msg.TopicPartition.Partition, "offset", msg.TopicPartition.Offset)
+	ks.metrics.FailedCounter.WithLabelValues(ks.TopicIn, stage).Inc()
+	if ks.TopicDLQ == "" {
+		return nil
+	}
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: KeyError, Value: []byte(err.Error())},
+		kafka.Header{Key: KeyErrorStage, Value: []byte(stage)},
+		kafka.Header{Key: KeySourceTopic, Value: []byte(ks.TopicIn)},
+		kafka.Header{Key: KeySourcePartition, Value: []byte(strconv.Itoa(int(msg.TopicPartition.Partition)))},
+		kafka.Header{Key: KeySourceOffset, Value: []byte(msg.TopicPartition.Offset.String())},
+	)
+	return []*kafka.Message{{
+		TopicPartition: kafka.TopicPartition{Topic: &ks.TopicDLQ, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers:        headers,
+	}}
+}
+
+// deliver produces the messages for a consumed message with the server's delivery guarantee.
+func (ks *SeldonKafkaServer) deliver(source kafka.TopicPartition, messages []*kafka.Message) {
+	switch ks.Delivery {
+	case DeliveryExactlyOnce:
+		ks.results <- &kafkaResult{source: source, messages: messages}
+	case DeliveryAtLeastOnce:
+		deliveryChan := make(chan kafka.Event, len(messages))
+		for _, msg := range messages {
+			if err := ks.Producer.Produce(msg, deliveryChan); err != nil {
+				ks.metrics.FailedCounter.WithLabelValues(ks.TopicIn, ErrorStageDeliver).Inc()
+				ks.fail(fmt.Errorf("failed to produce to %s: %w", *msg.TopicPartition.Topic, err))
+				return
+			}
+		}
+		for range messages {
+			m := (<-deliveryChan).(*kafka.Message)
+			if m.TopicPartition.Error != nil {
+				ks.metrics.FailedCounter.WithLabelValues(ks.TopicIn, ErrorStageDeliver).Inc()
+				ks.fail(fmt.Errorf("failed to deliver to %s: %w", *m.TopicPartition.Topic, m.TopicPartition.Error))
+				return
+			}
+		}
+		ks.storeOffset(source)
+	default:
+		for _, msg := range messages {
+			if err := ks.Producer.Produce(msg, nil); err != nil {
+				ks.metrics.FailedCounter.WithLabelValues(ks.TopicIn, ErrorStageDeliver).Inc()
+				ks.Log.Error(err, "Failed to produce response")
+			}
+		}
+		ks.offsets.done(source)
+	}
+}
+
+// storeOffset marks a message delivered, storing its partition's offset to be auto committed once every earlier
+// message of the partition has been delivered too.
+func (ks *SeldonKafkaServer) storeOffset(source kafka.TopicPartition) {
+	ks.offsetsMu.Lock()
+	defer ks.offsetsMu.Unlock()
+	if tp, ok := ks.offsets.done(source); ok {
+		if _, err := ks.consumer.StoreOffsets([]kafka.TopicPartition{tp}); err != nil {
+			ks.Log.Error(err, "Failed to store offset", "partition", tp.Partition, "offset", tp.Offset)
+		}
+	}
+}
+
+// fail stops the server after an error that would lose messages if consumption carried on. Uncommitted messages
+// are consumed again when the server restarts.
+func (ks *SeldonKafkaServer) fail(err error) {
+	select {
+	case ks.errors <- err:
+	default:
+	}
+}
+
+// drain waits for the messages in flight to be processed and commits their offsets.
+func (ks *SeldonKafkaServer) drain(revoked []kafka.TopicPartition) {
+	if !ks.offsets.waitIdle(ks.DrainTimeout) {
+		ks.Log.Info("Timed out draining messages", "inFlight", ks.offsets.inFlight())
+	}
+	switch ks.Delivery {
+	case DeliveryAtLeastOnce:
+		if _, err := ks.consumer.Commit(); err != nil {
+			if kerr, ok := err.(kafka.Error); !ok || kerr.Code() != kafka.ErrNoOffset {
+				ks.Log.Error(err, "Failed to commit offsets")
+			}
+		}
+	case DeliveryExactlyOnce:
+		req := flushRequest{revoked: revoked, done: make(chan struct{})}
+		select {
+		case ks.flushes <- req:
+			<-req.done
+		case <-ks.quit:
+		}
+	}
+}
+
+// rebalance drains the messages of revoked partitions, so their offsets are committed before another consumer
+// takes them over.
+func (ks *SeldonKafkaServer) rebalance(c *kafka.Consumer, event kafka.Event) error {
+	if e, ok := event.(kafka.RevokedPartitions); ok {
+		ks.Log.Info("Partitions revoked", "partitions", e.Partitions)
+		ks.drain(e.Partitions)
+		ks.offsets.remove(e.Partitions)
+	}
+	return nil
+}
+
+// commitTransactions produces the results of processed messages and commits their offsets in transactions, so
+// consumers reading committed messages see each response exactly once.
+func (ks *SeldonKafkaServer) commitTransactions() {
+	pending := make(map[partitionKey][]*kafkaResult)
+	offsets := make(map[partitionKey]kafka.TopicPartition)
+	// After a failed transaction nothing more is committed, as later offsets would skip its messages
+	failed := false
+	ticker := time.NewTicker(transactionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ks.quit:
+			return
+		case r := <-ks.results:
+			key := newPartitionKey(r.source)
+			pending[key] = append(pending[key], r)
+			if tp, ok := ks.offsets.done(r.source); ok {
+				offsets[key] = tp
+			}
+		case <-ticker.C:
+			failed = failed || !ks.commitTransaction(pending, offsets)
+		case req := <-ks.flushes:
+			failed = failed || !ks.commitTransaction(pending, offsets)
+			for _, tp := range req.revoked {
+				delete(pending, newPartitionKey(tp))
+			}
+			close(req.done)
+		}
+	}
+}
+
+// commitTransaction commits the results of the messages below the offsets to commit and removes them from pending,
+// returning false if the transaction failed.
+func (ks *SeldonKafkaServer) commitTransaction(pending map[partitionKey][]*kafkaResult, offsets map[partitionKey]kafka.TopicPartition) bool {
+	if len(offsets) == 0 {
+		return true
+	}
+	err := ks.transaction(pending, offsets)
+	if err != nil {
+		ks.fail(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	for key, tp := range offsets {
+		var remaining []*kafkaResult
+		for _, r := range pending[key] {
+			if r.source.Offset >= tp.Offset {
+				remaining = append(remaining, r)
+			}
+		}
+		if len(remaining) == 0 {
+			delete(pending, key)
+		} else {
+			pending[key] = remaining
+		}
+		delete(offsets, key)
+	}
+	return err == nil
+}
+
+func (ks *SeldonKafkaServer) transaction(pending map[partitionKey][]*kafkaResult, offsets map[partitionKey]kafka.TopicPartition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), transactionTimeout)
+	defer cancel()
+	if err := ks.Producer.BeginTransaction(); err != nil {
+		return err
+	}
+	commit := make([]kafka.TopicPartition, 0, len(offsets))
+	for key, tp := range offsets {
+		for _, r := range pending[key] {
+			if r.source.Offset >= tp.Offset {
+				continue
+			}
+			for _, msg := range r.messages {
+				if err := ks.Producer.Produce(msg, nil); err != nil {
+					_ = ks.Producer.AbortTransaction(ctx)
+					return err
+				}
+			}
+		}
+		commit = append(commit, tp)
+	}
+	metadata, err := ks.consumer.GetConsumerGroupMetadata()
+	if err == nil {
+		err = ks.Producer.SendOffsetsToTransaction(ctx, commit, metadata)
+	}
+	if err == nil {
+		err = ks.Producer.CommitTransaction(ctx)
+	}
+	if err != nil {
+		_ = ks.Producer.AbortTransaction(ctx)
+		return err
+	}
+	return nil
+}
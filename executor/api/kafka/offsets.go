@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+func newPartitionKey(tp kafka.TopicPartition) partitionKey {
+	key := partitionKey{partition: tp.Partition}
+	if tp.Topic != nil {
+		key.topic = *tp.Topic
+	}
+	return key
+}
+
+type partitionOffsets struct {
+	// offsets of the messages in flight, in the order they were consumed
+	offsets []kafka.Offset
+	done    map[kafka.Offset]bool
+}
+
+// offsetTracker tracks the messages being processed. Workers process messages concurrently, so a message's offset
+// can only be committed once every message consumed before it from the same partition has been processed.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[partitionKey]*partitionOffsets),
+	}
+}
+
+// add records a consumed message as in flight.
+func (t *offsetTracker) add(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := newPartitionKey(tp)
+	p, ok := t.partitions[key]
+	if !ok {
+		p = &partitionOffsets{done: make(map[kafka.Offset]bool)}
+		t.partitions[key] = p
+	}
+	p.offsets = append(p.offsets, tp.Offset)
+}
+
+// done records a message as processed. If it completes a run of processed messages at the start of its partition
+// it returns the offset to commit for the partition, which is the offset of the next message to consume.
+func (t *offsetTracker) done(tp kafka.TopicPartition) (kafka.TopicPartition, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := newPartitionKey(tp)
+	p, ok := t.partitions[key]
+	if !ok {
+		// The partition was revoked
+		return kafka.TopicPartition{}, false
+	}
+	p.done[tp.Offset] = true
+	committed := 0
+	for _, offset := range p.offsets {
+		if !p.done[offset] {
+			break
+		}
+		delete(p.done, offset)
+		committed++
+	}
+	if committed == 0 {
+		return kafka.TopicPartition{}, false
+	}
+	next := p.offsets[committed-1] + 1
+	p.offsets = p.offsets[committed:]
+	if len(p.offsets) == 0 {
+		delete(t.partitions, key)
+	}
+	return kafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition, Offset: next}, true
+}
+
+// remove stops tracking the messages of revoked partitions.
+func (t *offsetTracker) remove(partitions []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tp := range partitions {
+		delete(t.partitions, newPartitionKey(tp))
+	}
+}
+
+func (t *offsetTracker) inFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, p := range t.partitions {
+		n += len(p.offsets)
+	}
+	return n
+}
+
+// waitIdle waits for every message in flight to be processed, returning false if the timeout passes first.
+func (t *offsetTracker) waitIdle(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for t.inFlight() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	. "github.com/onsi/gomega"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	g := NewGomegaWithT(t)
+	topic := "in"
+	tp := func(partition int32, offset kafka.Offset) kafka.TopicPartition {
+		return kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}
+	}
+
+	tracker := newOffsetTracker()
+	for _, offset := range []kafka.Offset{10, 11, 12} {
+		tracker.add(tp(0, offset))
+	}
+	tracker.add(tp(1, 5))
+	g.Expect(tracker.inFlight()).To(Equal(4))
+
+	// Offsets are only committed once every earlier message is processed
+	_, ok := tracker.done(tp(0, 11))
+	g.Expect(ok).To(BeFalse())
+	commit, ok := tracker.done(tp(0, 10))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(commit).To(Equal(tp(0, 12)))
+	commit, ok = tracker.done(tp(1, 5))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(commit).To(Equal(tp(1, 6)))
+	g.Expect(tracker.inFlight()).To(Equal(1))
+	g.Expect(tracker.waitIdle(20 * time.Millisecond)).To(BeFalse())
+
+	// Messages of revoked partitions are no longer tracked
+	tracker.remove([]kafka.TopicPartition{tp(0, kafka.OffsetInvalid)})
+	_, ok = tracker.done(tp(0, 12))
+	g.Expect(ok).To(BeFalse())
+	g.Expect(tracker.waitIdle(20 * time.Millisecond)).To(BeTrue())
+}
@@ -2,12 +2,14 @@ package kafka
 
 import (
 	"context"
+	"fmt"
 	"github.com/cloudevents/sdk-go/pkg/bindings/http"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/go-logr/logr"
 	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/rest"
+	"github.com/seldonio/seldon-core/executor/api/util"
 	"os"
 	"os/signal"
 	"syscall"
@@ -48,11 +50,112 @@ func (kp *KafkaProxy) getTopicIn() string {
 }
 
 func (kp *KafkaProxy) getDefaultTopicResponse() string {
-	return kp.Hostname + "." + kp.ModelName + "." + kp.PredictorName + "." + kp.DeploymentName + "." + kp.Namespace
+	return kp.getTopicIn() + ".reply"
+}
+
+// handleRequest calls the model with a request and returns the reply for the caller. If the call fails the reply
+// has the error in its headers, so the caller doesn't wait for a reply that never comes.
+func (kp *KafkaProxy) handleRequest(e *kafka.Message) *kafka.Message {
+	kp.Log.Info("Message", "Partition", e.TopicPartition)
+	if e.Headers != nil {
+		kp.Log.Info("Received", "headers", e.Headers)
+	}
+
+	puid := ""
+	responseTopic := ""
+	method := ""
+	correlationId := ""
+	for _, header := range e.Headers {
+		switch header.Key {
+		case payload.SeldonPUIDHeader:
+			puid = string(header.Value)
+		case KeyTopicResponse:
+			responseTopic = string(header.Value)
+		case KeyMethod:
+			method = string(header.Value)
+		case KeyCorrelationId:
+			correlationId = string(header.Value)
+		default:
+			kp.Log.Info("Skipping", "header", string(header.Value))
+		}
+	}
+	kp.Log.Info("Extracted headers", payload.SeldonPUIDHeader, puid, KeyTopicResponse, responseTopic, KeyMethod, method)
+	if responseTopic == "" {
+		responseTopic = kp.getDefaultTopicResponse()
+	}
+	if puid == "" {
+		kp.Log.Info("No puid found")
+		puid = "0"
+	}
+	if method == "" {
+		kp.Log.Info("No method found will use default")
+		method = client.SeldonPredictPath
+	}
+	kp.Log.Info("Extracted headers with defaults", payload.SeldonPUIDHeader, puid, KeyTopicResponse, responseTopic, KeyMethod, method)
+
+	reply := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &responseTopic, Partition: kafka.PartitionAny},
+		Headers:        []kafka.Header{{Key: payload.SeldonPUIDHeader, Value: []byte(puid)}},
+	}
+	if correlationId != "" {
+		reply.Headers = append(reply.Headers, kafka.Header{Key: KeyCorrelationId, Value: []byte(correlationId)})
+	}
+	resBytes, err := kp.call(e, puid, method)
+	if err != nil {
+		kp.Log.Error(err, "Failed prediction")
+		reply.Headers = append(reply.Headers, kafka.Header{Key: KeyError, Value: []byte(err.Error())})
+		return reply
+	}
+	reply.Value = resBytes
+	return reply
+}
+
+func (kp *KafkaProxy) call(e *kafka.Message, puid string, method string) ([]byte, error) {
+	headers := collectHeaders(e.Headers)
+	ctx := context.Background()
+	// Add Seldon Puid to Context
+	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, puid)
+
+	// Assume JSON if no content type - should maybe be application/octet-stream?
+	contentType := rest.ContentTypeJSON
+	if ct, ok := headers[http.ContentType]; ok {
+		if len(ct) == 1 {
+			contentType = ct[0]
+		}
+	}
+	reqPayload, err := kp.Client.Unmarshall(e.Value, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshall payload: %w", err)
+	}
+
+	var resPayload payload.SeldonPayload
+	switch method {
+	case client.SeldonPredictPath:
+		resPayload, err = kp.Client.Predict(ctx, kp.ModelName, kp.Hostname, kp.Port, reqPayload, headers)
+	case client.SeldonTransformInputPath:
+		resPayload, err = kp.Client.TransformInput(ctx, kp.ModelName, kp.Hostname, kp.Port, reqPayload, headers)
+	case client.SeldonTransformOutputPath:
+		resPayload, err = kp.Client.TransformOutput(ctx, kp.ModelName, kp.Hostname, kp.Port, reqPayload, headers)
+	case client.SeldonFeedbackPath:
+		resPayload, err = kp.Client.Feedback(ctx, kp.ModelName, kp.Hostname, kp.Port, reqPayload, headers)
+	case client.SeldonCombinePath:
+		var msgs []payload.SeldonPayload
+		msgs, err = rest.ExtractSeldonMessagesFromJson(reqPayload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract payload: %w", err)
+		}
+		resPayload, err = kp.Client.Combine(ctx, kp.ModelName, kp.Hostname, kp.Port, msgs, headers)
+	default:
+		return nil, fmt.Errorf("unsupported method %s", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resPayload.GetBytes()
 }
 
 func (kp *KafkaProxy) Consume() error {
-	c, err := kafka.NewConsumer(&kafka.ConfigMap{
+	c, err := util.NewKafkaConsumer(kafka.ConfigMap{
 		"bootstrap.servers":     kp.Broker,
 		"broker.address.family": "v4",
 		"group.id":              kp.getGroupName(),
@@ -63,7 +166,7 @@ func (kp *KafkaProxy) Consume() error {
 	}
 	kp.Log.Info("Created", "consumer", c.String())
 
-	p, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": kp.Broker})
+	p, err := util.NewKafkaProducer(kafka.ConfigMap{"bootstrap.servers": kp.Broker}, kp.Log)
 	if err != nil {
 		return err
 	}
@@ -92,91 +195,17 @@ func (kp *KafkaProxy) Consume() error {
 
 			switch e := ev.(type) {
 			case *kafka.Message:
-				kp.Log.Info("Message", "Partition", e.TopicPartition)
-				if e.Headers != nil {
-					kp.Log.Info("Received", "headers", e.Headers)
-				}
-
-				puid := ""
-				responseTopic := ""
-				method := ""
-				for _, header := range e.Headers {
-					switch header.Key {
-					case payload.SeldonPUIDHeader:
-						puid = string(header.Value)
-					case KeyTopicResponse:
-						responseTopic = string(header.Value)
-					case KeyMethod:
-						method = string(header.Value)
-					default:
-						kp.Log.Info("Skipping", "header", string(header.Value))
-					}
-				}
-				kp.Log.Info("Extracted headers", payload.SeldonPUIDHeader, puid, KeyTopicResponse, responseTopic, KeyMethod, method)
-				if responseTopic == "" {
-					responseTopic = kp.getDefaultTopicResponse()
-				}
-				if puid == "" {
-					kp.Log.Info("No puid found")
-					puid = "0"
-				}
-				if method == "" {
-					kp.Log.Info("No method found will use default")
-					method = client.SeldonPredictPath
-				}
-				kp.Log.Info("Extracted headers with defaults", payload.SeldonPUIDHeader, puid, KeyTopicResponse, responseTopic, KeyMethod, method)
-
-				headers := collectHeaders(e.Headers)
-				ctx := context.Background()
-				// Add Seldon Puid to Context
-				ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, puid)
-
-				// Assume JSON if no content type - should maybe be application/octet-stream?
-				contentType := rest.ContentTypeJSON
-				if ct, ok := headers[http.ContentType]; ok {
-					if len(ct) == 1 {
-						contentType = ct[0]
-					}
-				}
-				reqPayload, err := kp.Client.Unmarshall(e.Value, contentType)
-				if err != nil {
-					kp.Log.Error(err, "Failed to unmarshall Payload")
-					continue
-				}
-
-				var resPayload payload.SeldonPayload
-
-				switch method {
-				case client.SeldonPredictPath:
-					resPayload, err = kp.Client.Predict(ctx, kp.ModelName, kp.Hostname, kp.Port, reqPayload, headers)
-				case client.SeldonCombinePath:
-					msgs, err := rest.ExtractSeldonMessagesFromJson(reqPayload)
-					if err != nil {
-						kp.Log.Error(err, "Failed to extract Payload")
-						continue
-					}
-					resPayload, err = kp.Client.Combine(ctx, kp.ModelName, kp.Hostname, kp.Port, msgs, headers)
-				}
-
-				if err != nil {
-					kp.Log.Error(err, "Failed prediction")
-					continue
-				}
-				resBytes, err := resPayload.GetBytes()
-				if err != nil {
-					kp.Log.Error(err, "Failed to get bytes from prediction response")
-				}
-
-				err = p.Produce(&kafka.Message{
-					TopicPartition: kafka.TopicPartition{Topic: &responseTopic, Partition: kafka.PartitionAny},
-					Value:          resBytes,
-					Headers:        []kafka.Header{{Key: payload.SeldonPUIDHeader, Value: []byte(puid)}},
-				}, nil)
+				reply := kp.handleRequest(e)
+				err = p.Produce(reply, nil)
 				if err != nil {
 					kp.Log.Error(err, "Failed to produce response")
 				}
-				kp.Log.Info("Produced message", "topic", responseTopic)
+				kp.Log.Info("Produced message", "topic", *reply.TopicPartition.Topic)
 
+			case kafka.OAuthBearerTokenRefresh:
+				if err := util.RefreshKafkaOAuthBearerToken(c); err != nil {
+					kp.Log.Error(err, "Failed to refresh OAUTHBEARER token")
+				}
 			case kafka.Error:
 				// Errors should generally be considered
 				// informational, the client will try to
@@ -1,11 +1,15 @@
 package kafka
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
 	"net/url"
 	"os"
 	"os/signal"
 	"reflect"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,6 +22,7 @@ import (
 	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon"
 	"github.com/seldonio/seldon-core/executor/api/grpc/tensorflow"
+	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/rest"
 	"github.com/seldonio/seldon-core/executor/api/util"
@@ -36,6 +41,24 @@ const (
 	ENV_KAFKA_OUTPUT_TOPIC = "KAFKA_OUTPUT_TOPIC"
 	ENV_KAFKA_FULL_GRAPH   = "KAFKA_FULL_GRAPH"
 	ENV_KAFKA_WORKERS      = "KAFKA_WORKERS"
+	ENV_KAFKA_DELIVERY     = "KAFKA_DELIVERY"
+	ENV_KAFKA_DLQ_TOPIC    = "KAFKA_DLQ_TOPIC"
+	ENV_KAFKA_BATCH_SIZE   = "KAFKA_BATCH_SIZE"
+	ENV_KAFKA_BATCH_WAIT   = "KAFKA_BATCH_WAIT_MS"
+	ENV_KAFKA_KEY_ORDERING = "KAFKA_KEY_ORDERING"
+)
+
+const DefaultBatchWait = 10 * time.Millisecond
+
+// Delivery guarantees of the kafka server
+const (
+	// DeliveryAtMostOnce auto commits offsets as messages are consumed, so messages in flight are lost on failure
+	DeliveryAtMostOnce = "at-most-once"
+	// DeliveryAtLeastOnce commits offsets once responses are produced, so messages in flight are consumed again
+	// after a failure
+	DeliveryAtLeastOnce = "at-least-once"
+	// DeliveryExactlyOnce produces responses and commits offsets in kafka transactions
+	DeliveryExactlyOnce = "exactly-once"
 )
 
 type SeldonKafkaServer struct {
@@ -48,14 +71,42 @@ type SeldonKafkaServer struct {
 	Broker         string
 	TopicIn        string
 	TopicOut       string
+	TopicDLQ       string
+	Delivery       string
+	DrainTimeout   time.Duration
 	ServerUrl      *url.URL
 	Workers        int
-	Log            logr.Logger
+	// BatchSize is the most messages a worker sends to the graph in one call, waiting up to BatchWait for them
+	BatchSize int
+	BatchWait time.Duration
+	// KeyOrdering processes messages with the same key in the order they were consumed
+	KeyOrdering bool
+	Log         logr.Logger
+
+	consumer  *kafka.Consumer
+	offsets   *offsetTracker
+	offsetsMu sync.Mutex
+	results   chan *kafkaResult
+	flushes   chan flushRequest
+	errors    chan error
+	quit      chan struct{}
+	metrics   *metric.KafkaMetrics
 }
 
-func NewKafkaServer(fullGraph bool, workers int, deploymentName, namespace, protocol, transport string, annotations map[string]string, serverUrl *url.URL, predictor *v1.PredictorSpec, broker, topicIn, topicOut string, log logr.Logger) (*SeldonKafkaServer, error) {
+func NewKafkaServer(fullGraph bool, workers int, deploymentName, namespace, protocol, transport string, annotations map[string]string, serverUrl *url.URL, predictor *v1.PredictorSpec, broker, topicIn, topicOut, topicDLQ, delivery string, log logr.Logger) (*SeldonKafkaServer, error) {
 	var apiClient client.SeldonApiClient
 	var err error
+	switch delivery {
+	case "":
+		delivery = DeliveryAtMostOnce
+	case DeliveryAtMostOnce, DeliveryAtLeastOnce, DeliveryExactlyOnce:
+	default:
+		return nil, fmt.Errorf("Unknown kafka delivery %s", delivery)
+	}
+	if delivery != DeliveryAtMostOnce && topicDLQ == "" {
+		// Requests that can't be processed would otherwise be committed without trace
+		return nil, fmt.Errorf("Kafka delivery %s needs a dead letter topic", delivery)
+	}
 	if fullGraph {
 		log.Info("Starting full graph kafka server")
 		apiClient = NewKafkaClient(serverUrl.Hostname(), deploymentName, namespace, protocol, transport, predictor, broker, log)
@@ -81,27 +132,21 @@ func NewKafkaServer(fullGraph bool, workers int, deploymentName, namespace, prot
 	var producerConfigMap = kafka.ConfigMap{"bootstrap.servers": broker,
 		"go.delivery.reports": false, // Need this othewise will get memory leak
 	}
-	if broker != "" {
-		if util.GetKafkaSecurityProtocol() == "SSL" {
-			sslKakfaServer := util.GetSslElements()
-			producerConfigMap["security.protocol"] = util.GetKafkaSecurityProtocol()
-			if sslKakfaServer.CACertFile != "" && sslKakfaServer.ClientCertFile != "" {
-				producerConfigMap["ssl.ca.location"] = sslKakfaServer.CACertFile
-				producerConfigMap["ssl.key.location"] = sslKakfaServer.ClientKeyFile
-				producerConfigMap["ssl.certificate.location"] = sslKakfaServer.ClientCertFile
-			}
-			if sslKakfaServer.CACert != "" && sslKakfaServer.ClientCert != "" {
-				producerConfigMap["ssl.ca.pem"] = sslKakfaServer.CACert
-				producerConfigMap["ssl.key.pem"] = sslKakfaServer.ClientKey
-				producerConfigMap["ssl.certificate.pem"] = sslKakfaServer.ClientCert
-			}
-			producerConfigMap["ssl.key.password"] = sslKakfaServer.ClientKeyPass // Key password, if any
-
+	switch delivery {
+	case DeliveryAtLeastOnce:
+		// Delivery reports are sent to a channel for each response, so offsets are stored once it is delivered
+		producerConfigMap["go.delivery.reports"] = true
+		producerConfigMap["enable.idempotence"] = true
+	case DeliveryExactlyOnce:
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
 		}
+		producerConfigMap["transactional.id"] = predictor.Name + "." + deploymentName + "." + namespace + "." + hostname
 	}
 	// Create Producer
 	log.Info("Creating producer", "broker", broker)
-	p, err := kafka.NewProducer(&producerConfigMap)
+	p, err := util.NewKafkaProducer(producerConfigMap, log)
 	if err != nil {
 		return nil, err
 	}
@@ -117,9 +162,20 @@ func NewKafkaServer(fullGraph bool, workers int, deploymentName, namespace, prot
 		Broker:         broker,
 		TopicIn:        topicIn,
 		TopicOut:       topicOut,
+		TopicDLQ:       topicDLQ,
+		Delivery:       delivery,
+		DrainTimeout:   DefaultDrainTimeout,
 		ServerUrl:      serverUrl,
 		Workers:        workers,
+		BatchSize:      1,
+		BatchWait:      DefaultBatchWait,
 		Log:            log.WithName("KafkaServer"),
+		offsets:        newOffsetTracker(),
+		results:        make(chan *kafkaResult, workers),
+		flushes:        make(chan flushRequest),
+		errors:         make(chan error, 1),
+		quit:           make(chan struct{}),
+		metrics:        metric.NewKafkaMetrics(),
 	}, nil
 }
 
@@ -156,19 +212,111 @@ func getProto(messageType string, messageBytes []byte) (proto2.Message, error) {
 	return msg, err
 }
 
-func (ks *SeldonKafkaServer) Serve() error {
-	c, err := kafka.NewConsumer(&kafka.ConfigMap{
+func (ks *SeldonKafkaServer) getConsumerConfig() kafka.ConfigMap {
+	config := kafka.ConfigMap{
 		"bootstrap.servers":     ks.Broker,
 		"broker.address.family": "v4",
 		"group.id":              ks.getGroupName(),
 		"session.timeout.ms":    6000,
-		"auto.offset.reset":     "earliest"})
+		"auto.offset.reset":     "earliest"}
+	switch ks.Delivery {
+	case DeliveryAtLeastOnce:
+		// Offsets are stored once responses are delivered and auto committed from the store
+		config["enable.auto.offset.store"] = false
+	case DeliveryExactlyOnce:
+		// Offsets are committed in transactions
+		config["enable.auto.commit"] = false
+		config["isolation.level"] = "read_committed"
+	}
+	return config
+}
+
+// createJob creates the job for a consumed message, unmarshalling its request.
+func (ks *SeldonKafkaServer) createJob(e *kafka.Message) *KafkaJob {
+	headers := collectHeaders(e.Headers)
+	job := KafkaJob{
+		msg:     e,
+		start:   time.Now(),
+		headers: headers,
+		reqKey:  e.Key,
+	}
+	switch ks.Transport {
+	case api.TransportRest:
+		// Assume JSON if no content type - should maybe be application/octet-stream?
+		contentType := rest.ContentTypeJSON
+		if ct, ok := headers[http.ContentType]; ok {
+			if len(ct) == 1 {
+				contentType = ct[0]
+			}
+		}
+		job.reqPayload, job.err = ks.Client.Unmarshall(e.Value, contentType)
+	case api.TransportGrpc:
+		if val, ok := headers[KeyProtoName]; ok && len(val) == 1 {
+			protoName := val[0]
+			proto, err := getProto(protoName, e.Value)
+			if err != nil {
+				job.err = fmt.Errorf("failed to get proto %s from bytes: %w", protoName, err)
+			} else {
+				job.reqPayload = &payload.ProtoPayload{Msg: proto}
+			}
+		} else {
+			job.err = fmt.Errorf("failed to find %s in headers", KeyProtoName)
+		}
+	}
+	return &job
+}
+
+// jobChanIndex returns the channel for a message's job, hashing its key so messages with the same key are processed
+// in order by the same worker. Messages without a key are spread over the channels in turn.
+func jobChanIndex(key []byte, n int, next *int) int {
+	if n == 1 {
+		return 0
+	}
+	if len(key) == 0 {
+		*next = (*next + 1) % n
+		return *next
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	return int(h.Sum32() % uint32(n))
+}
+
+// recordLag sets the consumer lag of a message's partition, the number of messages after it.
+func (ks *SeldonKafkaServer) recordLag(tp kafka.TopicPartition) {
+	if tp.Topic == nil {
+		return
+	}
+	_, high, err := ks.consumer.GetWatermarkOffsets(*tp.Topic, tp.Partition)
+	if err != nil || high <= 0 {
+		return
+	}
+	lag := high - int64(tp.Offset) - 1
+	if lag < 0 {
+		lag = 0
+	}
+	ks.metrics.ConsumerLagGauge.WithLabelValues(*tp.Topic, strconv.Itoa(int(tp.Partition))).Set(float64(lag))
+}
+
+// Serve consumes requests from the input topic until SIGINT or SIGTERM is received, then stops consuming and waits
+// for the requests in flight to be processed.
+func (ks *SeldonKafkaServer) Serve() error {
+	c, err := util.NewKafkaConsumer(ks.getConsumerConfig())
 	if err != nil {
 		return err
 	}
-	ks.Log.Info("Created", "consumer", c.String())
+	ks.Log.Info("Created", "consumer", c.String(), "delivery", ks.Delivery)
+	ks.consumer = c
+
+	if ks.Delivery == DeliveryExactlyOnce {
+		ctx, cancel := context.WithTimeout(context.Background(), transactionTimeout)
+		err = ks.Producer.InitTransactions(ctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
 
-	err = c.SubscribeTopics([]string{ks.TopicIn}, nil)
+	err = c.SubscribeTopics([]string{ks.TopicIn}, ks.rebalance)
 	if err != nil {
 		return err
 	}
@@ -179,16 +327,26 @@ func (ks *SeldonKafkaServer) Serve() error {
 
 	// create a cancel channel
 	cancelChan := make(chan struct{})
-	// make a channel with a capacity of the number of workers
-	jobChan := make(chan *KafkaJob, ks.Workers)
+	// With key ordering each worker has its own channel, and messages with the same key go to the same worker
+	jobChans := make([]chan *KafkaJob, 1)
+	if ks.KeyOrdering && ks.Workers > 1 {
+		jobChans = make([]chan *KafkaJob, ks.Workers)
+	}
+	for i := range jobChans {
+		jobChans[i] = make(chan *KafkaJob, ks.Workers*ks.BatchSize/len(jobChans))
+	}
 	for i := 0; i < ks.Workers; i++ {
-		go ks.worker(jobChan, cancelChan)
+		go ks.worker(jobChans[i%len(jobChans)], cancelChan)
+	}
+	next := 0
+	if ks.Delivery == DeliveryExactlyOnce {
+		go ks.commitTransactions()
 	}
 
 	//wait for graph to be ready
 	ready := false
 	for ready == false {
-		err := predictor.Ready(&ks.Predictor.Graph)
+		err := predictor.ReadyPredictor(ks.Predictor)
 		ready = err == nil
 		if !ready {
 			ks.Log.Info("Waiting for graph to be ready")
@@ -197,11 +355,15 @@ func (ks *SeldonKafkaServer) Serve() error {
 	}
 
 	cnt := 0
+	var serveErr error
 	for run == true {
 		select {
 		case sig := <-sigchan:
 			ks.Log.Info("Terminating", "signal", sig)
 			run = false
+		case serveErr = <-ks.errors:
+			ks.Log.Error(serveErr, "Stopping consumer")
+			run = false
 		default:
 			ev := c.Poll(100)
 			if ev == nil {
@@ -214,49 +376,15 @@ func (ks *SeldonKafkaServer) Serve() error {
 				if cnt%1000 == 0 {
 					ks.Log.Info("Processed", "messages", cnt)
 				}
-				headers := collectHeaders(e.Headers)
-
-				var reqPayload payload.SeldonPayload
-				var err error
-				switch ks.Transport {
-				case api.TransportRest:
-					// Assume JSON if no content type - should maybe be application/octet-stream?
-					contentType := rest.ContentTypeJSON
-					if ct, ok := headers[http.ContentType]; ok {
-						if len(ct) == 1 {
-							contentType = ct[0]
-						}
-					}
-					reqPayload, err = ks.Client.Unmarshall(e.Value, contentType)
-					if err != nil {
-						ks.Log.Error(err, "Failed to unmarshall Payload")
-						continue
-					}
-				case api.TransportGrpc:
-					if val, ok := headers[KeyProtoName]; ok && len(val) == 1 {
-						protoName := val[0]
-						proto, err := getProto(protoName, e.Value)
-						if err != nil {
-							ks.Log.Error(err, "Failed to get proto from bytes")
-							continue
-						}
-						reqPayload = &payload.ProtoPayload{Msg: proto}
-
-					} else {
-						ks.Log.Info("Failed to find proto name in headers")
-						continue
-					}
-
-				}
-
-				job := KafkaJob{
-					headers:    headers,
-					reqKey:     e.Key,
-					reqPayload: reqPayload,
-				}
+				ks.offsets.add(e.TopicPartition)
+				ks.recordLag(e.TopicPartition)
 				// enqueue a job
-				jobChan <- &job
+				jobChans[jobChanIndex(e.Key, len(jobChans), &next)] <- ks.createJob(e)
 
+			case kafka.OAuthBearerTokenRefresh:
+				if err := util.RefreshKafkaOAuthBearerToken(c); err != nil {
+					ks.Log.Error(err, "Failed to refresh OAUTHBEARER token")
+				}
 			case kafka.Error:
 				// Errors should generally be considered
 				// informational, the client will try to
@@ -274,8 +402,14 @@ func (ks *SeldonKafkaServer) Serve() error {
 	}
 
 	ks.Log.Info("Final Processed", "messages", cnt)
+	if serveErr == nil {
+		ks.Log.Info("Draining", "inFlight", ks.offsets.inFlight())
+		ks.drain(nil)
+	}
 	ks.Log.Info("Closing consumer")
-	close(cancelChan)
 	c.Close()
-	return nil
+	close(ks.quit)
+	close(cancelChan)
+	ks.Producer.Flush(int(ks.DrainTimeout / time.Millisecond))
+	return serveErr
 }
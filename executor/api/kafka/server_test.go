@@ -1,11 +1,25 @@
 package kafka
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
 	seldon "github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
-	"testing"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 func TestGetProtoSeldonMessage(t *testing.T) {
@@ -23,3 +37,199 @@ func TestGetProtoSeldonMessage(t *testing.T) {
 
 	g.Expect(proto.Equal(sm2, &sm)).Should(Equal(true))
 }
+
+func createTestKafkaServer(delivery string) *SeldonKafkaServer {
+	return &SeldonKafkaServer{
+		Client:         test.SeldonMessageTestClient{},
+		DeploymentName: "dep",
+		Namespace:      "default",
+		Transport:      api.TransportRest,
+		Predictor:      &v1.PredictorSpec{Name: "p"},
+		TopicIn:        "in",
+		TopicOut:       "out",
+		TopicDLQ:       "dlq",
+		Delivery:       delivery,
+		BatchSize:      1,
+		Log:            logf.Log.WithName("KafkaServerTest"),
+		offsets:        newOffsetTracker(),
+		results:        make(chan *kafkaResult, 10),
+		metrics:        metric.NewKafkaMetrics(),
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	topic := "in"
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 2, Offset: 42},
+		Key:            []byte("key"),
+		Value:          []byte("bad"),
+		Headers:        []kafka.Header{{Key: payload.SeldonPUIDHeader, Value: []byte("puid")}},
+	}
+
+	ks := createTestKafkaServer(DeliveryAtLeastOnce)
+	messages := ks.deadLetter(msg, ErrorStagePredict, errors.New("model failed"))
+	g.Expect(messages).To(HaveLen(1))
+	g.Expect(*messages[0].TopicPartition.Topic).To(Equal("dlq"))
+	g.Expect(messages[0].Key).To(Equal(msg.Key))
+	g.Expect(messages[0].Value).To(Equal(msg.Value))
+	g.Expect(collectHeaders(messages[0].Headers)).To(Equal(map[string][]string{
+		payload.SeldonPUIDHeader: {"puid"},
+		KeyError:                 {"model failed"},
+		KeyErrorStage:            {ErrorStagePredict},
+		KeySourceTopic:           {"in"},
+		KeySourcePartition:       {"2"},
+		KeySourceOffset:          {"42"},
+	}))
+
+	ks.TopicDLQ = ""
+	g.Expect(ks.deadLetter(msg, ErrorStagePredict, errors.New("model failed"))).To(BeEmpty())
+}
+
+func TestPredictFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	topic := "in"
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 2, Offset: 42},
+		Value:          []byte(`{"data":{"ndarray":[1]}}`),
+	}
+
+	// Requests the graph rejects are dead lettered
+	ks := createTestKafkaServer(DeliveryAtLeastOnce)
+	ks.errors = make(chan error, 1)
+	messages, failed := ks.predictFailed(msg, status.Error(codes.InvalidArgument, "bad input"))
+	g.Expect(failed).To(BeFalse())
+	g.Expect(messages).To(HaveLen(1))
+	g.Expect(*messages[0].TopicPartition.Topic).To(Equal("dlq"))
+	g.Expect(ks.errors).To(BeEmpty())
+
+	// Other failures stop the server without delivering the request
+	messages, failed = ks.predictFailed(msg, status.Error(codes.Unavailable, "model down"))
+	g.Expect(failed).To(BeTrue())
+	g.Expect(messages).To(BeEmpty())
+	g.Expect(ks.errors).To(HaveLen(1))
+
+	// Unless messages in flight may be lost anyway
+	ks = createTestKafkaServer(DeliveryAtMostOnce)
+	messages, failed = ks.predictFailed(msg, errors.New("model failed"))
+	g.Expect(failed).To(BeFalse())
+	g.Expect(messages).To(BeEmpty())
+}
+
+func TestDeliveryNeedsDeadLetterTopic(t *testing.T) {
+	g := NewGomegaWithT(t)
+	serverUrl, _ := url.Parse("http://localhost")
+	for _, delivery := range []string{DeliveryAtLeastOnce, DeliveryExactlyOnce} {
+		_, err := NewKafkaServer(false, 1, "dep", "default", api.ProtocolSeldon, api.TransportRest, map[string]string{}, serverUrl, &v1.PredictorSpec{Name: "p"}, "localhost:9092", "in", "out", "", delivery, logf.Log)
+		g.Expect(err).ToNot(BeNil())
+	}
+}
+
+func TestCreateJob(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msg := &kafka.Message{Value: []byte(`{"data":{"ndarray":[1]}}`)}
+
+	ks := createTestKafkaServer(DeliveryAtLeastOnce)
+	job := ks.createJob(msg)
+	g.Expect(job.err).To(BeNil())
+	g.Expect(job.msg).To(Equal(msg))
+	g.Expect(job.headers).To(HaveKey(payload.SeldonPUIDHeader))
+
+	// gRPC requests without a proto name are sent to the dead letter topic
+	ks.Transport = api.TransportGrpc
+	job = ks.createJob(msg)
+	g.Expect(job.err).ToNot(BeNil())
+}
+
+func TestConsumerConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := createTestKafkaServer(DeliveryAtMostOnce).getConsumerConfig()
+	g.Expect(config).ToNot(HaveKey("enable.auto.offset.store"))
+	g.Expect(config).ToNot(HaveKey("enable.auto.commit"))
+
+	config = createTestKafkaServer(DeliveryAtLeastOnce).getConsumerConfig()
+	g.Expect(config["enable.auto.offset.store"]).To(Equal(false))
+
+	config = createTestKafkaServer(DeliveryExactlyOnce).getConsumerConfig()
+	g.Expect(config["enable.auto.commit"]).To(Equal(false))
+	g.Expect(config["isolation.level"]).To(Equal("read_committed"))
+}
+
+func TestJobChanIndex(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	next := 0
+	g.Expect(jobChanIndex([]byte("a"), 1, &next)).To(Equal(0))
+	index := jobChanIndex([]byte("a"), 4, &next)
+	for i := 0; i < 10; i++ {
+		g.Expect(jobChanIndex([]byte("a"), 4, &next)).To(Equal(index))
+	}
+	// Messages without a key are spread over the channels
+	seen := map[int]bool{}
+	for i := 0; i < 4; i++ {
+		seen[jobChanIndex(nil, 4, &next)] = true
+	}
+	g.Expect(seen).To(HaveLen(4))
+}
+
+func TestCollectBatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ks := createTestKafkaServer(DeliveryAtMostOnce)
+	jobChan := make(chan *KafkaJob, 10)
+	for i := 0; i < 5; i++ {
+		jobChan <- &KafkaJob{}
+	}
+
+	g.Expect(ks.collectBatch(&KafkaJob{}, jobChan)).To(HaveLen(1))
+
+	ks.BatchSize = 3
+	ks.BatchWait = time.Second
+	g.Expect(ks.collectBatch(&KafkaJob{}, jobChan)).To(HaveLen(3))
+
+	// A partial batch is returned after the wait
+	ks.BatchSize = 10
+	ks.BatchWait = 10 * time.Millisecond
+	g.Expect(ks.collectBatch(&KafkaJob{}, jobChan)).To(HaveLen(4))
+}
+
+func TestProcessKafkaRequestsBatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ks := createTestKafkaServer(DeliveryExactlyOnce)
+	ks.BatchSize = 3
+	modelType := v1.MODEL
+	ks.ServerUrl, _ = url.Parse("http://localhost")
+	ks.Predictor = &v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Type:     &modelType,
+			Endpoint: &v1.Endpoint{ServiceHost: "foo", ServicePort: 9000, Type: v1.REST},
+		},
+	}
+	topic := "in"
+	var jobs []*KafkaJob
+	for i, value := range []string{`{"data":{"ndarray":[[1.0]]}}`, `bad`, `{"data":{"ndarray":[[2.0]]}}`} {
+		msg := &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 0, Offset: kafka.Offset(i)},
+			Value:          []byte(value),
+		}
+		ks.offsets.add(msg.TopicPartition)
+		jobs = append(jobs, ks.createJob(msg))
+	}
+	jobs[1].err = errors.New("bad request")
+
+	ks.processKafkaRequests(jobs)
+	g.Expect(ks.results).To(HaveLen(3))
+	for i := range jobs {
+		r := <-ks.results
+		g.Expect(r.source.Offset).To(Equal(kafka.Offset(i)))
+		g.Expect(r.messages).To(HaveLen(1))
+		if i == 1 {
+			g.Expect(*r.messages[0].TopicPartition.Topic).To(Equal("dlq"))
+		} else {
+			g.Expect(*r.messages[0].TopicPartition.Topic).To(Equal("out"))
+			g.Expect(string(r.messages[0].Value)).To(ContainSubstring(fmt.Sprintf(`"ndarray":[[%d]]`, i/2+1)))
+		}
+	}
+}
@@ -1,16 +1,20 @@
 package kafka
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/cloudevents/sdk-go/pkg/bindings/http"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/go-logr/logr"
+	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/rest"
-	"os"
-	"os/signal"
-	"sync"
-	"syscall"
+	"github.com/seldonio/seldon-core/executor/api/util"
 )
 
 const (
@@ -22,17 +26,24 @@ const (
 type KafkaRPC struct {
 	Client       *KafkaClient
 	Producer     *kafka.Producer
+	ModelName    string
 	Broker       string
 	GroupId      string
 	TopicReceive string
 	TopicSend    string
-	Receivers    map[string]chan<- payload.SeldonPayload
-	Lock         sync.RWMutex
 	Log          logr.Logger
+
+	receivers *receivers
+	metrics   *metric.KafkaRPCMetrics
+	// ready is closed once the reply topic has been assigned, and done once replies are no longer consumed
+	ready chan struct{}
+	done  chan struct{}
 }
 
+// getTopicReceiveForModel returns the topic a node's replies are sent to. It is shared by the predictor's replicas
+// so it stays the same when pods are restarted.
 func getTopicReceiveForModel(modelName string, kc *KafkaClient) string {
-	return kc.Hostname + "." + modelName + "." + kc.predictor.Name + "." + kc.DeploymentName + "." + kc.Namespace
+	return getTopicSendForModel(modelName, kc) + ".reply"
 }
 
 func getTopicSendForModel(modelName string, kc *KafkaClient) string {
@@ -40,12 +51,14 @@ func getTopicSendForModel(modelName string, kc *KafkaClient) string {
 }
 
 func NewKafkaRPC(client *KafkaClient, modelName string) (*KafkaRPC, error) {
+	replica, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
 	topicReceive := getTopicReceiveForModel(modelName, client)
-	// Set group name to be same as receive topic
-	groupId := topicReceive
 
 	// Create producer
-	p, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": client.Broker})
+	p, err := util.NewKafkaProducer(kafka.ConfigMap{"bootstrap.servers": client.Broker}, client.Log)
 	if err != nil {
 		return nil, err
 	}
@@ -54,13 +67,16 @@ func NewKafkaRPC(client *KafkaClient, modelName string) (*KafkaRPC, error) {
 	return &KafkaRPC{
 		Client:       client,
 		Producer:     p,
+		ModelName:    modelName,
 		Broker:       client.Broker,
-		GroupId:      groupId,
+		GroupId:      topicReceive + "." + replica,
 		TopicSend:    getTopicSendForModel(modelName, client),
 		TopicReceive: topicReceive,
-		Receivers:    make(map[string]chan<- payload.SeldonPayload),
-		Lock:         sync.RWMutex{},
 		Log:          client.Log.WithName("KafkaRPC"),
+		receivers:    newReceivers(replica),
+		metrics:      metric.NewKafkaRPCMetrics(),
+		ready:        make(chan struct{}),
+		done:         make(chan struct{}),
 	}, nil
 }
 
@@ -73,21 +89,78 @@ func getPuidFromHeaders(headers []kafka.Header) string {
 	return ""
 }
 
+// getConsumerConfig returns the configuration of the reply consumer. Each replica consumes every reply in a group
+// of its own, reading from the end of the topic as it only waits for replies to calls made since it started. No
+// offsets are committed, so the group is forgotten when the replica stops.
+func (tp *KafkaRPC) getConsumerConfig() kafka.ConfigMap {
+	return kafka.ConfigMap{
+		"bootstrap.servers":     tp.Broker,
+		"broker.address.family": "v4",
+		"group.id":              tp.GroupId,
+		"session.timeout.ms":    6000,
+		"enable.auto.commit":    false,
+		"auto.offset.reset":     "latest"}
+}
+
+// rebalance marks the consumer ready once it has been assigned the reply topic's partitions.
+func (tp *KafkaRPC) rebalance(c *kafka.Consumer, event kafka.Event) error {
+	if e, ok := event.(kafka.AssignedPartitions); ok {
+		if err := c.Assign(e.Partitions); err != nil {
+			return err
+		}
+		select {
+		case <-tp.ready:
+		default:
+			close(tp.ready)
+		}
+	}
+	return nil
+}
+
+// handleReply passes a reply to the call waiting for it.
+func (tp *KafkaRPC) handleReply(e *kafka.Message) {
+	headers := collectHeaders(e.Headers)
+	var id string
+	if ids := headers[KeyCorrelationId]; len(ids) == 1 {
+		id = ids[0]
+	}
+	if !tp.receivers.owns(id) {
+		return
+	}
+
+	var reply rpcReply
+	if errs := headers[KeyError]; len(errs) > 0 {
+		reply.err = fmt.Errorf("node %s failed: %s", tp.ModelName, errs[0])
+	} else {
+		// Assume JSON if no content type - should maybe be application/octet-stream?
+		contentType := rest.ContentTypeJSON
+		if ct, ok := headers[http.ContentType]; ok {
+			if len(ct) == 1 {
+				contentType = ct[0]
+			}
+		}
+		reply.msg, reply.err = tp.Client.Unmarshall(e.Value, contentType)
+	}
+	puid := getPuidFromHeaders(e.Headers)
+	if !tp.receivers.deliver(id, puid, reply) {
+		tp.metrics.UnmatchedCounter.WithLabelValues(tp.ModelName).Inc()
+		tp.Log.Info("No call waiting for reply", "correlationId", id, "puid", puid)
+	}
+}
+
 func (tp *KafkaRPC) start() {
 	go func() {
-		c, err := kafka.NewConsumer(&kafka.ConfigMap{
-			"bootstrap.servers":     tp.Broker,
-			"broker.address.family": "v4",
-			"group.id":              tp.GroupId,
-			"session.timeout.ms":    6000,
-			"auto.offset.reset":     "earliest"})
+		defer close(tp.done)
+		c, err := util.NewKafkaConsumer(tp.getConsumerConfig())
 		if err != nil {
 			tp.Log.Error(err, "Failed to create consumer", "groupId", tp.GroupId)
+			return
 		}
 
-		err = c.SubscribeTopics([]string{tp.TopicReceive}, nil)
+		err = c.SubscribeTopics([]string{tp.TopicReceive}, tp.rebalance)
 		if err != nil {
 			tp.Log.Error(err, "Failed to subscribe to topic", "topic", tp.TopicReceive)
+			c.Close()
 			return
 		}
 
@@ -95,6 +168,7 @@ func (tp *KafkaRPC) start() {
 		run := true
 		sigchan := make(chan os.Signal, 1)
 		signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(sigchan)
 
 		for run == true {
 			select {
@@ -109,35 +183,12 @@ func (tp *KafkaRPC) start() {
 
 				switch e := ev.(type) {
 				case *kafka.Message:
-					tp.Log.Info("Message", "Partition", e.TopicPartition)
-
-					headers := collectHeaders(e.Headers)
+					tp.handleReply(e)
 
-					// Assume JSON if no content type - should maybe be application/octet-stream?
-					contentType := rest.ContentTypeJSON
-					if ct, ok := headers[http.ContentType]; ok {
-						if len(ct) == 1 {
-							contentType = ct[0]
-						}
+				case kafka.OAuthBearerTokenRefresh:
+					if err := util.RefreshKafkaOAuthBearerToken(c); err != nil {
+						tp.Log.Error(err, "Failed to refresh OAUTHBEARER token")
 					}
-					msg, err := tp.Client.Unmarshall(e.Value, contentType)
-					if err != nil {
-						tp.Log.Error(err, "Failed to unmarshal consume", "topic")
-					} else {
-						puid := getPuidFromHeaders(e.Headers)
-						if puid == "" {
-							tp.Log.Info("Failed to find puid in message", "topic", tp.TopicReceive)
-						} else {
-							tp.Lock.Lock()
-							if c, ok := tp.Receivers[puid]; ok {
-								c <- msg
-							} else {
-								tp.Log.Info("Failed to find receiver key for", "puid", puid)
-							}
-							tp.Lock.Unlock()
-						}
-					}
-
 				case kafka.Error:
 					// Errors should generally be considered
 					// informational, the client will try to
@@ -159,18 +210,32 @@ func (tp *KafkaRPC) start() {
 	}()
 }
 
-func (tp *KafkaRPC) call(msg []byte, puid string, method string) (payload.SeldonPayload, error) {
-	//add to receivers
-	c := make(chan payload.SeldonPayload)
-	tp.Lock.Lock()
-	tp.Receivers[puid] = c
-	tp.Lock.Unlock()
+// call sends a request to a node and waits for its reply until the context's deadline, or the client's timeout if
+// that is sooner.
+func (tp *KafkaRPC) call(ctx context.Context, msg []byte, puid string, method string) (payload.SeldonPayload, error) {
+	ctx, cancel := context.WithTimeout(ctx, tp.Client.Timeout)
+	defer cancel()
+	tp.metrics.InFlightGauge.WithLabelValues(tp.ModelName).Inc()
+	defer tp.metrics.InFlightGauge.WithLabelValues(tp.ModelName).Dec()
+
+	// Replies sent before the reply topic is assigned would be missed
+	select {
+	case <-tp.ready:
+	case <-tp.done:
+		return nil, fmt.Errorf("reply consumer for %s stopped", tp.TopicReceive)
+	case <-ctx.Done():
+		return nil, tp.callError(ctx, puid)
+	}
+
+	id, replyChan := tp.receivers.add(puid)
+	defer tp.receivers.remove(id)
 	//produce msg with topic for reply in headers
 	err := tp.Producer.Produce(&kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &tp.TopicSend, Partition: kafka.PartitionAny},
 		Value:          msg,
 		Headers: []kafka.Header{
 			{Key: payload.SeldonPUIDHeader, Value: []byte(puid)},
+			{Key: KeyCorrelationId, Value: []byte(id)},
 			{Key: KeyTopicResponse, Value: []byte(tp.TopicReceive)},
 			{Key: KeyMethod, Value: []byte(method)},
 		}}, nil)
@@ -178,15 +243,21 @@ func (tp *KafkaRPC) call(msg []byte, puid string, method string) (payload.Seldon
 		tp.Log.Error(err, "Failed to produce request", "topic", tp.TopicSend)
 		return nil, err
 	}
-	//wait for response
 
-	sigchan := make(chan os.Signal, 1)
-	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 	select {
-	case sig := <-sigchan:
-		tp.Log.Info("Terminating", "signal", sig)
-		return nil, fmt.Errorf("Terminated")
-	case res := <-c:
-		return res, nil
+	case reply := <-replyChan:
+		return reply.msg, reply.err
+	case <-tp.done:
+		return nil, fmt.Errorf("reply consumer for %s stopped", tp.TopicReceive)
+	case <-ctx.Done():
+		return nil, tp.callError(ctx, puid)
+	}
+}
+
+func (tp *KafkaRPC) callError(ctx context.Context, puid string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		tp.metrics.TimeoutCounter.WithLabelValues(tp.ModelName).Inc()
 	}
+	deadline, _ := ctx.Deadline()
+	return fmt.Errorf("no reply from %s for puid %s by %s: %w", tp.ModelName, puid, deadline.Format(time.RFC3339Nano), ctx.Err())
 }
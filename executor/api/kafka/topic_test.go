@@ -0,0 +1,174 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReceivers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := newReceivers("replica-0")
+
+	id, replyChan := r.add("puid-1")
+	g.Expect(r.owns(id)).To(BeTrue())
+	g.Expect(r.owns("replica-1/abc")).To(BeFalse())
+	g.Expect(r.owns("")).To(BeTrue())
+	g.Expect(r.deliver(id, "puid-1", rpcReply{err: errors.New("failed")})).To(BeTrue())
+	g.Expect(r.len()).To(Equal(0))
+	g.Expect((<-replyChan).err).ToNot(BeNil())
+	// Late replies aren't delivered
+	g.Expect(r.deliver(id, "puid-1", rpcReply{})).To(BeFalse())
+
+	// Replies from proxies that don't copy the correlation id are matched by puid
+	_, replyChan = r.add("puid-2")
+	g.Expect(r.deliver("", "puid-3", rpcReply{})).To(BeFalse())
+	g.Expect(r.deliver("", "puid-2", rpcReply{})).To(BeTrue())
+	g.Expect(replyChan).To(Receive())
+
+	id, _ = r.add("puid-4")
+	r.remove(id)
+	g.Expect(r.len()).To(Equal(0))
+}
+
+func createTestKafkaRPC(g *GomegaWithT, timeout time.Duration) *KafkaRPC {
+	p, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": "localhost:1", "message.timeout.ms": 100})
+	g.Expect(err).To(BeNil())
+	kc := &KafkaClient{
+		DeploymentName: "dep",
+		Namespace:      "default",
+		predictor:      &v1.PredictorSpec{Name: "p"},
+		Timeout:        timeout,
+		Log:            logf.Log.WithName("KafkaRPCTest"),
+	}
+	tp := &KafkaRPC{
+		Client:       kc,
+		Producer:     p,
+		ModelName:    "model",
+		TopicSend:    getTopicSendForModel("model", kc),
+		TopicReceive: getTopicReceiveForModel("model", kc),
+		Log:          kc.Log,
+		receivers:    newReceivers("replica-0"),
+		metrics:      metric.NewKafkaRPCMetrics(),
+		ready:        make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	close(tp.ready)
+	return tp
+}
+
+func createTestReply(id string, headers ...kafka.Header) *kafka.Message {
+	return &kafka.Message{
+		Value:   []byte(`{"data":{"ndarray":[1]}}`),
+		Headers: append([]kafka.Header{{Key: KeyCorrelationId, Value: []byte(id)}}, headers...),
+	}
+}
+
+// waitingCall returns the correlation id of the call waiting for a reply.
+func waitingCall(g *GomegaWithT, tp *KafkaRPC) string {
+	g.Eventually(tp.receivers.len).Should(Equal(1))
+	tp.receivers.mu.Lock()
+	defer tp.receivers.mu.Unlock()
+	for id := range tp.receivers.calls {
+		return id
+	}
+	return ""
+}
+
+func TestKafkaRPCTopics(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tp := createTestKafkaRPC(g, time.Second)
+	g.Expect(tp.TopicSend).To(Equal("model.p.dep.default"))
+	g.Expect(tp.TopicReceive).To(Equal("model.p.dep.default.reply"))
+}
+
+func TestKafkaRPCCallTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tp := createTestKafkaRPC(g, 50*time.Millisecond)
+
+	_, err := tp.call(context.Background(), []byte("{}"), "puid", client.SeldonPredictPath)
+	g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	g.Expect(tp.receivers.len()).To(Equal(0))
+
+	// A shorter deadline of the caller applies
+	tp.Client.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = tp.call(ctx, []byte("{}"), "puid", client.SeldonPredictPath)
+	g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+}
+
+func TestKafkaRPCCallReply(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tp := createTestKafkaRPC(g, time.Minute)
+
+	type result struct {
+		msg payload.SeldonPayload
+		err error
+	}
+	results := make(chan result, 1)
+	go func() {
+		msg, err := tp.call(context.Background(), []byte("{}"), "puid", client.SeldonPredictPath)
+		results <- result{msg, err}
+	}()
+	id := waitingCall(g, tp)
+	// Replies to other replicas' calls are ignored
+	tp.handleReply(createTestReply("replica-1/" + id))
+	g.Consistently(results).ShouldNot(Receive())
+	tp.handleReply(createTestReply(id))
+	var res result
+	g.Eventually(results).Should(Receive(&res))
+	g.Expect(res.err).To(BeNil())
+	g.Expect(res.msg.GetPayload()).To(Equal([]byte(`{"data":{"ndarray":[1]}}`)))
+
+	go func() {
+		msg, err := tp.call(context.Background(), []byte("{}"), "puid", client.SeldonPredictPath)
+		results <- result{msg, err}
+	}()
+	id = waitingCall(g, tp)
+	tp.handleReply(createTestReply(id, kafka.Header{Key: KeyError, Value: []byte("model crashed")}))
+	g.Eventually(results).Should(Receive(&res))
+	g.Expect(res.err).To(MatchError(ContainSubstring("model crashed")))
+
+	// Calls fail once replies are no longer consumed
+	close(tp.done)
+	_, err := tp.call(context.Background(), []byte("{}"), "puid", client.SeldonPredictPath)
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestKafkaProxyHandleRequest(t *testing.T) {
+	g := NewGomegaWithT(t)
+	kp := NewKafkaProxy(test.SeldonMessageTestClient{}, "model", "p", "dep", "default", "localhost:9092", "localhost", 9000, logf.Log.WithName("KafkaProxyTest"))
+
+	request := &kafka.Message{
+		Value: []byte(`{"data":{"ndarray":[1]}}`),
+		Headers: []kafka.Header{
+			{Key: payload.SeldonPUIDHeader, Value: []byte("puid")},
+			{Key: KeyCorrelationId, Value: []byte("replica-0/1")},
+			{Key: KeyMethod, Value: []byte(client.SeldonPredictPath)},
+		},
+	}
+	reply := kp.handleRequest(request)
+	g.Expect(*reply.TopicPartition.Topic).To(Equal("model.p.dep.default.reply"))
+	g.Expect(reply.Value).To(Equal(request.Value))
+	g.Expect(collectHeaders(reply.Headers)).To(Equal(map[string][]string{
+		payload.SeldonPUIDHeader: {"puid"},
+		KeyCorrelationId:         {"replica-0/1"},
+	}))
+
+	// Failures are replied to, so the caller doesn't wait for its deadline
+	request.Headers[2].Value = []byte(client.SeldonRoutePath)
+	reply = kp.handleRequest(request)
+	g.Expect(reply.Value).To(BeNil())
+	g.Expect(collectHeaders(reply.Headers)).To(HaveKey(KeyError))
+}
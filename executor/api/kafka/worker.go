@@ -2,6 +2,8 @@ package kafka
 
 import (
 	"context"
+	"time"
+
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -11,9 +13,14 @@ import (
 )
 
 type KafkaJob struct {
+	msg *kafka.Message
+	// start is when the message was consumed
+	start      time.Time
 	headers    map[string][]string
 	reqKey     []byte
 	reqPayload payload.SeldonPayload
+	// err is set if the message couldn't be unmarshalled into a request
+	err error
 }
 
 func (ks *SeldonKafkaServer) worker(jobChan <-chan *KafkaJob, cancelChan <-chan struct{}) {
@@ -23,35 +30,83 @@ func (ks *SeldonKafkaServer) worker(jobChan <-chan *KafkaJob, cancelChan <-chan
 			return
 
 		case job := <-jobChan:
-			ks.processKafkaRequest(job)
+			ks.processKafkaRequests(ks.collectBatch(job, jobChan))
 		}
 	}
 }
 
-func (ks *SeldonKafkaServer) processKafkaRequest(job *KafkaJob) {
-	ctx := context.Background()
-	// Add Seldon Puid to Context
-	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, job.headers[payload.SeldonPUIDHeader][0])
+// collectBatch returns a batch of jobs starting with the first, adding jobs from the channel until the batch is full
+// or BatchWait has passed.
+func (ks *SeldonKafkaServer) collectBatch(first *KafkaJob, jobChan <-chan *KafkaJob) []*KafkaJob {
+	jobs := []*KafkaJob{first}
+	if ks.BatchSize <= 1 {
+		return jobs
+	}
+	timer := time.NewTimer(ks.BatchWait)
+	defer timer.Stop()
+	for len(jobs) < ks.BatchSize {
+		select {
+		case job := <-jobChan:
+			jobs = append(jobs, job)
+		case <-timer.C:
+			return jobs
+		}
+	}
+	return jobs
+}
 
-	// Apply tracing if active
-	if opentracing.IsGlobalTracerRegistered() {
-		tracer := opentracing.GlobalTracer()
-		serverSpan := tracer.StartSpan("kafkaServer", ext.RPCServerOption(nil))
-		ctx = opentracing.ContextWithSpan(ctx, serverSpan)
-		defer serverSpan.Finish()
+// processKafkaRequests predicts the requests of a batch of jobs with as few graph calls as possible, then delivers
+// the responses in the order the messages were consumed.
+func (ks *SeldonKafkaServer) processKafkaRequests(jobs []*KafkaJob) {
+	messages := make([][]*kafka.Message, len(jobs))
+	var valid []*KafkaJob
+	var validIdx []int
+	for i, job := range jobs {
+		if job.err != nil {
+			messages[i] = ks.deadLetter(job.msg, ErrorStageUnmarshal, job.err)
+		} else {
+			valid = append(valid, job)
+			validIdx = append(validIdx, i)
+		}
+	}
+
+	failed := make([]bool, len(jobs))
+	if len(valid) == 1 {
+		resPayload, err := ks.predictGraph(valid[0].headers, valid[0].reqPayload)
+		messages[validIdx[0]], failed[validIdx[0]] = ks.responseMessages(valid[0], resPayload, err)
+	} else if len(valid) > 1 {
+		reqs := make([]payload.SeldonPayload, len(valid))
+		metas := make([]map[string][]string, len(valid))
+		for i, job := range valid {
+			reqs[i] = job.reqPayload
+			metas[i] = job.headers
+		}
+		// Only messages with the same headers are batched and each batch is a request with a puid of its own
+		resPayloads, errs := predictor.PredictBatch(reqs, metas, ks.predictGraph)
+		for i, job := range valid {
+			messages[validIdx[i]], failed[validIdx[i]] = ks.responseMessages(job, resPayloads[i], errs[i])
+		}
 	}
 
-	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, ks.Client, logf.Log.WithName("KafkaClient"), ks.ServerUrl, ks.Namespace, job.headers, "")
+	for i, job := range jobs {
+		if failed[i] {
+			continue
+		}
+		ks.deliver(job.msg.TopicPartition, messages[i])
+		ks.metrics.ProcessingHistogram.WithLabelValues(ks.TopicIn).Observe(time.Since(job.start).Seconds())
+	}
+}
 
-	resPayload, err := seldonPredictorProcess.Predict(&ks.Predictor.Graph, job.reqPayload)
+// responseMessages returns the messages to produce for a job's prediction, its response or dead letter. It returns
+// true if the prediction failed in a way that may succeed if the request is consumed again, when the job must not
+// be delivered.
+func (ks *SeldonKafkaServer) responseMessages(job *KafkaJob, resPayload payload.SeldonPayload, err error) ([]*kafka.Message, bool) {
 	if err != nil {
-		ks.Log.Error(err, "Failed prediction")
-		return
+		return ks.predictFailed(job.msg, err)
 	}
 	resBytes, err := resPayload.GetBytes()
 	if err != nil {
-		ks.Log.Error(err, "Failed to get bytes from prediction response")
-		return
+		return ks.deadLetter(job.msg, ErrorStagePredict, err), false
 	}
 
 	kafkaHeaders := make([]kafka.Header, 0)
@@ -61,13 +116,27 @@ func (ks *SeldonKafkaServer) processKafkaRequest(job *KafkaJob) {
 	//	kafkaHeaders = []kafka.Header{{Key: KeyProtoName, Value: []byte(proto2.MessageName(*resPayload.GetPayload().(*proto2.Message)))}}
 	//}
 
-	err = ks.Producer.Produce(&kafka.Message{
+	return []*kafka.Message{{
 		TopicPartition: kafka.TopicPartition{Topic: &ks.TopicOut, Partition: kafka.PartitionAny},
 		Key:            job.reqKey,
 		Value:          resBytes,
 		Headers:        kafkaHeaders,
-	}, nil)
-	if err != nil {
-		ks.Log.Error(err, "Failed to produce response")
+	}}, false
+}
+
+func (ks *SeldonKafkaServer) predictGraph(headers map[string][]string, reqPayload payload.SeldonPayload) (payload.SeldonPayload, error) {
+	ctx := context.Background()
+	// Add Seldon Puid to Context
+	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, headers[payload.SeldonPUIDHeader][0])
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		tracer := opentracing.GlobalTracer()
+		serverSpan := tracer.StartSpan("kafkaServer", ext.RPCServerOption(nil))
+		ctx = opentracing.ContextWithSpan(ctx, serverSpan)
+		defer serverSpan.Finish()
 	}
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, ks.Client, logf.Log.WithName("KafkaClient"), ks.ServerUrl, ks.Namespace, headers, "")
+	return seldonPredictorProcess.PredictGraph(ks.Predictor, reqPayload)
 }
@@ -0,0 +1,45 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type CacheMetrics struct {
+	HitsCounter   *prometheus.CounterVec
+	MissesCounter *prometheus.CounterVec
+}
+
+func NewCacheMetrics() *CacheMetrics {
+	labelNames := []string{CacheNameMetric}
+
+	hits := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: CacheHitsMetricName,
+			Help: "Number of requests answered from the response cache",
+		},
+		labelNames,
+	)
+	if err := prometheus.Register(hits); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			hits = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	misses := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: CacheMissesMetricName,
+			Help: "Number of requests not found in the response cache",
+		},
+		labelNames,
+	)
+	if err := prometheus.Register(misses); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			misses = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	return &CacheMetrics{
+		HitsCounter:   hits,
+		MissesCounter: misses,
+	}
+}
@@ -10,14 +10,51 @@ const (
 	ModelNameMetric        = "model_name"
 	ModelImageMetric       = "model_image"
 	ModelVersionMetric     = "model_version"
+	CacheNameMetric        = "cache"
+	LoggerDropReasonMetric = "reason"
+	KafkaTopicMetric       = "topic"
+	KafkaPartitionMetric   = "partition"
+	KafkaErrorStageMetric  = "stage"
+	GraphStepMetric        = "step"
+	RouteMetric            = "route"
+	RouteChildMetric       = "child"
+	PayloadDirectionMetric = "direction" // request or response
 
 	ServerRequestsMetricName = "seldon_api_executor_server_requests_seconds"
 	ClientRequestsMetricName = "seldon_api_executor_client_requests_seconds"
 
+	CircuitBreakerOpenMetricName     = "seldon_api_executor_circuit_breaker_open"
+	CircuitBreakerRejectedMetricName = "seldon_api_executor_circuit_breaker_rejected_total"
+	NodeRetriesMetricName            = "seldon_api_executor_node_retries_total"
+
+	CacheHitsMetricName   = "seldon_api_executor_cache_hits_total"
+	CacheMissesMetricName = "seldon_api_executor_cache_misses_total"
+
+	LoggerQueueDepthMetricName   = "seldon_api_executor_logger_queue_depth"
+	LoggerDroppedMetricName      = "seldon_api_executor_logger_dropped_total"
+	LoggerRetriesMetricName      = "seldon_api_executor_logger_retries_total"
+	LoggerSpooledBytesMetricName = "seldon_api_executor_logger_spooled_bytes"
+
+	KafkaConsumerLagMetricName = "seldon_api_executor_kafka_consumer_lag"
+	KafkaProcessingMetricName  = "seldon_api_executor_kafka_processing_seconds"
+	KafkaFailedMetricName      = "seldon_api_executor_kafka_failed_messages_total"
+
+	KafkaRPCInFlightMetricName  = "seldon_api_executor_kafka_rpc_in_flight"
+	KafkaRPCTimeoutMetricName   = "seldon_api_executor_kafka_rpc_timeouts_total"
+	KafkaRPCUnmatchedMetricName = "seldon_api_executor_kafka_rpc_unmatched_replies_total"
+
+	GraphStepMetricName        = "seldon_api_executor_graph_step_seconds"
+	GraphRouteMetricName       = "seldon_api_executor_graph_route_total"
+	GraphAbortMetricName       = "seldon_api_executor_graph_aborted_requests_total"
+	GraphPayloadSizeMetricName = "seldon_api_executor_graph_payload_size_bytes"
+
 	PredictionHttpServiceName = "predictions"
 	StatusHttpServiceName     = "status"
 	MetadataHttpServiceName   = "metadata"
 	FeedbackHttpServiceName   = "feedback"
+	ConfigHttpServiceName     = "config"
+	StatsHttpServiceName      = "stats"
+	RepositoryHttpServiceName = "repository"
 )
 
 var (
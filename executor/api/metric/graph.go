@@ -0,0 +1,88 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// PayloadSizeBuckets range from 64 bytes to 16 MiB.
+var PayloadSizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
+
+type GraphMetrics struct {
+	StepHistogram        *prometheus.HistogramVec
+	RouteCounter         *prometheus.CounterVec
+	AbortCounter         *prometheus.CounterVec
+	PayloadSizeHistogram *prometheus.HistogramVec
+	Predictor            *v1.PredictorSpec
+	DeploymentName       string
+}
+
+func NewGraphMetrics(spec *v1.PredictorSpec, deploymentName string) *GraphMetrics {
+	step := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    GraphStepMetricName,
+			Help:    "Duration of a step of a graph node, including the executor's own work such as logging, caching and retries",
+			Buckets: DefBuckets,
+		},
+		[]string{DeploymentNameMetric, PredictorNameMetric, PredictorVersionMetric, ModelNameMetric, GraphStepMetric},
+	)
+	if err := prometheus.Register(step); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			step = e.ExistingCollector.(*prometheus.HistogramVec)
+		}
+	}
+
+	route := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: GraphRouteMetricName,
+			Help: "Number of routing decisions of a graph node, by route and the child chosen",
+		},
+		[]string{DeploymentNameMetric, PredictorNameMetric, PredictorVersionMetric, ModelNameMetric, RouteMetric, RouteChildMetric},
+	)
+	if err := prometheus.Register(route); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			route = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	abort := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: GraphAbortMetricName,
+			Help: "Number of requests a graph node's router returned unchanged by choosing the -2 route",
+		},
+		[]string{DeploymentNameMetric, PredictorNameMetric, PredictorVersionMetric, ModelNameMetric},
+	)
+	if err := prometheus.Register(abort); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			abort = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	payloadSize := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    GraphPayloadSizeMetricName,
+			Help:    "Size of the payloads sent to and received from a graph node",
+			Buckets: PayloadSizeBuckets,
+		},
+		[]string{DeploymentNameMetric, PredictorNameMetric, PredictorVersionMetric, ModelNameMetric, PayloadDirectionMetric},
+	)
+	if err := prometheus.Register(payloadSize); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			payloadSize = e.ExistingCollector.(*prometheus.HistogramVec)
+		}
+	}
+
+	return &GraphMetrics{
+		StepHistogram:        step,
+		RouteCounter:         route,
+		AbortCounter:         abort,
+		PayloadSizeHistogram: payloadSize,
+		Predictor:            spec,
+		DeploymentName:       deploymentName,
+	}
+}
+
+// LabelValues prefixes the given label values with the deployment and predictor labels.
+func (m *GraphMetrics) LabelValues(values ...string) []string {
+	return append([]string{m.DeploymentName, m.Predictor.Name, m.Predictor.Annotations["version"]}, values...)
+}
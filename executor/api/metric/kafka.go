@@ -0,0 +1,112 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type KafkaMetrics struct {
+	ConsumerLagGauge    *prometheus.GaugeVec
+	ProcessingHistogram *prometheus.HistogramVec
+	FailedCounter       *prometheus.CounterVec
+}
+
+func NewKafkaMetrics() *KafkaMetrics {
+	lag := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: KafkaConsumerLagMetricName,
+			Help: "Number of messages in a partition of the input topic after the last one consumed",
+		},
+		[]string{KafkaTopicMetric, KafkaPartitionMetric},
+	)
+	if err := prometheus.Register(lag); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			lag = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+
+	processing := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    KafkaProcessingMetricName,
+			Help:    "A histogram of the time from consuming a message to producing its response",
+			Buckets: DefBuckets,
+		},
+		[]string{KafkaTopicMetric},
+	)
+	if err := prometheus.Register(processing); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			processing = e.ExistingCollector.(*prometheus.HistogramVec)
+		}
+	}
+
+	failed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: KafkaFailedMetricName,
+			Help: "Number of consumed messages that failed to be processed",
+		},
+		[]string{KafkaTopicMetric, KafkaErrorStageMetric},
+	)
+	if err := prometheus.Register(failed); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			failed = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	return &KafkaMetrics{
+		ConsumerLagGauge:    lag,
+		ProcessingHistogram: processing,
+		FailedCounter:       failed,
+	}
+}
+
+type KafkaRPCMetrics struct {
+	InFlightGauge    *prometheus.GaugeVec
+	TimeoutCounter   *prometheus.CounterVec
+	UnmatchedCounter *prometheus.CounterVec
+}
+
+func NewKafkaRPCMetrics() *KafkaRPCMetrics {
+	inFlight := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: KafkaRPCInFlightMetricName,
+			Help: "Number of full graph kafka calls to a node waiting for a reply",
+		},
+		[]string{ModelNameMetric},
+	)
+	if err := prometheus.Register(inFlight); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			inFlight = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+
+	timeouts := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: KafkaRPCTimeoutMetricName,
+			Help: "Number of full graph kafka calls to a node that got no reply before their deadline",
+		},
+		[]string{ModelNameMetric},
+	)
+	if err := prometheus.Register(timeouts); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			timeouts = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	unmatched := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: KafkaRPCUnmatchedMetricName,
+			Help: "Number of replies from a node received after their call stopped waiting, or for another replica",
+		},
+		[]string{ModelNameMetric},
+	)
+	if err := prometheus.Register(unmatched); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			unmatched = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	return &KafkaRPCMetrics{
+		InFlightGauge:    inFlight,
+		TimeoutCounter:   timeouts,
+		UnmatchedCounter: unmatched,
+	}
+}
@@ -0,0 +1,70 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type LoggerMetrics struct {
+	QueueDepthGauge   prometheus.Gauge
+	DroppedCounter    *prometheus.CounterVec
+	RetriesCounter    prometheus.Counter
+	SpooledBytesGauge prometheus.Gauge
+}
+
+func NewLoggerMetrics() *LoggerMetrics {
+	queueDepth := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: LoggerQueueDepthMetricName,
+			Help: "Number of payload logs waiting in memory to be sent",
+		},
+	)
+	if err := prometheus.Register(queueDepth); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			queueDepth = e.ExistingCollector.(prometheus.Gauge)
+		}
+	}
+
+	dropped := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: LoggerDroppedMetricName,
+			Help: "Number of payload logs dropped without being sent",
+		},
+		[]string{LoggerDropReasonMetric},
+	)
+	if err := prometheus.Register(dropped); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			dropped = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	retries := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: LoggerRetriesMetricName,
+			Help: "Number of failed attempts to send payload logs from the spool",
+		},
+	)
+	if err := prometheus.Register(retries); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			retries = e.ExistingCollector.(prometheus.Counter)
+		}
+	}
+
+	spooledBytes := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: LoggerSpooledBytesMetricName,
+			Help: "Size in bytes of the payload logs spooled on disk",
+		},
+	)
+	if err := prometheus.Register(spooledBytes); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			spooledBytes = e.ExistingCollector.(prometheus.Gauge)
+		}
+	}
+
+	return &LoggerMetrics{
+		QueueDepthGauge:   queueDepth,
+		DroppedCounter:    dropped,
+		RetriesCounter:    retries,
+		SpooledBytesGauge: spooledBytes,
+	}
+}
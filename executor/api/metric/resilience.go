@@ -0,0 +1,60 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ResilienceMetrics struct {
+	CircuitOpenGauge       *prometheus.GaugeVec
+	CircuitRejectedCounter *prometheus.CounterVec
+	RetriesCounter         *prometheus.CounterVec
+}
+
+func NewResilienceMetrics() *ResilienceMetrics {
+	labelNames := []string{ModelNameMetric}
+
+	openGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: CircuitBreakerOpenMetricName,
+			Help: "Whether the circuit breaker for a graph node is open (1) or closed (0)",
+		},
+		labelNames,
+	)
+	if err := prometheus.Register(openGauge); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			openGauge = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+
+	rejected := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: CircuitBreakerRejectedMetricName,
+			Help: "Number of calls to a graph node rejected because its circuit breaker was open",
+		},
+		labelNames,
+	)
+	if err := prometheus.Register(rejected); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			rejected = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	retries := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: NodeRetriesMetricName,
+			Help: "Number of retried calls to a graph node",
+		},
+		labelNames,
+	)
+	if err := prometheus.Register(retries); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			retries = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+
+	return &ResilienceMetrics{
+		CircuitOpenGauge:       openGauge,
+		CircuitRejectedCounter: rejected,
+		RetriesCounter:         retries,
+	}
+}
@@ -0,0 +1,733 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/tensorflow/tensorflow/tensorflow/go/core/framework"
+)
+
+// Forms of the data of a SeldonMessage which can be translated to and from tensors.
+const (
+	SeldonDataNdarray  = "ndarray"
+	SeldonDataTensor   = "tensor"
+	SeldonDataTftensor = "tftensor"
+)
+
+// Tensor is a named tensor independent of the protocol it was sent in. Data holds its values flattened in
+// row-major order as float64, int64, bool or string according to Datatype, which is a V2 protocol datatype.
+type Tensor struct {
+	Name     string
+	Datatype string
+	Shape    []int64
+	Data     []interface{}
+}
+
+var tfDatatypes = map[framework.DataType]string{
+	framework.DataType_DT_FLOAT:  "FP32",
+	framework.DataType_DT_DOUBLE: "FP64",
+	framework.DataType_DT_INT8:   "INT8",
+	framework.DataType_DT_INT16:  "INT16",
+	framework.DataType_DT_INT32:  "INT32",
+	framework.DataType_DT_INT64:  "INT64",
+	framework.DataType_DT_UINT8:  "UINT8",
+	framework.DataType_DT_UINT16: "UINT16",
+	framework.DataType_DT_UINT32: "UINT32",
+	framework.DataType_DT_UINT64: "UINT64",
+	framework.DataType_DT_BOOL:   "BOOL",
+	framework.DataType_DT_STRING: "BYTES",
+}
+
+// TranslateRequest converts a JSON request in protocol from into protocol to. Requests translated into the
+// Seldon protocol carry their data in seldonForm, or as an ndarray if it is empty.
+func TranslateRequest(msg SeldonPayload, from string, to string, seldonForm string) (SeldonPayload, error) {
+	return translate(msg, from, to, false, seldonForm)
+}
+
+// TranslateResponse converts a JSON response in protocol from into protocol to. Responses translated into the
+// Seldon protocol carry their data in seldonForm, which should be the form of the original request.
+func TranslateResponse(msg SeldonPayload, from string, to string, seldonForm string) (SeldonPayload, error) {
+	return translate(msg, from, to, true, seldonForm)
+}
+
+// SeldonDataForm returns the form of the data in a Seldon protocol JSON message, or an empty string if it
+// has no tensor data.
+func SeldonDataForm(msg SeldonPayload) string {
+	data, err := DecompressSeldonPayload(msg)
+	if err != nil {
+		return ""
+	}
+	var m struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return ""
+	}
+	for _, form := range []string{SeldonDataNdarray, SeldonDataTensor, SeldonDataTftensor} {
+		if _, ok := m.Data[form]; ok {
+			return form
+		}
+	}
+	return ""
+}
+
+func translate(msg SeldonPayload, from string, to string, response bool, seldonForm string) (SeldonPayload, error) {
+	if from == to {
+		return msg, nil
+	}
+	data, err := DecompressSeldonPayload(msg)
+	if err != nil {
+		return nil, err
+	}
+	tensors, err := DecodeTensors(from, data, response)
+	if err != nil {
+		return nil, err
+	}
+	b, err := EncodeTensors(to, tensors, response, seldonForm)
+	if err != nil {
+		return nil, err
+	}
+	return &BytesPayload{Msg: b, ContentType: msg.GetContentType()}, nil
+}
+
+// DecodeTensors reads the tensors of a JSON request or response in the given protocol.
+func DecodeTensors(protocol string, msg []byte, response bool) ([]Tensor, error) {
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	switch protocol {
+	case api.ProtocolSeldon:
+		return decodeSeldon(m, msg)
+	case api.ProtocolTensorflow:
+		if response {
+			return decodeTensorflow(m, "predictions", "outputs")
+		}
+		return decodeTensorflow(m, "instances", "inputs")
+	case api.ProtocolKFServing:
+		if response {
+			return decodeV2(m, "outputs")
+		}
+		return decodeV2(m, "inputs")
+	}
+	return nil, fmt.Errorf("unknown protocol %s", protocol)
+}
+
+// EncodeTensors writes tensors as a JSON request or response in the given protocol. A SeldonMessage can hold
+// a single tensor, which is written in seldonForm.
+func EncodeTensors(protocol string, tensors []Tensor, response bool, seldonForm string) ([]byte, error) {
+	var m interface{}
+	var err error
+	switch protocol {
+	case api.ProtocolSeldon:
+		m, err = encodeSeldon(tensors, seldonForm)
+	case api.ProtocolTensorflow:
+		if response {
+			m, err = encodeTensorflow(tensors, "predictions", "outputs")
+		} else {
+			m, err = encodeTensorflow(tensors, "instances", "inputs")
+		}
+	case api.ProtocolKFServing:
+		if response {
+			m, err = encodeV2(tensors, "outputs", "output")
+		} else {
+			m, err = encodeV2(tensors, "inputs", "input")
+		}
+	default:
+		err = fmt.Errorf("unknown protocol %s", protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func decodeSeldon(m map[string]interface{}, msg []byte) ([]Tensor, error) {
+	data, ok := m["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("only SeldonMessages with data can be translated")
+	}
+	if ndarray, ok := data[SeldonDataNdarray]; ok {
+		t, err := tensorFromNested(ndarray, "")
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{*t}, nil
+	}
+	if tensor, ok := data[SeldonDataTensor].(map[string]interface{}); ok {
+		var shape []int64
+		if err := appendInts(&shape, tensor["shape"]); err != nil {
+			return nil, err
+		}
+		values, _ := tensor["values"].([]interface{})
+		t, err := newTensor("", "FP64", shape, values)
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{*t}, nil
+	}
+	if _, ok := data[SeldonDataTftensor]; ok {
+		var raw struct {
+			Data struct {
+				Tftensor json.RawMessage `json:"tftensor"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(msg, &raw); err != nil {
+			return nil, err
+		}
+		tp := &framework.TensorProto{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(raw.Data.Tftensor), tp); err != nil {
+			return nil, err
+		}
+		t, err := tensorFromTF(tp)
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{*t}, nil
+	}
+	return nil, fmt.Errorf("only SeldonMessages with ndarray, tensor or tftensor data can be translated")
+}
+
+func encodeSeldon(tensors []Tensor, form string) (interface{}, error) {
+	if len(tensors) != 1 {
+		return nil, fmt.Errorf("a SeldonMessage can hold one tensor but there are %d", len(tensors))
+	}
+	t := tensors[0]
+	var data interface{}
+	switch {
+	case form == SeldonDataTftensor:
+		tp, err := tensorToTF(&t)
+		if err != nil {
+			return nil, err
+		}
+		ma := jsonpb.Marshaler{}
+		s, err := ma.MarshalToString(tp)
+		if err != nil {
+			return nil, err
+		}
+		data = map[string]interface{}{SeldonDataTftensor: json.RawMessage(s)}
+	case form == SeldonDataTensor && isNumeric(t.Datatype):
+		values := make([]interface{}, len(t.Data))
+		for i, v := range t.Data {
+			f, err := toFloat(v)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = jsonFloat{f, 64}
+		}
+		data = map[string]interface{}{SeldonDataTensor: map[string]interface{}{"shape": t.Shape, "values": values}}
+	default:
+		ndarray, err := nest(&t)
+		if err != nil {
+			return nil, err
+		}
+		if len(t.Shape) == 0 {
+			ndarray = []interface{}{ndarray}
+		}
+		data = map[string]interface{}{SeldonDataNdarray: ndarray}
+	}
+	return map[string]interface{}{"data": data}, nil
+}
+
+func decodeTensorflow(m map[string]interface{}, rowKey string, columnKey string) ([]Tensor, error) {
+	if rows, ok := m[rowKey].([]interface{}); ok {
+		if len(rows) > 0 {
+			if _, named := rows[0].(map[string]interface{}); named {
+				columns := map[string]interface{}{}
+				for _, row := range rows {
+					r, ok := row.(map[string]interface{})
+					if !ok {
+						return nil, fmt.Errorf("tensorflow %s must all be objects or all be values", rowKey)
+					}
+					for name, v := range r {
+						column, _ := columns[name].([]interface{})
+						columns[name] = append(column, v)
+					}
+				}
+				return tensorsFromNamed(columns)
+			}
+		}
+		t, err := tensorFromNested(rows, "")
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{*t}, nil
+	}
+	if columns, ok := m[columnKey]; ok {
+		if named, ok := columns.(map[string]interface{}); ok {
+			return tensorsFromNamed(named)
+		}
+		t, err := tensorFromNested(columns, "")
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{*t}, nil
+	}
+	return nil, fmt.Errorf("tensorflow message has no %s or %s", rowKey, columnKey)
+}
+
+func encodeTensorflow(tensors []Tensor, rowKey string, columnKey string) (interface{}, error) {
+	if len(tensors) == 1 {
+		nested, err := nest(&tensors[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(tensors[0].Shape) == 0 {
+			return map[string]interface{}{columnKey: nested}, nil
+		}
+		return map[string]interface{}{rowKey: nested}, nil
+	}
+	columns := map[string]interface{}{}
+	for i := range tensors {
+		nested, err := nest(&tensors[i])
+		if err != nil {
+			return nil, err
+		}
+		columns[tensorName(&tensors[i], "input", i)] = nested
+	}
+	return map[string]interface{}{columnKey: columns}, nil
+}
+
+func decodeV2(m map[string]interface{}, key string) ([]Tensor, error) {
+	items, ok := m[key].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("V2 message has no %s", key)
+	}
+	tensors := make([]Tensor, 0, len(items))
+	for _, item := range items {
+		v2, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("V2 %s must be objects", key)
+		}
+		var shape []int64
+		if err := appendInts(&shape, v2["shape"]); err != nil {
+			return nil, err
+		}
+		_, data, err := flatten(v2["data"])
+		if err != nil {
+			return nil, err
+		}
+		name, _ := v2["name"].(string)
+		datatype, _ := v2["datatype"].(string)
+		if datatype == "" {
+			if datatype, err = inferDatatype(data); err != nil {
+				return nil, err
+			}
+		}
+		t, err := newTensor(name, datatype, shape, data)
+		if err != nil {
+			return nil, err
+		}
+		tensors = append(tensors, *t)
+	}
+	return tensors, nil
+}
+
+func encodeV2(tensors []Tensor, key string, prefix string) (interface{}, error) {
+	items := make([]interface{}, len(tensors))
+	for i := range tensors {
+		t := &tensors[i]
+		data := make([]interface{}, len(t.Data))
+		for j, v := range t.Data {
+			data[j] = jsonValue(v, t.Datatype)
+		}
+		shape := t.Shape
+		if shape == nil {
+			shape = []int64{}
+		}
+		items[i] = map[string]interface{}{
+			"name":     tensorName(t, prefix, i),
+			"datatype": t.Datatype,
+			"shape":    shape,
+			"data":     data,
+		}
+	}
+	return map[string]interface{}{key: items}, nil
+}
+
+func tensorName(t *Tensor, prefix string, i int) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return prefix + "-" + strconv.Itoa(i)
+}
+
+func tensorsFromNamed(named map[string]interface{}) ([]Tensor, error) {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tensors := make([]Tensor, len(names))
+	for i, name := range names {
+		t, err := tensorFromNested(named[name], name)
+		if err != nil {
+			return nil, err
+		}
+		tensors[i] = *t
+	}
+	return tensors, nil
+}
+
+// tensorFromNested creates a tensor from a JSON value of nested arrays, inferring its datatype from the values.
+func tensorFromNested(v interface{}, name string) (*Tensor, error) {
+	shape, data, err := flatten(v)
+	if err != nil {
+		return nil, err
+	}
+	datatype, err := inferDatatype(data)
+	if err != nil {
+		return nil, err
+	}
+	return newTensor(name, datatype, shape, data)
+}
+
+func newTensor(name string, datatype string, shape []int64, data []interface{}) (*Tensor, error) {
+	size := int64(1)
+	for _, dim := range shape {
+		size *= dim
+	}
+	if size != int64(len(data)) {
+		return nil, fmt.Errorf("tensor %s of shape %v has %d values", name, shape, len(data))
+	}
+	values := make([]interface{}, len(data))
+	for i, v := range data {
+		value, err := normalize(v, datatype)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return &Tensor{Name: name, Datatype: datatype, Shape: shape, Data: values}, nil
+}
+
+// flatten returns the shape and row-major values of nested JSON arrays, which must be rectangular.
+func flatten(v interface{}) ([]int64, []interface{}, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		if v == nil {
+			return nil, nil, fmt.Errorf("tensor has no values")
+		}
+		return nil, []interface{}{v}, nil
+	}
+	shape := []int64{int64(len(list))}
+	var data []interface{}
+	var inner []int64
+	for i, item := range list {
+		itemShape, itemData, err := flatten(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			inner = itemShape
+		} else if !equalShapes(inner, itemShape) {
+			return nil, nil, fmt.Errorf("nested arrays must all have the same shape but found %v and %v", inner, itemShape)
+		}
+		data = append(data, itemData...)
+	}
+	return append(shape, inner...), data, nil
+}
+
+func equalShapes(a []int64, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// nest returns the values of a tensor as nested arrays of its shape.
+func nest(t *Tensor) (interface{}, error) {
+	if len(t.Shape) == 0 {
+		if len(t.Data) != 1 {
+			return nil, fmt.Errorf("scalar tensor %s has %d values", t.Name, len(t.Data))
+		}
+		return jsonValue(t.Data[0], t.Datatype), nil
+	}
+	var build func(dims []int64, data []interface{}) []interface{}
+	build = func(dims []int64, data []interface{}) []interface{} {
+		list := make([]interface{}, dims[0])
+		if len(dims) == 1 {
+			for i := range list {
+				list[i] = jsonValue(data[i], t.Datatype)
+			}
+			return list
+		}
+		stride := len(data) / int(dims[0])
+		for i := range list {
+			list[i] = build(dims[1:], data[i*stride:(i+1)*stride])
+		}
+		return list
+	}
+	size := int64(1)
+	for _, dim := range t.Shape {
+		size *= dim
+	}
+	if size != int64(len(t.Data)) {
+		return nil, fmt.Errorf("tensor %s of shape %v has %d values", t.Name, t.Shape, len(t.Data))
+	}
+	if size == 0 {
+		return []interface{}{}, nil
+	}
+	return build(t.Shape, t.Data), nil
+}
+
+// inferDatatype chooses a V2 datatype for JSON values. Numbers written with a decimal point or exponent make
+// a tensor FP64, otherwise numbers are INT64.
+func inferDatatype(data []interface{}) (string, error) {
+	datatype := ""
+	for _, v := range data {
+		var dt string
+		switch n := v.(type) {
+		case json.Number:
+			dt = "INT64"
+			if strings.ContainsAny(n.String(), ".eE") {
+				dt = "FP64"
+			}
+		case bool:
+			dt = "BOOL"
+		case string:
+			dt = "BYTES"
+		default:
+			return "", fmt.Errorf("unsupported tensor value %v", v)
+		}
+		switch {
+		case datatype == "" || datatype == dt:
+			datatype = dt
+		case isNumeric(datatype) && isNumeric(dt):
+			datatype = "FP64"
+		default:
+			return "", fmt.Errorf("tensor values must all be numbers, booleans or strings")
+		}
+	}
+	if datatype == "" {
+		datatype = "FP64"
+	}
+	return datatype, nil
+}
+
+func isNumeric(datatype string) bool {
+	return isFloat(datatype) || isInt(datatype)
+}
+
+func isFloat(datatype string) bool {
+	return strings.HasPrefix(datatype, "FP")
+}
+
+func isInt(datatype string) bool {
+	return strings.HasPrefix(datatype, "INT") || strings.HasPrefix(datatype, "UINT")
+}
+
+// normalize converts a decoded value to the Go type used for datatype.
+func normalize(v interface{}, datatype string) (interface{}, error) {
+	switch {
+	case isFloat(datatype):
+		return toFloat(v)
+	case isInt(datatype):
+		return toInt(v)
+	case datatype == "BOOL":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case datatype == "BYTES":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported datatype %s", datatype)
+	}
+	return nil, fmt.Errorf("value %v is not of datatype %s", v, datatype)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Float64()
+	case float64:
+		return n, nil
+	case int64:
+		return float64(n), nil
+	}
+	return 0, fmt.Errorf("value %v is not a number", v)
+}
+
+func toInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return 0, err
+		}
+		return floatToInt(f)
+	case float64:
+		return floatToInt(n)
+	case int64:
+		return n, nil
+	}
+	return 0, fmt.Errorf("value %v is not a number", v)
+}
+
+func floatToInt(f float64) (int64, error) {
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("value %v is not an integer", f)
+	}
+	return int64(f), nil
+}
+
+func appendInts(ints *[]int64, v interface{}) error {
+	list, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("shape must be an array")
+	}
+	for _, item := range list {
+		i, err := toInt(item)
+		if err != nil {
+			return err
+		}
+		*ints = append(*ints, i)
+	}
+	return nil
+}
+
+// jsonFloat is written to JSON with a decimal point, so floats stay floats when read by numpy.
+type jsonFloat struct {
+	value float64
+	bits  int
+}
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+		return nil, fmt.Errorf("unsupported value %v", f.value)
+	}
+	s := strconv.FormatFloat(f.value, 'g', -1, f.bits)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return []byte(s), nil
+}
+
+func jsonValue(v interface{}, datatype string) interface{} {
+	if f, ok := v.(float64); ok {
+		if datatype == "FP32" || datatype == "FP16" {
+			return jsonFloat{f, 32}
+		}
+		return jsonFloat{f, 64}
+	}
+	return v
+}
+
+func tensorFromTF(tp *framework.TensorProto) (*Tensor, error) {
+	datatype, ok := tfDatatypes[tp.Dtype]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tftensor dtype %s", tp.Dtype)
+	}
+	if len(tp.TensorContent) > 0 {
+		return nil, fmt.Errorf("tftensors with tensor_content can't be translated")
+	}
+	var shape []int64
+	size := int64(1)
+	if tp.TensorShape != nil {
+		for _, dim := range tp.TensorShape.Dim {
+			shape = append(shape, dim.Size)
+			size *= dim.Size
+		}
+	}
+
+	var data []interface{}
+	switch tp.Dtype {
+	case framework.DataType_DT_FLOAT:
+		for _, v := range tp.FloatVal {
+			data = append(data, float64(v))
+		}
+	case framework.DataType_DT_DOUBLE:
+		for _, v := range tp.DoubleVal {
+			data = append(data, v)
+		}
+	case framework.DataType_DT_INT64:
+		for _, v := range tp.Int64Val {
+			data = append(data, v)
+		}
+	case framework.DataType_DT_UINT32:
+		for _, v := range tp.Uint32Val {
+			data = append(data, int64(v))
+		}
+	case framework.DataType_DT_UINT64:
+		for _, v := range tp.Uint64Val {
+			data = append(data, int64(v))
+		}
+	case framework.DataType_DT_BOOL:
+		for _, v := range tp.BoolVal {
+			data = append(data, v)
+		}
+	case framework.DataType_DT_STRING:
+		for _, v := range tp.StringVal {
+			data = append(data, string(v))
+		}
+	default:
+		for _, v := range tp.IntVal {
+			data = append(data, int64(v))
+		}
+	}
+
+	// Tensorflow allows a single value to fill the whole tensor
+	if len(data) == 1 && size > 1 {
+		for i := int64(1); i < size; i++ {
+			data = append(data, data[0])
+		}
+	}
+	return newTensor("", datatype, shape, data)
+}
+
+func tensorToTF(t *Tensor) (*framework.TensorProto, error) {
+	tp := &framework.TensorProto{TensorShape: &framework.TensorShapeProto{}}
+	for _, dim := range t.Shape {
+		tp.TensorShape.Dim = append(tp.TensorShape.Dim, &framework.TensorShapeProto_Dim{Size: dim})
+	}
+	found := false
+	for dtype, datatype := range tfDatatypes {
+		if datatype == t.Datatype {
+			tp.Dtype = dtype
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("datatype %s can't be sent as a tftensor", t.Datatype)
+	}
+	for _, v := range t.Data {
+		switch tp.Dtype {
+		case framework.DataType_DT_FLOAT:
+			tp.FloatVal = append(tp.FloatVal, float32(v.(float64)))
+		case framework.DataType_DT_DOUBLE:
+			tp.DoubleVal = append(tp.DoubleVal, v.(float64))
+		case framework.DataType_DT_INT64:
+			tp.Int64Val = append(tp.Int64Val, v.(int64))
+		case framework.DataType_DT_UINT32:
+			tp.Uint32Val = append(tp.Uint32Val, uint32(v.(int64)))
+		case framework.DataType_DT_UINT64:
+			tp.Uint64Val = append(tp.Uint64Val, uint64(v.(int64)))
+		case framework.DataType_DT_BOOL:
+			tp.BoolVal = append(tp.BoolVal, v.(bool))
+		case framework.DataType_DT_STRING:
+			tp.StringVal = append(tp.StringVal, []byte(v.(string)))
+		default:
+			tp.IntVal = append(tp.IntVal, int32(v.(int64)))
+		}
+	}
+	return tp, nil
+}
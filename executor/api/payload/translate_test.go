@@ -0,0 +1,309 @@
+package payload
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+)
+
+func TestTranslateRequest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		seldonForm string
+		msg        string
+		expected   string
+	}{
+		{
+			name:     "seldon float ndarray to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"names":["a","b"],"ndarray":[[1.0,2.5],[3,4]]}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"FP64","shape":[2,2],"data":[1.0,2.5,3.0,4.0]}]}`,
+		},
+		{
+			name:     "seldon int ndarray to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"ndarray":[[1,2,3]]}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"INT64","shape":[1,3],"data":[1,2,3]}]}`,
+		},
+		{
+			name:     "seldon string ndarray to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"ndarray":["a","b"]}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"BYTES","shape":[2],"data":["a","b"]}]}`,
+		},
+		{
+			name:     "seldon bool ndarray to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"ndarray":[[true],[false]]}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"BOOL","shape":[2,1],"data":[true,false]}]}`,
+		},
+		{
+			name:     "seldon tensor to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"tensor":{"shape":[2,1,2],"values":[1,2,3,4]}}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"FP64","shape":[2,1,2],"data":[1,2,3,4]}]}`,
+		},
+		{
+			name:     "seldon float tftensor to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"tftensor":{"dtype":"DT_FLOAT","tensorShape":{"dim":[{"size":"1"},{"size":"2"}]},"floatVal":[0.5,1.5]}}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"FP32","shape":[1,2],"data":[0.5,1.5]}]}`,
+		},
+		{
+			name:     "seldon int32 tftensor to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"tftensor":{"dtype":"DT_INT32","tensorShape":{"dim":[{"size":"3"}]},"intVal":[1,2,3]}}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"INT32","shape":[3],"data":[1,2,3]}]}`,
+		},
+		{
+			name:     "seldon tftensor with a single fill value to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"tftensor":{"dtype":"DT_DOUBLE","tensorShape":{"dim":[{"size":"2"},{"size":"2"}]},"doubleVal":[7]}}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"FP64","shape":[2,2],"data":[7,7,7,7]}]}`,
+		},
+		{
+			name:     "seldon string tftensor to v2",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"data":{"tftensor":{"dtype":"DT_STRING","tensorShape":{"dim":[{"size":"1"}]},"stringVal":["aGVsbG8="]}}}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"BYTES","shape":[1],"data":["hello"]}]}`,
+		},
+		{
+			name:     "v2 to seldon ndarray",
+			from:     api.ProtocolKFServing,
+			to:       api.ProtocolSeldon,
+			msg:      `{"id":"1","inputs":[{"name":"x","datatype":"FP32","shape":[2,2],"data":[1,2,3,4]}]}`,
+			expected: `{"data":{"ndarray":[[1,2],[3,4]]}}`,
+		},
+		{
+			name:     "v2 with nested data to seldon ndarray",
+			from:     api.ProtocolKFServing,
+			to:       api.ProtocolSeldon,
+			msg:      `{"inputs":[{"name":"x","datatype":"INT8","shape":[2,2],"data":[[1,2],[3,4]]}]}`,
+			expected: `{"data":{"ndarray":[[1,2],[3,4]]}}`,
+		},
+		{
+			name:       "v2 int to seldon tensor",
+			from:       api.ProtocolKFServing,
+			to:         api.ProtocolSeldon,
+			seldonForm: SeldonDataTensor,
+			msg:        `{"inputs":[{"name":"x","datatype":"INT32","shape":[1,2],"data":[1,2]}]}`,
+			expected:   `{"data":{"tensor":{"shape":[1,2],"values":[1.0,2.0]}}}`,
+		},
+		{
+			name:       "v2 strings to seldon tensor fall back to ndarray",
+			from:       api.ProtocolKFServing,
+			to:         api.ProtocolSeldon,
+			seldonForm: SeldonDataTensor,
+			msg:        `{"inputs":[{"name":"x","datatype":"BYTES","shape":[1],"data":["a"]}]}`,
+			expected:   `{"data":{"ndarray":["a"]}}`,
+		},
+		{
+			name:       "v2 to seldon tftensor",
+			from:       api.ProtocolKFServing,
+			to:         api.ProtocolSeldon,
+			seldonForm: SeldonDataTftensor,
+			msg:        `{"inputs":[{"name":"x","datatype":"FP32","shape":[1,2],"data":[1.5,2]}]}`,
+			expected:   `{"data":{"tftensor":{"dtype":"DT_FLOAT","tensorShape":{"dim":[{"size":"1"},{"size":"2"}]},"floatVal":[1.5,2]}}}`,
+		},
+		{
+			name:     "v2 scalar to seldon",
+			from:     api.ProtocolKFServing,
+			to:       api.ProtocolSeldon,
+			msg:      `{"inputs":[{"name":"x","datatype":"FP64","shape":[],"data":[3]}]}`,
+			expected: `{"data":{"ndarray":[3]}}`,
+		},
+		{
+			name:     "tensorflow instances to v2",
+			from:     api.ProtocolTensorflow,
+			to:       api.ProtocolKFServing,
+			msg:      `{"instances":[[1.0,2.0],[3.0,4.0]]}`,
+			expected: `{"inputs":[{"name":"input-0","datatype":"FP64","shape":[2,2],"data":[1,2,3,4]}]}`,
+		},
+		{
+			name:     "tensorflow named instances to v2",
+			from:     api.ProtocolTensorflow,
+			to:       api.ProtocolKFServing,
+			msg:      `{"instances":[{"b":[1,2],"a":"x"},{"b":[3,4],"a":"y"}]}`,
+			expected: `{"inputs":[{"name":"a","datatype":"BYTES","shape":[2],"data":["x","y"]},{"name":"b","datatype":"INT64","shape":[2,2],"data":[1,2,3,4]}]}`,
+		},
+		{
+			name:     "tensorflow columnar inputs to seldon",
+			from:     api.ProtocolTensorflow,
+			to:       api.ProtocolSeldon,
+			msg:      `{"inputs":[[1,2],[3,4]]}`,
+			expected: `{"data":{"ndarray":[[1,2],[3,4]]}}`,
+		},
+		{
+			name:     "seldon to tensorflow",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolTensorflow,
+			msg:      `{"data":{"tensor":{"shape":[1,2],"values":[1,2]}}}`,
+			expected: `{"instances":[[1.0,2.0]]}`,
+		},
+		{
+			name:     "v2 with several inputs to tensorflow",
+			from:     api.ProtocolKFServing,
+			to:       api.ProtocolTensorflow,
+			msg:      `{"inputs":[{"name":"a","datatype":"INT64","shape":[1],"data":[1]},{"name":"b","datatype":"BOOL","shape":[1],"data":[true]}]}`,
+			expected: `{"inputs":{"a":[1],"b":[true]}}`,
+		},
+		{
+			name:     "same protocol is unchanged",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolSeldon,
+			msg:      `{"strData":"hello"}`,
+			expected: `{"strData":"hello"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &BytesPayload{Msg: []byte(tt.msg), ContentType: "application/json"}
+			res, err := TranslateRequest(msg, tt.from, tt.to, tt.seldonForm)
+			g.Expect(err).To(BeNil())
+			g.Expect(string(res.GetPayload().([]byte))).To(MatchJSON(tt.expected))
+			g.Expect(res.GetContentType()).To(Equal("application/json"))
+		})
+	}
+}
+
+func TestTranslateResponse(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		seldonForm string
+		msg        string
+		expected   string
+	}{
+		{
+			name:       "v2 outputs to seldon in the form of the request",
+			from:       api.ProtocolKFServing,
+			to:         api.ProtocolSeldon,
+			seldonForm: SeldonDataTensor,
+			msg:        `{"model_name":"m","id":"1","outputs":[{"name":"predict","datatype":"FP64","shape":[2],"data":[0.1,0.9]}]}`,
+			expected:   `{"data":{"tensor":{"shape":[2],"values":[0.1,0.9]}}}`,
+		},
+		{
+			name:     "seldon to v2 outputs",
+			from:     api.ProtocolSeldon,
+			to:       api.ProtocolKFServing,
+			msg:      `{"meta":{},"data":{"names":["t:0","t:1"],"ndarray":[[0.1,0.9]]}}`,
+			expected: `{"outputs":[{"name":"output-0","datatype":"FP64","shape":[1,2],"data":[0.1,0.9]}]}`,
+		},
+		{
+			name:     "tensorflow predictions to v2 outputs",
+			from:     api.ProtocolTensorflow,
+			to:       api.ProtocolKFServing,
+			msg:      `{"predictions":[[0.1,0.9]]}`,
+			expected: `{"outputs":[{"name":"output-0","datatype":"FP64","shape":[1,2],"data":[0.1,0.9]}]}`,
+		},
+		{
+			name:     "v2 outputs to tensorflow predictions",
+			from:     api.ProtocolKFServing,
+			to:       api.ProtocolTensorflow,
+			msg:      `{"outputs":[{"name":"predict","datatype":"INT64","shape":[2],"data":[1,0]}]}`,
+			expected: `{"predictions":[1,0]}`,
+		},
+		{
+			name:     "several v2 outputs to tensorflow outputs",
+			from:     api.ProtocolKFServing,
+			to:       api.ProtocolTensorflow,
+			msg:      `{"outputs":[{"name":"a","datatype":"INT64","shape":[1],"data":[1]},{"name":"b","datatype":"FP32","shape":[1],"data":[0.5]}]}`,
+			expected: `{"outputs":{"a":[1],"b":[0.5]}}`,
+		},
+		{
+			name:     "tensorflow named outputs to v2",
+			from:     api.ProtocolTensorflow,
+			to:       api.ProtocolKFServing,
+			msg:      `{"outputs":{"scores":[[0.5,0.5]],"classes":[1]}}`,
+			expected: `{"outputs":[{"name":"classes","datatype":"INT64","shape":[1],"data":[1]},{"name":"scores","datatype":"FP64","shape":[1,2],"data":[0.5,0.5]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &BytesPayload{Msg: []byte(tt.msg), ContentType: "application/json"}
+			res, err := TranslateResponse(msg, tt.from, tt.to, tt.seldonForm)
+			g.Expect(err).To(BeNil())
+			g.Expect(string(res.GetPayload().([]byte))).To(MatchJSON(tt.expected))
+		})
+	}
+}
+
+func TestTranslateErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		seldonForm string
+		msg        string
+	}{
+		{name: "ragged ndarray", from: api.ProtocolSeldon, to: api.ProtocolKFServing, msg: `{"data":{"ndarray":[[1,2],[3]]}}`},
+		{name: "mixed ndarray values", from: api.ProtocolSeldon, to: api.ProtocolKFServing, msg: `{"data":{"ndarray":[1,"a"]}}`},
+		{name: "json data", from: api.ProtocolSeldon, to: api.ProtocolKFServing, msg: `{"jsonData":{"a":1}}`},
+		{name: "str data", from: api.ProtocolSeldon, to: api.ProtocolTensorflow, msg: `{"strData":"hello"}`},
+		{name: "tensor shape mismatch", from: api.ProtocolSeldon, to: api.ProtocolKFServing, msg: `{"data":{"tensor":{"shape":[2,2],"values":[1,2,3]}}}`},
+		{name: "v2 shape mismatch", from: api.ProtocolKFServing, to: api.ProtocolSeldon, msg: `{"inputs":[{"name":"x","datatype":"FP32","shape":[3],"data":[1,2]}]}`},
+		{name: "v2 value not of datatype", from: api.ProtocolKFServing, to: api.ProtocolSeldon, msg: `{"inputs":[{"name":"x","datatype":"INT32","shape":[1],"data":[1.5]}]}`},
+		{name: "v2 several inputs to seldon", from: api.ProtocolKFServing, to: api.ProtocolSeldon, msg: `{"inputs":[{"name":"a","datatype":"INT64","shape":[1],"data":[1]},{"name":"b","datatype":"INT64","shape":[1],"data":[2]}]}`},
+		{name: "v2 fp16 to tftensor", from: api.ProtocolKFServing, to: api.ProtocolSeldon, seldonForm: SeldonDataTftensor, msg: `{"inputs":[{"name":"x","datatype":"FP16","shape":[1],"data":[1]}]}`},
+		{name: "v2 without inputs", from: api.ProtocolKFServing, to: api.ProtocolSeldon, msg: `{"outputs":[]}`},
+		{name: "tensorflow without instances", from: api.ProtocolTensorflow, to: api.ProtocolSeldon, msg: `{"predictions":[1]}`},
+		{name: "unknown protocol", from: "foo", to: api.ProtocolSeldon, msg: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &BytesPayload{Msg: []byte(tt.msg), ContentType: "application/json"}
+			_, err := TranslateRequest(msg, tt.from, tt.to, tt.seldonForm)
+			g.Expect(err).ToNot(BeNil())
+		})
+	}
+}
+
+func TestTranslateKeepsFloats(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	msg := &BytesPayload{Msg: []byte(`{"inputs":[{"name":"x","datatype":"FP32","shape":[3],"data":[1,0.1,100000]}]}`)}
+	res, err := TranslateRequest(msg, api.ProtocolKFServing, api.ProtocolSeldon, "")
+	g.Expect(err).To(BeNil())
+	g.Expect(string(res.GetPayload().([]byte))).To(Equal(`{"data":{"ndarray":[1.0,0.1,100000.0]}}`))
+}
+
+func TestSeldonDataForm(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		msg      string
+		expected string
+	}{
+		{msg: `{"data":{"ndarray":[1]}}`, expected: SeldonDataNdarray},
+		{msg: `{"data":{"tensor":{"shape":[1],"values":[1]}}}`, expected: SeldonDataTensor},
+		{msg: `{"data":{"tftensor":{"dtype":"DT_FLOAT"}}}`, expected: SeldonDataTftensor},
+		{msg: `{"strData":"a"}`, expected: ""},
+		{msg: `not json`, expected: ""},
+	}
+
+	for _, tt := range tests {
+		g.Expect(SeldonDataForm(&BytesPayload{Msg: []byte(tt.msg)})).To(Equal(tt.expected))
+	}
+}
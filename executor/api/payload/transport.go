@@ -0,0 +1,218 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+)
+
+// ProtoToJSON converts the payload of a gRPC call into the JSON of the same call over REST. V2 inference
+// messages are written in the V2 REST form and all other messages with jsonpb.
+func ProtoToJSON(msg SeldonPayload) (SeldonPayload, error) {
+	var b []byte
+	var err error
+	switch v := msg.GetPayload().(type) {
+	case []byte:
+		return msg, nil
+	case *inference.ModelInferRequest:
+		b, err = v2ProtoToJSON(v.ModelName, v.ModelVersion, v.Id, "inputs", "input", v2InputTensors(v), len(v.RawInputContents))
+	case *inference.ModelInferResponse:
+		b, err = v2ProtoToJSON(v.ModelName, v.ModelVersion, v.Id, "outputs", "output", v2OutputTensors(v), len(v.RawOutputContents))
+	case proto.Message:
+		ma := jsonpb.Marshaler{}
+		var s string
+		s, err = ma.MarshalToString(v)
+		b = []byte(s)
+	default:
+		err = fmt.Errorf("unable to convert payload of type %T to JSON", v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &BytesPayload{Msg: b, ContentType: "application/json"}, nil
+}
+
+// JSONToProto converts the JSON payload of a REST call into pb, the message of the same call over gRPC.
+func JSONToProto(msg SeldonPayload, pb proto.Message) (SeldonPayload, error) {
+	if _, ok := msg.GetPayload().(proto.Message); ok {
+		return msg, nil
+	}
+	data, err := DecompressSeldonPayload(msg)
+	if err != nil {
+		return nil, err
+	}
+	switch v := pb.(type) {
+	case *inference.ModelInferRequest:
+		tensors, err := DecodeTensors(api.ProtocolKFServing, data, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &v2Header{ModelName: &v.ModelName, ModelVersion: &v.ModelVersion, Id: &v.Id}); err != nil {
+			return nil, err
+		}
+		for i := range tensors {
+			t := &tensors[i]
+			contents, err := v2Contents(t)
+			if err != nil {
+				return nil, err
+			}
+			v.Inputs = append(v.Inputs, &inference.ModelInferRequest_InferInputTensor{Name: t.Name, Datatype: t.Datatype, Shape: t.Shape, Contents: contents})
+		}
+	case *inference.ModelInferResponse:
+		tensors, err := DecodeTensors(api.ProtocolKFServing, data, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &v2Header{ModelName: &v.ModelName, ModelVersion: &v.ModelVersion, Id: &v.Id}); err != nil {
+			return nil, err
+		}
+		for i := range tensors {
+			t := &tensors[i]
+			contents, err := v2Contents(t)
+			if err != nil {
+				return nil, err
+			}
+			v.Outputs = append(v.Outputs, &inference.ModelInferResponse_InferOutputTensor{Name: t.Name, Datatype: t.Datatype, Shape: t.Shape, Contents: contents})
+		}
+	default:
+		um := jsonpb.Unmarshaler{AllowUnknownFields: true}
+		if err := um.Unmarshal(bytes.NewReader(data), pb); err != nil {
+			return nil, err
+		}
+	}
+	return &ProtoPayload{Msg: pb}, nil
+}
+
+type v2Header struct {
+	ModelName    *string `json:"model_name,omitempty"`
+	ModelVersion *string `json:"model_version,omitempty"`
+	Id           *string `json:"id,omitempty"`
+}
+
+type v2ProtoTensor struct {
+	name     string
+	datatype string
+	shape    []int64
+	contents *inference.InferTensorContents
+}
+
+func v2InputTensors(req *inference.ModelInferRequest) []v2ProtoTensor {
+	tensors := make([]v2ProtoTensor, len(req.Inputs))
+	for i, input := range req.Inputs {
+		tensors[i] = v2ProtoTensor{input.Name, input.Datatype, input.Shape, input.Contents}
+	}
+	return tensors
+}
+
+func v2OutputTensors(res *inference.ModelInferResponse) []v2ProtoTensor {
+	tensors := make([]v2ProtoTensor, len(res.Outputs))
+	for i, output := range res.Outputs {
+		tensors[i] = v2ProtoTensor{output.Name, output.Datatype, output.Shape, output.Contents}
+	}
+	return tensors
+}
+
+func v2ProtoToJSON(modelName string, modelVersion string, id string, key string, prefix string, protoTensors []v2ProtoTensor, raw int) ([]byte, error) {
+	if raw > 0 {
+		return nil, fmt.Errorf("V2 messages with raw contents can't be converted to JSON")
+	}
+	tensors := make([]Tensor, len(protoTensors))
+	for i, pt := range protoTensors {
+		data, err := v2ContentsData(pt.datatype, pt.contents)
+		if err != nil {
+			return nil, err
+		}
+		t, err := newTensor(pt.name, pt.datatype, pt.shape, data)
+		if err != nil {
+			return nil, err
+		}
+		tensors[i] = *t
+	}
+	m, err := encodeV2(tensors, key, prefix)
+	if err != nil {
+		return nil, err
+	}
+	doc := m.(map[string]interface{})
+	for k, v := range map[string]string{"model_name": modelName, "model_version": modelVersion, "id": id} {
+		if v != "" {
+			doc[k] = v
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func v2Contents(t *Tensor) (*inference.InferTensorContents, error) {
+	contents := &inference.InferTensorContents{}
+	for _, v := range t.Data {
+		switch t.Datatype {
+		case "BOOL":
+			contents.BoolContents = append(contents.BoolContents, v.(bool))
+		case "INT8", "INT16", "INT32":
+			contents.IntContents = append(contents.IntContents, int32(v.(int64)))
+		case "INT64":
+			contents.Int64Contents = append(contents.Int64Contents, v.(int64))
+		case "UINT8", "UINT16", "UINT32":
+			contents.UintContents = append(contents.UintContents, uint32(v.(int64)))
+		case "UINT64":
+			contents.Uint64Contents = append(contents.Uint64Contents, uint64(v.(int64)))
+		case "FP32":
+			contents.Fp32Contents = append(contents.Fp32Contents, float32(v.(float64)))
+		case "FP64":
+			contents.Fp64Contents = append(contents.Fp64Contents, v.(float64))
+		case "BYTES":
+			contents.ByteContents = append(contents.ByteContents, []byte(v.(string)))
+		default:
+			return nil, fmt.Errorf("datatype %s can't be sent as typed V2 contents", t.Datatype)
+		}
+	}
+	return contents, nil
+}
+
+func v2ContentsData(datatype string, contents *inference.InferTensorContents) ([]interface{}, error) {
+	var data []interface{}
+	if contents == nil {
+		return data, nil
+	}
+	switch datatype {
+	case "BOOL":
+		for _, v := range contents.BoolContents {
+			data = append(data, v)
+		}
+	case "INT8", "INT16", "INT32":
+		for _, v := range contents.IntContents {
+			data = append(data, int64(v))
+		}
+	case "INT64":
+		for _, v := range contents.Int64Contents {
+			data = append(data, v)
+		}
+	case "UINT8", "UINT16", "UINT32":
+		for _, v := range contents.UintContents {
+			data = append(data, int64(v))
+		}
+	case "UINT64":
+		for _, v := range contents.Uint64Contents {
+			data = append(data, int64(v))
+		}
+	case "FP32":
+		for _, v := range contents.Fp32Contents {
+			data = append(data, float64(v))
+		}
+	case "FP64":
+		for _, v := range contents.Fp64Contents {
+			data = append(data, v)
+		}
+	case "BYTES":
+		for _, v := range contents.ByteContents {
+			data = append(data, string(v))
+		}
+	default:
+		return nil, fmt.Errorf("datatype %s can't be read from typed V2 contents", datatype)
+	}
+	return data, nil
+}
@@ -0,0 +1,67 @@
+package payload
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+)
+
+func TestV2JSONToProto(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	msg := &BytesPayload{Msg: []byte(`{"id":"1","inputs":[{"name":"a","datatype":"FP32","shape":[1,2],"data":[1.5,2]},{"name":"b","datatype":"BYTES","shape":[1],"data":["x"]}]}`)}
+	res, err := JSONToProto(msg, &inference.ModelInferRequest{})
+	g.Expect(err).To(BeNil())
+	req := res.GetPayload().(*inference.ModelInferRequest)
+	g.Expect(req.Id).To(Equal("1"))
+	g.Expect(req.Inputs).To(HaveLen(2))
+	g.Expect(req.Inputs[0].Shape).To(Equal([]int64{1, 2}))
+	g.Expect(req.Inputs[0].Contents.Fp32Contents).To(Equal([]float32{1.5, 2}))
+	g.Expect(req.Inputs[1].Contents.ByteContents).To(Equal([][]byte{[]byte("x")}))
+
+	back, err := ProtoToJSON(res)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(back.GetPayload().([]byte))).To(MatchJSON(`{"id":"1","inputs":[{"name":"a","datatype":"FP32","shape":[1,2],"data":[1.5,2.0]},{"name":"b","datatype":"BYTES","shape":[1],"data":["x"]}]}`))
+}
+
+func TestV2ProtoToJSON(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	res := &inference.ModelInferResponse{
+		ModelName: "model",
+		Outputs: []*inference.ModelInferResponse_InferOutputTensor{
+			{Name: "out", Datatype: "INT64", Shape: []int64{2}, Contents: &inference.InferTensorContents{Int64Contents: []int64{3, 4}}},
+		},
+	}
+	msg, err := ProtoToJSON(&ProtoPayload{Msg: res})
+	g.Expect(err).To(BeNil())
+	g.Expect(string(msg.GetPayload().([]byte))).To(MatchJSON(`{"model_name":"model","outputs":[{"name":"out","datatype":"INT64","shape":[2],"data":[3,4]}]}`))
+
+	res.RawOutputContents = [][]byte{{1}}
+	_, err = ProtoToJSON(&ProtoPayload{Msg: res})
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestSeldonMessageTransport(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	msg := &BytesPayload{Msg: []byte(`{"data":{"names":["a"],"ndarray":[[1]]}}`)}
+	res, err := JSONToProto(msg, &proto.SeldonMessage{})
+	g.Expect(err).To(BeNil())
+	sm := res.GetPayload().(*proto.SeldonMessage)
+	g.Expect(sm.GetData().GetNames()).To(Equal([]string{"a"}))
+
+	back, err := ProtoToJSON(res)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(back.GetPayload().([]byte))).To(MatchJSON(`{"data":{"names":["a"],"ndarray":[[1]]}}`))
+
+	// Payloads already in the target form are unchanged
+	same, err := JSONToProto(res, &proto.SeldonMessage{})
+	g.Expect(err).To(BeNil())
+	g.Expect(same).To(Equal(res))
+	same, err = ProtoToJSON(msg)
+	g.Expect(err).To(BeNil())
+	g.Expect(same).To(Equal(msg))
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/api/util"
 	"github.com/seldonio/seldon-core/executor/k8s"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
@@ -187,7 +188,7 @@ func (smc *JSONRestClient) doHttp(ctx context.Context, modelName string, method
 	var req *http.Request
 	var err error
 	if msg != nil {
-		req, err = http.NewRequest("POST", url.String(), bytes.NewBuffer(msg))
+		req, err = http.NewRequestWithContext(ctx, "POST", url.String(), bytes.NewBuffer(msg))
 		if err != nil {
 			return nil, "", "", err
 		}
@@ -196,7 +197,7 @@ func (smc *JSONRestClient) doHttp(ctx context.Context, modelName string, method
 			req.Header.Set("Content-Encoding", contentEncoding)
 		}
 	} else {
-		req, err = http.NewRequest("GET", url.String(), nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 		if err != nil {
 			return nil, "", "", err
 		}
@@ -205,7 +206,10 @@ func (smc *JSONRestClient) doHttp(ctx context.Context, modelName string, method
 	// Add metadata passed in
 	smc.addHeaders(req, meta)
 
-	if opentracing.IsGlobalTracerRegistered() {
+	if tracing.OpenTelemetryEnabled() {
+		_, clientSpan := tracing.StartHTTPClientSpan(ctx, method, req.Header)
+		defer clientSpan.End()
+	} else if opentracing.IsGlobalTracerRegistered() {
 		tracer := opentracing.GlobalTracer()
 
 		startSpanOptions := make([]opentracing.StartSpanOption, 0)
@@ -246,8 +250,19 @@ func (smc *JSONRestClient) doHttp(ctx context.Context, modelName string, method
 	return b, contentTypeResponse, contentEncodingResponse, err
 }
 
-func (smc *JSONRestClient) modifyMethod(method string, modelName string) string {
-	switch smc.Protocol {
+// nodeProtocol returns the protocol of the graph node a call is for, which is that of the graph unless the node
+// sets its own.
+func (smc *JSONRestClient) nodeProtocol(ctx context.Context, modelName string) string {
+	if smc.predictor != nil {
+		if pu := v1.GetPredictiveUnitForPredictor(smc.predictor, client.NodeName(ctx, modelName)); pu != nil && pu.Protocol != "" {
+			return string(pu.Protocol)
+		}
+	}
+	return smc.Protocol
+}
+
+func (smc *JSONRestClient) modifyMethod(ctx context.Context, method string, modelName string) string {
+	switch smc.nodeProtocol(ctx, modelName) {
 	case api.ProtocolTensorflow:
 		switch method {
 		case client.SeldonPredictPath, client.SeldonTransformInputPath, client.SeldonTransformOutputPath:
@@ -277,6 +292,14 @@ func (smc *JSONRestClient) modifyMethod(method string, modelName string) string
 			return "/v2/models/" + modelName + "/ready"
 		case client.SeldonMetadataPath:
 			return "/v2/models/" + modelName
+		case client.SeldonModelConfigPath:
+			return "/v2/models/" + modelName + "/config"
+		case client.SeldonRepositoryIndexPath:
+			return "/v2/repository/index"
+		case client.SeldonModelLoadPath:
+			return "/v2/repository/models/" + modelName + "/load"
+		case client.SeldonModelUnloadPath:
+			return "/v2/repository/models/" + modelName + "/unload"
 		}
 	default:
 		return method
@@ -312,13 +335,66 @@ func (smc *JSONRestClient) call(ctx context.Context, modelName string, method st
 	return &res, err
 }
 
+// callTranslated calls a node whose protocol may differ from that of the graph, translating the request into
+// the node's protocol and its response into resProtocol.
+func (smc *JSONRestClient) callTranslated(ctx context.Context, modelName string, method string, host string, port int32, req payload.SeldonPayload, meta map[string][]string, resProtocol string) (payload.SeldonPayload, error) {
+	protocol := smc.nodeProtocol(ctx, modelName)
+	if protocol == smc.Protocol && protocol == resProtocol {
+		return smc.call(ctx, modelName, smc.modifyMethod(ctx, method, modelName), host, port, req, meta)
+	}
+
+	seldonForm := ""
+	if smc.Protocol == api.ProtocolSeldon {
+		seldonForm = payload.SeldonDataForm(req)
+	}
+	treq, err := payload.TranslateRequest(req, smc.Protocol, protocol, seldonForm)
+	if err != nil {
+		return smc.CreateErrorPayload(err), err
+	}
+	res, err := smc.call(ctx, modelName, smc.modifyMethod(ctx, method, modelName), host, port, treq, meta)
+	if err != nil {
+		return res, err
+	}
+	tres, err := payload.TranslateResponse(res, protocol, resProtocol, seldonForm)
+	if err != nil {
+		return smc.CreateErrorPayload(err), err
+	}
+	return tres, nil
+}
+
 func (smc *JSONRestClient) Status(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonStatusPath, modelName), host, port, msg, meta)
+	return smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonStatusPath, modelName), host, port, msg, meta)
 }
 
 // Return model's metadata as payload.SeldonPaylaod (to expose as received on corresponding executor endpoint)
 func (smc *JSONRestClient) Metadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonMetadataPath, modelName), host, port, msg, meta)
+	return smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonMetadataPath, modelName), host, port, msg, meta)
+}
+
+func (smc *JSONRestClient) callRepository(ctx context.Context, modelName string, method string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if protocol := smc.nodeProtocol(ctx, modelName); protocol != api.ProtocolKFServing {
+		return nil, errors.Errorf("%s is not supported for protocol %s", method, protocol)
+	}
+	if msg == nil && method != client.SeldonModelConfigPath {
+		msg = &payload.BytesPayload{Msg: []byte("{}"), ContentType: ContentTypeJSON}
+	}
+	return smc.call(ctx, modelName, smc.modifyMethod(ctx, method, modelName), host, port, msg, meta)
+}
+
+func (smc *JSONRestClient) ModelConfig(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return smc.callRepository(ctx, modelName, client.SeldonModelConfigPath, host, port, nil, meta)
+}
+
+func (smc *JSONRestClient) RepositoryIndex(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return smc.callRepository(ctx, modelName, client.SeldonRepositoryIndexPath, host, port, msg, meta)
+}
+
+func (smc *JSONRestClient) RepositoryModelLoad(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return smc.callRepository(ctx, modelName, client.SeldonModelLoadPath, host, port, msg, meta)
+}
+
+func (smc *JSONRestClient) RepositoryModelUnload(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	return smc.callRepository(ctx, modelName, client.SeldonModelUnloadPath, host, port, msg, meta)
 }
 
 // Return model's metadata decoded to payload.ModelMetadata (to build GraphMetadata)
@@ -353,16 +429,21 @@ func (smc *JSONRestClient) Chain(ctx context.Context, modelName string, msg payl
 }
 
 func (smc *JSONRestClient) Predict(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonPredictPath, modelName), host, port, req, meta)
+	return smc.callTranslated(ctx, modelName, client.SeldonPredictPath, host, port, req, meta, smc.Protocol)
 }
 
 func (smc *JSONRestClient) TransformInput(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonTransformInputPath, modelName), host, port, req, meta)
+	return smc.callTranslated(ctx, modelName, client.SeldonTransformInputPath, host, port, req, meta, smc.Protocol)
 }
 
 // Try to extract from SeldonMessage otherwise fall back to extract from Json Array
 func (smc *JSONRestClient) Route(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (int, error) {
-	sp, err := smc.call(ctx, modelName, smc.modifyMethod(client.SeldonRoutePath, modelName), host, port, req, meta)
+	resProtocol := smc.Protocol
+	if smc.nodeProtocol(ctx, modelName) != smc.Protocol {
+		// Routes are read from SeldonMessages
+		resProtocol = api.ProtocolSeldon
+	}
+	sp, err := smc.callTranslated(ctx, modelName, client.SeldonRoutePath, host, port, req, meta, resProtocol)
 	if err != nil {
 		return 0, err
 	} else {
@@ -370,22 +451,49 @@ func (smc *JSONRestClient) Route(ctx context.Context, modelName string, host str
 	}
 }
 
+// Combine sends a combiner whose protocol differs from that of the graph the outputs of its children in its own
+// protocol, and translates its response back.
 func (smc *JSONRestClient) Combine(ctx context.Context, modelName string, host string, port int32, msgs []payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	protocol := smc.nodeProtocol(ctx, modelName)
+	seldonForm := ""
+	if smc.Protocol == api.ProtocolSeldon && len(msgs) > 0 {
+		seldonForm = payload.SeldonDataForm(msgs[0])
+	}
+	if protocol != smc.Protocol {
+		tmsgs := make([]payload.SeldonPayload, len(msgs))
+		for i, msg := range msgs {
+			tmsg, err := payload.TranslateResponse(msg, smc.Protocol, protocol, seldonForm)
+			if err != nil {
+				return smc.CreateErrorPayload(err), err
+			}
+			tmsgs[i] = tmsg
+		}
+		msgs = tmsgs
+	}
 	req, err := CombineSeldonMessagesToJson(msgs)
 	if err != nil {
 		return nil, err
 	}
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonCombinePath, modelName), host, port, req, meta)
+	res, err := smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonCombinePath, modelName), host, port, req, meta)
+	if err != nil || protocol == smc.Protocol {
+		return res, err
+	}
+	tres, err := payload.TranslateResponse(res, protocol, smc.Protocol, seldonForm)
+	if err != nil {
+		return smc.CreateErrorPayload(err), err
+	}
+	return tres, nil
 }
 
 func (smc *JSONRestClient) TransformOutput(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonTransformOutputPath, modelName), host, port, req, meta)
+	return smc.callTranslated(ctx, modelName, client.SeldonTransformOutputPath, host, port, req, meta, smc.Protocol)
 }
 
 func (smc *JSONRestClient) Feedback(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	// Currently feedback is enabled across all protocols but client only works on seldon protocol
-	if smc.Protocol != api.ProtocolSeldon {
+	// Currently feedback is enabled across all protocols but client only works on seldon protocol, which nodes with a
+	// protocol of their own may not use
+	if smc.Protocol != api.ProtocolSeldon || smc.nodeProtocol(ctx, modelName) != api.ProtocolSeldon {
 		return req, nil
 	}
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonFeedbackPath, modelName), host, port, req, meta)
+	return smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonFeedbackPath, modelName), host, port, req, meta)
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -17,6 +18,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
@@ -381,3 +383,66 @@ func TestMarshall(t *testing.T) {
 		g.Expect(w.String()).To(Equal(test.expected))
 	}
 }
+
+func TestNodeProtocolTranslation(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+	var path string
+	var body []byte
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"model_name":"model","outputs":[{"name":"predict","datatype":"FP64","shape":[1,2],"data":[0.9,0.1]}]}`))
+	})
+	host, port, httpClient, teardown := testingHTTPClient(g, h)
+	defer teardown()
+	predictor := v1.PredictorSpec{
+		Name:        "test",
+		Annotations: map[string]string{},
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Protocol: v1.ProtocolKfserving,
+		},
+	}
+	seldonRestClient, err := NewJSONRestClient(api.ProtocolSeldon, "test", &predictor, nil, SetHTTPClient(httpClient))
+	g.Expect(err).To(BeNil())
+
+	req := &payload.BytesPayload{Msg: []byte(`{"data":{"tensor":{"shape":[1,2],"values":[1.1,2.0]}}}`), ContentType: ContentTypeJSON}
+	resPayload, err := seldonRestClient.Predict(createTestContext(), "model", host, int32(port), req, map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(path).To(Equal("/v2/models/model/infer"))
+	g.Expect(string(body)).To(MatchJSON(`{"inputs":[{"name":"input-0","datatype":"FP64","shape":[1,2],"data":[1.1,2.0]}]}`))
+	g.Expect(string(resPayload.GetPayload().([]byte))).To(MatchJSON(`{"data":{"tensor":{"shape":[1,2],"values":[0.9,0.1]}}}`))
+
+	// A node called with an overridden model name keeps its protocol
+	ctx := client.WithNodeName(createTestContext(), "model")
+	_, err = seldonRestClient.Predict(ctx, "served", host, int32(port), req, map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(path).To(Equal("/v2/models/served/infer"))
+
+	// Nodes without a protocol use that of the graph
+	resPayload, err = seldonRestClient.Predict(createTestContext(), "other", host, int32(port), req, map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(path).To(Equal("/predict"))
+	g.Expect(string(body)).To(Equal(`{"data":{"tensor":{"shape":[1,2],"values":[1.1,2.0]}}}`))
+
+	// A combiner is sent the outputs of its children in its own protocol and its response is translated back
+	resPayload, err = seldonRestClient.Combine(createTestContext(), "model", host, int32(port), []payload.SeldonPayload{req, req}, map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(path).To(Equal("/v2/models/model/aggregate"))
+	g.Expect(string(body)).To(MatchJSON(`[` +
+		`{"outputs":[{"name":"output-0","datatype":"FP64","shape":[1,2],"data":[1.1,2.0]}]},` +
+		`{"outputs":[{"name":"output-0","datatype":"FP64","shape":[1,2],"data":[1.1,2.0]}]}]`))
+	g.Expect(string(resPayload.GetPayload().([]byte))).To(MatchJSON(`{"data":{"tensor":{"shape":[1,2],"values":[0.9,0.1]}}}`))
+
+	// Feedback is only sent to nodes using the Seldon protocol
+	path = ""
+	feedback := &payload.BytesPayload{Msg: []byte(`{"reward":1}`), ContentType: ContentTypeJSON}
+	resPayload, err = seldonRestClient.Feedback(createTestContext(), "model", host, int32(port), feedback, map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(resPayload).To(Equal(feedback))
+	g.Expect(path).To(BeEmpty())
+	_, err = seldonRestClient.Feedback(createTestContext(), "other", host, int32(port), feedback, map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(path).To(Equal(client.SeldonFeedbackPath))
+}
@@ -4,6 +4,9 @@ const (
 	TracingPredictionsName = "predictions"
 	TracingStatusName      = "status"
 	TracingMetadataName    = "metadata"
+	TracingConfigName      = "config"
+	TracingStatsName       = "stats"
+	TracingRepositoryName  = "repository"
 
 	LoggingRestClientName = "RestClient"
 )
@@ -17,3 +17,8 @@ func (e *httpStatusError) Error() string {
 func invalidPayload(msg string) error {
 	return fmt.Errorf("invalid payload: %s", msg)
 }
+
+// HTTPStatus returns the status code the service responded with.
+func (e *httpStatusError) HTTPStatus() int {
+	return e.StatusCode
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/predictor"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -93,6 +94,10 @@ func (r *SeldonRestApi) respondWithError(w http.ResponseWriter, payload payload.
 
 	if serr, ok := err.(*httpStatusError); ok {
 		w.WriteHeader(serr.StatusCode)
+	} else if _, ok := err.(*predictor.CircuitOpenError); ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else if _, ok := err.(*predictor.ModelNotFoundError); ok {
+		w.WriteHeader(http.StatusNotFound)
 	} else {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
@@ -179,17 +184,27 @@ func (r *SeldonRestApi) Initialise() {
 			// Enabling for standard seldon core feedback API endpoint with standard schema
 			r.Router.NewRoute().Path("/api/v1.0/feedback").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.FeedbackHttpServiceName, r.feedback))
 		case api.ProtocolKFServing:
+			r.Router.NewRoute().Path("/v2/health/live").Methods("GET", "OPTIONS").HandlerFunc(r.alive)
+			r.Router.NewRoute().Path("/v2/health/ready").Methods("GET", "OPTIONS").HandlerFunc(r.checkReady)
+			r.Router.NewRoute().Path("/v2").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.serverMetadata))
 			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}/infer").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.PredictionHttpServiceName, r.predictions))
 			r.Router.NewRoute().Path("/v2/models/infer").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.PredictionHttpServiceName, r.predictions)) // Nonstandard path - Seldon extension
 			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}/ready").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatusHttpServiceName, r.status))
+			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}/config").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.ConfigHttpServiceName, r.modelConfig))
+			// Register the stats routes before metadata so "stats" is not taken as a model name
+			r.Router.NewRoute().Path("/v2/models/stats").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatsHttpServiceName, r.modelStatistics))
+			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}/stats").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatsHttpServiceName, r.modelStatistics))
 			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.metadata))
+			r.Router.NewRoute().Path("/v2/repository/index").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.RepositoryHttpServiceName, r.repositoryIndex))
+			r.Router.NewRoute().Path("/v2/repository/models/{"+ModelHttpPathVariable+"}/load").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.RepositoryHttpServiceName, r.repositoryModelLoad))
+			r.Router.NewRoute().Path("/v2/repository/models/{"+ModelHttpPathVariable+"}/unload").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.RepositoryHttpServiceName, r.repositoryModelUnload))
 
 		}
 	}
 }
 
 func (r *SeldonRestApi) checkReady(w http.ResponseWriter, req *http.Request) {
-	err := predictor.Ready(&r.predictor.Graph)
+	err := predictor.ReadyPredictor(r.predictor)
 	if err != nil {
 		r.Log.Error(err, "Ready check failed")
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -203,6 +218,11 @@ func (r *SeldonRestApi) alive(w http.ResponseWriter, req *http.Request) {
 }
 
 func setupTracing(ctx context.Context, req *http.Request, spanName string) (context.Context, opentracing.Span) {
+	if tracing.OpenTelemetryEnabled() {
+		// The OpenTracing bridge sets the OpenTelemetry span as the context's OpenTracing span too
+		ctx, _ = tracing.StartHTTPServerSpan(ctx, spanName, req.Header)
+		return ctx, opentracing.SpanFromContext(ctx)
+	}
 	tracer := opentracing.GlobalTracer()
 	spanCtx, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
 	serverSpan := tracer.StartSpan(spanName, ext.RPCServerOption(spanCtx))
@@ -224,7 +244,7 @@ func (r *SeldonRestApi) metadata(w http.ResponseWriter, req *http.Request) {
 	modelName := vars[ModelHttpPathVariable]
 
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, modelName)
-	resPayload, err := seldonPredictorProcess.Metadata(&r.predictor.Graph, modelName, nil)
+	resPayload, err := seldonPredictorProcess.Metadata(r.predictor, modelName, nil)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -246,7 +266,7 @@ func (r *SeldonRestApi) status(w http.ResponseWriter, req *http.Request) {
 	modelName := vars[ModelHttpPathVariable]
 
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, modelName)
-	resPayload, err := seldonPredictorProcess.Status(&r.predictor.Graph, modelName, nil)
+	resPayload, err := seldonPredictorProcess.Status(r.predictor, modelName, nil)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -278,7 +298,7 @@ func (r *SeldonRestApi) feedback(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	resPayload, err := seldonPredictorProcess.Feedback(&r.predictor.Graph, reqPayload)
+	resPayload, err := seldonPredictorProcess.FeedbackGraph(r.predictor, reqPayload)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -317,7 +337,7 @@ func (r *SeldonRestApi) predictions(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	resPayload, err := seldonPredictorProcess.Predict(&r.predictor.Graph, reqPayload)
+	resPayload, err := seldonPredictorProcess.PredictGraph(r.predictor, reqPayload)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -351,3 +371,142 @@ func (r *SeldonRestApi) graphMetadata(w http.ResponseWriter, req *http.Request)
 
 	r.respondWithSuccess(w, http.StatusOK, &resPayload)
 }
+
+func (r *SeldonRestApi) serverMetadata(w http.ResponseWriter, req *http.Request) {
+	seldonPredictorProcess := predictor.NewPredictorProcess(req.Context(), r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, "")
+	msg, _ := json.Marshal(seldonPredictorProcess.ServerMetadata(r.predictor))
+	resPayload := payload.BytesPayload{Msg: msg, ContentType: ContentTypeJSON}
+	r.respondWithSuccess(w, http.StatusOK, &resPayload)
+}
+
+func (r *SeldonRestApi) modelConfig(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		var serverSpan opentracing.Span
+		ctx, serverSpan = setupTracing(ctx, req, TracingConfigName)
+		defer serverSpan.Finish()
+	}
+
+	vars := mux.Vars(req)
+	modelName := vars[ModelHttpPathVariable]
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, modelName)
+	resPayload, err := seldonPredictorProcess.ModelConfig(r.predictor, modelName, nil)
+	if err != nil {
+		r.respondWithError(w, resPayload, err)
+		return
+	}
+	r.respondWithSuccess(w, http.StatusOK, resPayload)
+}
+
+func (r *SeldonRestApi) modelStatistics(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		var serverSpan opentracing.Span
+		ctx, serverSpan = setupTracing(ctx, req, TracingStatsName)
+		defer serverSpan.Finish()
+	}
+
+	vars := mux.Vars(req)
+	modelName := vars[ModelHttpPathVariable]
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, modelName)
+	stats, err := seldonPredictorProcess.ModelStatistics(r.predictor, modelName)
+	if err != nil {
+		r.respondWithError(w, nil, err)
+		return
+	}
+
+	msg, err := json.Marshal(map[string]interface{}{"model_stats": stats})
+	if err != nil {
+		r.respondWithError(w, nil, err)
+		return
+	}
+	resPayload := payload.BytesPayload{Msg: msg, ContentType: ContentTypeJSON}
+	r.respondWithSuccess(w, http.StatusOK, &resPayload)
+}
+
+// readRepositoryRequest returns the body of a repository request, which may be empty.
+func (r *SeldonRestApi) readRepositoryRequest(req *http.Request) (payload.SeldonPayload, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	bodyBytes, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bodyBytes) == 0 {
+		return nil, nil
+	}
+	return &payload.BytesPayload{Msg: bodyBytes, ContentType: ContentTypeJSON}, nil
+}
+
+func (r *SeldonRestApi) repositoryIndex(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		var serverSpan opentracing.Span
+		ctx, serverSpan = setupTracing(ctx, req, TracingRepositoryName)
+		defer serverSpan.Finish()
+	}
+
+	reqPayload, err := r.readRepositoryRequest(req)
+	if err != nil {
+		r.respondWithError(w, nil, err)
+		return
+	}
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, "")
+	resPayload, err := seldonPredictorProcess.RepositoryIndex(r.predictor, reqPayload)
+	if err != nil {
+		r.respondWithError(w, resPayload, err)
+		return
+	}
+	r.respondWithSuccess(w, http.StatusOK, resPayload)
+}
+
+func (r *SeldonRestApi) repositoryModelLoad(w http.ResponseWriter, req *http.Request) {
+	r.repositoryModelAction(w, req, true)
+}
+
+func (r *SeldonRestApi) repositoryModelUnload(w http.ResponseWriter, req *http.Request) {
+	r.repositoryModelAction(w, req, false)
+}
+
+func (r *SeldonRestApi) repositoryModelAction(w http.ResponseWriter, req *http.Request, load bool) {
+	ctx := req.Context()
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		var serverSpan opentracing.Span
+		ctx, serverSpan = setupTracing(ctx, req, TracingRepositoryName)
+		defer serverSpan.Finish()
+	}
+
+	reqPayload, err := r.readRepositoryRequest(req)
+	if err != nil {
+		r.respondWithError(w, nil, err)
+		return
+	}
+
+	vars := mux.Vars(req)
+	modelName := vars[ModelHttpPathVariable]
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, modelName)
+	var resPayload payload.SeldonPayload
+	if load {
+		resPayload, err = seldonPredictorProcess.RepositoryModelLoad(r.predictor, modelName, reqPayload)
+	} else {
+		resPayload, err = seldonPredictorProcess.RepositoryModelUnload(r.predictor, modelName, reqPayload)
+	}
+	if err != nil {
+		r.respondWithError(w, resPayload, err)
+		return
+	}
+	r.respondWithSuccess(w, http.StatusOK, resPayload)
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -16,6 +17,7 @@ import (
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/test"
+	"github.com/seldonio/seldon-core/executor/predictor"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 )
 
@@ -708,3 +710,170 @@ func TestTensorflowModelBadModelName(t *testing.T) {
 	r.Router.ServeHTTP(res, req)
 	g.Expect(res.Code).To(Equal(200))
 }
+
+func TestKFServingRepositoryWithServer(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/models/mymodel/config":
+			w.Write([]byte(`{"name":"mymodel","platform":"sklearn"}`))
+		case "/v2/repository/index":
+			w.Write([]byte(`[{"name":"mymodel","version":"1","state":"READY"}]`))
+		case "/v2/repository/models/mymodel/load":
+		case "/v2/models/mymodel/infer":
+			bodyBytes, _ := ioutil.ReadAll(r.Body)
+			w.Write(bodyBytes)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	url, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	urlParts := strings.Split(url.Host, ":")
+	port, err := strconv.Atoi(urlParts[1])
+	g.Expect(err).Should(BeNil())
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "mymodel",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: urlParts[0],
+				ServicePort: int32(port),
+				Type:        v1.REST,
+				HttpPort:    int32(port),
+			},
+		},
+	}
+
+	client, err := NewJSONRestClient(api.ProtocolKFServing, "dep", &p, nil)
+	g.Expect(err).To(BeNil())
+	r := NewServerRestApi(&p, client, false, url, "default", api.ProtocolKFServing, "test", "/metrics")
+	r.Initialise()
+
+	req, _ := http.NewRequest("POST", "/v2/models/mymodel/infer", strings.NewReader(`{"inputs":[]}`))
+	req.Header = map[string][]string{"Content-Type": []string{"application/json"}}
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+
+	req, _ = http.NewRequest("GET", "/v2/models/mymodel/config", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+	g.Expect(res.Body.String()).To(Equal(`{"name":"mymodel","platform":"sklearn"}`))
+
+	req, _ = http.NewRequest("POST", "/v2/repository/index", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+	g.Expect(res.Body.String()).To(Equal(`[{"name":"mymodel","version":"1","state":"READY"}]`))
+
+	req, _ = http.NewRequest("POST", "/v2/repository/models/mymodel/load", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+
+	req, _ = http.NewRequest("POST", "/v2/repository/models/other/unload", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(404))
+
+	g.Expect(paths).To(Equal([]string{
+		"POST /v2/models/mymodel/infer",
+		"GET /v2/models/mymodel/config",
+		"POST /v2/repository/index",
+		"POST /v2/repository/models/mymodel/load",
+	}))
+
+	for _, path := range []string{"/v2/models/stats", "/v2/models/mymodel/stats"} {
+		req, _ = http.NewRequest("GET", path, nil)
+		res = httptest.NewRecorder()
+		r.Router.ServeHTTP(res, req)
+		g.Expect(res.Code).To(Equal(200))
+		var stats struct {
+			ModelStats []predictor.ModelStatistics `json:"model_stats"`
+		}
+		g.Expect(json.Unmarshal(res.Body.Bytes(), &stats)).To(BeNil())
+		g.Expect(stats.ModelStats).To(HaveLen(1))
+		g.Expect(stats.ModelStats[0].Name).To(Equal("mymodel"))
+		g.Expect(stats.ModelStats[0].InferenceCount).To(BeNumerically(">=", 1))
+		g.Expect(stats.ModelStats[0].InferenceStats.Success.Ns).To(BeNumerically(">", 0))
+	}
+
+	req, _ = http.NewRequest("GET", "/v2/models/other/stats", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(404))
+}
+
+func TestKFServingServerEndpoints(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	url, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	urlParts := strings.Split(url.Host, ":")
+	port, err := strconv.Atoi(urlParts[1])
+	g.Expect(err).Should(BeNil())
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name:        "p",
+		Annotations: map[string]string{"version": "v1"},
+		Graph: v1.PredictiveUnit{
+			Name: "mymodel",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: urlParts[0],
+				ServicePort: int32(port),
+				Type:        v1.REST,
+				HttpPort:    int32(port),
+			},
+		},
+	}
+
+	client, err := NewJSONRestClient(api.ProtocolKFServing, "dep", &p, nil)
+	g.Expect(err).To(BeNil())
+	r := NewServerRestApi(&p, client, false, url, "default", api.ProtocolKFServing, "test", "/metrics")
+	r.Initialise()
+
+	req, _ := http.NewRequest("GET", "/v2/health/live", nil)
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+
+	req, _ = http.NewRequest("GET", "/v2/health/ready", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+
+	req, _ = http.NewRequest("GET", "/v2", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+	var metadata predictor.ServerMetadata
+	err = json.Unmarshal(res.Body.Bytes(), &metadata)
+	g.Expect(err).To(BeNil())
+	g.Expect(metadata.Name).To(Equal("p"))
+	g.Expect(metadata.Version).To(Equal("v1"))
+	g.Expect(metadata.Extensions).To(Equal(predictor.ServerExtensions))
+
+	server.Close()
+	req, _ = http.NewRequest("GET", "/v2/health/ready", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+}
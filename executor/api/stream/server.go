@@ -0,0 +1,290 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	proto2 "github.com/golang/protobuf/proto"
+	guuid "github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon"
+	"github.com/seldonio/seldon-core/executor/api/grpc/tensorflow"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/rest"
+	"github.com/seldonio/seldon-core/executor/predictor"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	ENV_STREAM_URL     = "STREAM_URL"
+	ENV_STREAM_INPUT   = "STREAM_INPUT"
+	ENV_STREAM_OUTPUT  = "STREAM_OUTPUT"
+	ENV_STREAM_DLQ     = "STREAM_DLQ"
+	ENV_STREAM_WORKERS = "STREAM_WORKERS"
+)
+
+const (
+	KeyProtoName  = "proto-name"
+	KeyError      = "seldon-error"
+	KeyErrorStage = "seldon-error-stage"
+
+	ErrorStageUnmarshal = "unmarshal"
+	ErrorStagePredict   = "predict"
+
+	contentTypeHeader = "Content-Type"
+)
+
+const publishTimeout = 30 * time.Second
+
+// SeldonStreamServer consumes requests from a streaming system, sends them through the graph and publishes the
+// responses. Requests are acknowledged once their response, or dead letter, has been published, so each is
+// processed at least once.
+type SeldonStreamServer struct {
+	Client         client.SeldonApiClient
+	Stream         Transport
+	DeploymentName string
+	Namespace      string
+	Transport      string
+	Predictor      *v1.PredictorSpec
+	ServerUrl      *url.URL
+	Output         string
+	DLQ            string
+	Workers        int
+	Log            logr.Logger
+}
+
+func NewStreamServer(workers int, deploymentName, namespace, protocol, transport string, annotations map[string]string, serverUrl *url.URL, predictor *v1.PredictorSpec, streamUrl, input, output, dlq string, log logr.Logger) (*SeldonStreamServer, error) {
+	var apiClient client.SeldonApiClient
+	var err error
+	switch transport {
+	case api.TransportRest:
+		apiClient, err = rest.NewJSONRestClient(protocol, deploymentName, predictor, annotations)
+		if err != nil {
+			return nil, err
+		}
+	case api.TransportGrpc:
+		if protocol == "seldon" {
+			apiClient = seldon.NewSeldonGrpcClient(predictor, deploymentName, annotations)
+		} else {
+			apiClient = tensorflow.NewTensorflowGrpcClient(predictor, deploymentName, annotations)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown transport %s", transport)
+	}
+	if workers < 1 {
+		return nil, fmt.Errorf("Invalid number of stream workers %d", workers)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := NewTransport(streamUrl, TransportConfig{
+		Log:      log.WithName("StreamTransport"),
+		Input:    input,
+		Group:    predictor.Name + "." + deploymentName + "." + namespace,
+		Consumer: hostname,
+		Prefetch: workers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SeldonStreamServer{
+		Client:         apiClient,
+		Stream:         stream,
+		DeploymentName: deploymentName,
+		Namespace:      namespace,
+		Transport:      transport,
+		Predictor:      predictor,
+		ServerUrl:      serverUrl,
+		Output:         output,
+		DLQ:            dlq,
+		Workers:        workers,
+		Log:            log.WithName("StreamServer"),
+	}, nil
+}
+
+// Serve consumes requests until SIGINT or SIGTERM is received, then stops consuming and waits for the requests in
+// flight to be processed.
+func (s *SeldonStreamServer) Serve() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigchan:
+			s.Log.Info("Terminating", "signal", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	//wait for graph to be ready
+	for ctx.Err() == nil {
+		if err := predictor.ReadyPredictor(s.Predictor); err == nil {
+			break
+		}
+		s.Log.Info("Waiting for graph to be ready")
+		time.Sleep(2 * time.Second)
+	}
+
+	err := s.serve(ctx)
+	if closeErr := s.Stream.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s *SeldonStreamServer) serve(ctx context.Context) error {
+	jobChan := make(chan *Message, s.Workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < s.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range jobChan {
+				s.process(msg)
+			}
+		}()
+	}
+	err := s.Stream.Consume(ctx, func(msg *Message) {
+		jobChan <- msg
+	})
+	close(jobChan)
+	wg.Wait()
+	return err
+}
+
+func (s *SeldonStreamServer) process(msg *Message) {
+	headers := make(map[string][]string, len(msg.Headers)+1)
+	for key, values := range msg.Headers {
+		headers[key] = values
+	}
+	if len(headers[payload.SeldonPUIDHeader]) == 0 {
+		headers[payload.SeldonPUIDHeader] = []string{guuid.New().String()}
+	}
+
+	reqPayload, err := s.unmarshal(msg.Data, headers)
+	if err != nil {
+		s.deadLetter(msg, ErrorStageUnmarshal, err)
+		return
+	}
+	resPayload, err := s.predict(headers, reqPayload)
+	if err != nil {
+		s.predictFailed(msg, err)
+		return
+	}
+	resBytes, err := resPayload.GetBytes()
+	if err != nil {
+		s.deadLetter(msg, ErrorStagePredict, err)
+		return
+	}
+	s.publish(msg, s.Output, &Message{
+		Data: resBytes,
+		Headers: map[string][]string{
+			payload.SeldonPUIDHeader: headers[payload.SeldonPUIDHeader],
+			contentTypeHeader:        {resPayload.GetContentType()},
+		},
+	})
+}
+
+func (s *SeldonStreamServer) unmarshal(data []byte, headers map[string][]string) (payload.SeldonPayload, error) {
+	switch s.Transport {
+	case api.TransportGrpc:
+		val, ok := headers[KeyProtoName]
+		if !ok || len(val) != 1 {
+			return nil, fmt.Errorf("failed to find %s in headers", KeyProtoName)
+		}
+		pbtype := proto2.MessageType(val[0])
+		if pbtype == nil {
+			return nil, fmt.Errorf("unknown proto %s", val[0])
+		}
+		msg := reflect.New(pbtype.Elem()).Interface().(proto2.Message)
+		if err := proto2.Unmarshal(data, msg); err != nil {
+			return nil, fmt.Errorf("failed to get proto %s from bytes: %w", val[0], err)
+		}
+		return &payload.ProtoPayload{Msg: msg}, nil
+	default:
+		contentType := rest.ContentTypeJSON
+		if ct := headers[contentTypeHeader]; len(ct) == 1 {
+			contentType = ct[0]
+		}
+		return s.Client.Unmarshall(data, contentType)
+	}
+}
+
+func (s *SeldonStreamServer) predict(headers map[string][]string, reqPayload payload.SeldonPayload) (payload.SeldonPayload, error) {
+	ctx := context.WithValue(context.Background(), payload.SeldonPUIDHeader, headers[payload.SeldonPUIDHeader][0])
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		tracer := opentracing.GlobalTracer()
+		serverSpan := tracer.StartSpan("streamServer", ext.RPCServerOption(nil))
+		ctx = opentracing.ContextWithSpan(ctx, serverSpan)
+		defer serverSpan.Finish()
+	}
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, s.Client, logf.Log.WithName("StreamClient"), s.ServerUrl, s.Namespace, headers, "")
+	return seldonPredictorProcess.PredictGraph(s.Predictor, reqPayload)
+}
+
+// predictFailed handles a request whose prediction failed. Requests the graph rejected would fail however often they
+// were processed, so are dead lettered. Other failures may not happen again, so the request is redelivered.
+func (s *SeldonStreamServer) predictFailed(msg *Message, err error) {
+	if client.IsRejected(err) {
+		s.deadLetter(msg, ErrorStagePredict, err)
+		return
+	}
+	s.Log.Error(err, "Failed to predict message, asking for it to be redelivered")
+	if err := msg.Nack(); err != nil {
+		s.Log.Error(err, "Failed to reject message")
+	}
+}
+
+// deadLetter publishes a request that failed to the dead letter destination, unchanged but with headers describing
+// the failure. Without a dead letter destination the request is dropped.
+func (s *SeldonStreamServer) deadLetter(msg *Message, stage string, err error) {
+	s.Log.Error(err, "Failed to process message", "stage", stage)
+	if s.DLQ == "" {
+		if err := msg.Ack(); err != nil {
+			s.Log.Error(err, "Failed to acknowledge message")
+		}
+		return
+	}
+	headers := make(map[string][]string, len(msg.Headers)+2)
+	for key, values := range msg.Headers {
+		headers[key] = values
+	}
+	headers[KeyError] = []string{err.Error()}
+	headers[KeyErrorStage] = []string{stage}
+	s.publish(msg, s.DLQ, &Message{Data: msg.Data, Headers: headers})
+}
+
+// publish publishes the result of a request, acknowledging the request once it has been stored or asking for it to
+// be redelivered if publishing fails.
+func (s *SeldonStreamServer) publish(source *Message, destination string, msg *Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := s.Stream.Publish(ctx, destination, msg); err != nil {
+		s.Log.Error(err, "Failed to publish message", "destination", destination)
+		if err := source.Nack(); err != nil {
+			s.Log.Error(err, "Failed to reject message")
+		}
+		return
+	}
+	if err := source.Ack(); err != nil {
+		s.Log.Error(err, "Failed to acknowledge message")
+	}
+}
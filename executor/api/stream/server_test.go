@@ -0,0 +1,230 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type published struct {
+	destination string
+	msg         *Message
+}
+
+// testTransport delivers its messages once and records what is published.
+type testTransport struct {
+	mu         sync.Mutex
+	messages   []*Message
+	published  []published
+	publishErr error
+	closed     bool
+}
+
+func (t *testTransport) Consume(ctx context.Context, handle func(msg *Message)) error {
+	for _, msg := range t.messages {
+		handle(msg)
+	}
+	return nil
+}
+
+func (t *testTransport) Publish(ctx context.Context, destination string, msg *Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.publishErr != nil {
+		return t.publishErr
+	}
+	t.published = append(t.published, published{destination: destination, msg: msg})
+	return nil
+}
+
+func (t *testTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
+type ackRecorder struct {
+	mu    sync.Mutex
+	acks  []string
+	nacks []string
+}
+
+func (r *ackRecorder) message(id string, data string, headers map[string][]string) *Message {
+	return &Message{
+		Data:    []byte(data),
+		Headers: headers,
+		Ack: func() error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.acks = append(r.acks, id)
+			return nil
+		},
+		Nack: func() error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.nacks = append(r.nacks, id)
+			return nil
+		},
+	}
+}
+
+func createTestStreamServer(transport *testTransport) *SeldonStreamServer {
+	modelType := v1.MODEL
+	serverUrl, _ := url.Parse("http://localhost")
+	return &SeldonStreamServer{
+		Client:         test.SeldonMessageTestClient{},
+		Stream:         transport,
+		DeploymentName: "dep",
+		Namespace:      "default",
+		Transport:      api.TransportRest,
+		Predictor: &v1.PredictorSpec{
+			Name: "p",
+			Graph: v1.PredictiveUnit{
+				Name:     "model",
+				Type:     &modelType,
+				Endpoint: &v1.Endpoint{ServiceHost: "foo", ServicePort: 9000, Type: v1.REST},
+			},
+		},
+		ServerUrl: serverUrl,
+		Output:    "out",
+		DLQ:       "dlq",
+		Workers:   2,
+		Log:       logf.Log.WithName("StreamServerTest"),
+	}
+}
+
+func TestStreamServerProcess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	acks := &ackRecorder{}
+	transport := &testTransport{messages: []*Message{
+		acks.message("1", `{"data":{"ndarray":[[1.0]]}}`, map[string][]string{payload.SeldonPUIDHeader: {"puid-1"}}),
+		acks.message("2", `{"data":{"ndarray":[[2.0]]}}`, nil),
+	}}
+	s := createTestStreamServer(transport)
+
+	g.Expect(s.serve(context.Background())).To(BeNil())
+	g.Expect(acks.acks).To(ConsistOf("1", "2"))
+	g.Expect(acks.nacks).To(BeEmpty())
+	g.Expect(transport.published).To(HaveLen(2))
+	for _, p := range transport.published {
+		g.Expect(p.destination).To(Equal("out"))
+		g.Expect(p.msg.Headers[payload.SeldonPUIDHeader]).To(HaveLen(1))
+		g.Expect(p.msg.Headers[contentTypeHeader]).To(Equal([]string{"application/json"}))
+		if string(p.msg.Data) == `{"data":{"ndarray":[[1.0]]}}` {
+			g.Expect(p.msg.Headers[payload.SeldonPUIDHeader]).To(Equal([]string{"puid-1"}))
+		}
+	}
+}
+
+func TestStreamServerDeadLetter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	acks := &ackRecorder{}
+	transport := &testTransport{messages: []*Message{
+		acks.message("1", `bad`, map[string][]string{"custom": {"a"}}),
+	}}
+	s := createTestStreamServer(transport)
+	// gRPC requests need a proto name
+	s.Transport = api.TransportGrpc
+
+	g.Expect(s.serve(context.Background())).To(BeNil())
+	g.Expect(acks.acks).To(Equal([]string{"1"}))
+	g.Expect(transport.published).To(HaveLen(1))
+	p := transport.published[0]
+	g.Expect(p.destination).To(Equal("dlq"))
+	g.Expect(string(p.msg.Data)).To(Equal("bad"))
+	g.Expect(p.msg.Headers["custom"]).To(Equal([]string{"a"}))
+	g.Expect(p.msg.Headers[KeyErrorStage]).To(Equal([]string{ErrorStageUnmarshal}))
+	g.Expect(p.msg.Headers[KeyError]).To(HaveLen(1))
+
+	// Without a dead letter destination failed requests are dropped
+	acks = &ackRecorder{}
+	transport = &testTransport{messages: []*Message{acks.message("2", `bad`, nil)}}
+	s = createTestStreamServer(transport)
+	s.Transport = api.TransportGrpc
+	s.DLQ = ""
+	g.Expect(s.serve(context.Background())).To(BeNil())
+	g.Expect(acks.acks).To(Equal([]string{"2"}))
+	g.Expect(transport.published).To(BeEmpty())
+}
+
+func TestStreamServerPredictFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// The test client fails predictions when asked to fail input transforms
+	errMethod := v1.TRANSFORM_INPUT
+	for _, tt := range []struct {
+		name      string
+		err       error
+		dlq       string
+		acks      []string
+		nacks     []string
+		published int
+	}{
+		{name: "transient", err: errors.New("connection refused"), dlq: "dlq", nacks: []string{"1"}},
+		{name: "transient without dlq", err: errors.New("connection refused"), nacks: []string{"1"}},
+		{name: "rejected", err: status.Error(codes.InvalidArgument, "bad input"), dlq: "dlq", acks: []string{"1"}, published: 1},
+		{name: "rejected without dlq", err: status.Error(codes.InvalidArgument, "bad input"), acks: []string{"1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			acks := &ackRecorder{}
+			transport := &testTransport{messages: []*Message{acks.message("1", `{"data":{"ndarray":[[1.0]]}}`, nil)}}
+			s := createTestStreamServer(transport)
+			s.Client = test.SeldonMessageTestClient{ErrMethod: &errMethod, Err: tt.err}
+			s.DLQ = tt.dlq
+
+			g.Expect(s.serve(context.Background())).To(BeNil())
+			g.Expect(acks.acks).To(Equal(tt.acks))
+			g.Expect(acks.nacks).To(Equal(tt.nacks))
+			g.Expect(transport.published).To(HaveLen(tt.published))
+			if tt.published > 0 {
+				g.Expect(transport.published[0].destination).To(Equal("dlq"))
+				g.Expect(transport.published[0].msg.Headers[KeyErrorStage]).To(Equal([]string{ErrorStagePredict}))
+			}
+		})
+	}
+}
+
+func TestStreamServerPublishFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+	acks := &ackRecorder{}
+	transport := &testTransport{
+		messages:   []*Message{acks.message("1", `{"data":{"ndarray":[[1.0]]}}`, nil)},
+		publishErr: errors.New("unavailable"),
+	}
+	s := createTestStreamServer(transport)
+
+	g.Expect(s.serve(context.Background())).To(BeNil())
+	g.Expect(acks.acks).To(BeEmpty())
+	g.Expect(acks.nacks).To(Equal([]string{"1"}))
+}
+
+func TestNewTransport(t *testing.T) {
+	g := NewGomegaWithT(t)
+	config := TransportConfig{Log: logf.Log.WithName("StreamTest"), Input: "in", Group: "g", Consumer: "c", Prefetch: 1}
+
+	_, err := NewTransport("kafka://broker:9092", config)
+	g.Expect(err).ToNot(BeNil())
+
+	transport := &testTransport{}
+	RegisterTransport("test", func(u *url.URL, config TransportConfig) (Transport, error) {
+		return transport, nil
+	})
+	created, err := NewTransport("test://server", config)
+	g.Expect(err).To(BeNil())
+	g.Expect(created).To(BeIdenticalTo(transport))
+
+	// Redis clients connect lazily
+	created, err = NewTransport("redis://localhost:6379/0", config)
+	g.Expect(err).To(BeNil())
+	g.Expect(created).To(BeAssignableToTypeOf(&redisTransport{}))
+	g.Expect(created.Close()).To(BeNil())
+}
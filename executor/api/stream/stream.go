@@ -0,0 +1,77 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// Message is a request consumed from a stream, or a response to publish.
+type Message struct {
+	Data    []byte
+	Headers map[string][]string
+	// Ack acknowledges a consumed message once it has been processed, and Nack asks for it to be redelivered.
+	Ack  func() error
+	Nack func() error
+}
+
+// Transport consumes requests from and publishes responses to a streaming system.
+type Transport interface {
+	// Consume passes messages from the input to handle until the context is cancelled or consuming fails. Each
+	// message is acknowledged by the server once its response has been published.
+	Consume(ctx context.Context, handle func(msg *Message)) error
+	// Publish sends a message to a destination, returning once the streaming system has stored it.
+	Publish(ctx context.Context, destination string, msg *Message) error
+	Close() error
+}
+
+// TransportConfig describes where a transport consumes requests from.
+type TransportConfig struct {
+	Log   logr.Logger
+	Input string
+	// Group is shared by the replicas of a predictor, which each consume a share of the input
+	Group string
+	// Consumer is unique to this replica
+	Consumer string
+	// Prefetch is the most messages to have in flight
+	Prefetch int
+}
+
+// TransportFactory creates the transport for a stream URL.
+type TransportFactory func(u *url.URL, config TransportConfig) (Transport, error)
+
+var (
+	transportFactoriesMu sync.RWMutex
+	transportFactories   = map[string]TransportFactory{
+		"nats":   NewNatsTransport,
+		"redis":  NewRedisTransport,
+		"rediss": NewRedisTransport,
+		"amqp":   NewAMQPTransport,
+		"amqps":  NewAMQPTransport,
+	}
+)
+
+// RegisterTransport sets the factory creating transports for stream URLs with the scheme.
+func RegisterTransport(scheme string, factory TransportFactory) {
+	transportFactoriesMu.Lock()
+	defer transportFactoriesMu.Unlock()
+	transportFactories[scheme] = factory
+}
+
+// NewTransport creates the transport for a stream URL, chosen by its scheme.
+func NewTransport(streamUrl string, config TransportConfig) (Transport, error) {
+	u, err := url.Parse(streamUrl)
+	if err != nil {
+		return nil, err
+	}
+	transportFactoriesMu.RLock()
+	factory, ok := transportFactories[u.Scheme]
+	transportFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no streaming transport for URL scheme %q", u.Scheme)
+	}
+	return factory(u, config)
+}
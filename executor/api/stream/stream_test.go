@@ -0,0 +1,54 @@
+package stream
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/streadway/amqp"
+)
+
+func TestAMQPHeaders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	headers := map[string][]string{
+		contentTypeHeader: {"application/json"},
+		"puid":            {"abc"},
+		"multi":           {"a", "b"},
+	}
+	table, contentType := toAMQPTable(headers)
+	g.Expect(contentType).To(Equal("application/json"))
+	g.Expect(table).To(Equal(amqp.Table{"puid": "abc", "multi": []interface{}{"a", "b"}}))
+	g.Expect(fromAMQPTable(table, contentType)).To(Equal(headers))
+
+	g.Expect(fromAMQPTable(amqp.Table{"retries": int32(2), "raw": []byte("x")}, "")).To(Equal(map[string][]string{
+		"retries": {"2"},
+		"raw":     {"x"},
+	}))
+}
+
+func TestDecodeRedisValues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	data, headers, err := decodeRedisValues(map[string]interface{}{
+		RedisDataField:    `{"data":{"ndarray":[1]}}`,
+		RedisHeadersField: `{"Content-Type":["application/json"]}`,
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(string(data)).To(Equal(`{"data":{"ndarray":[1]}}`))
+	g.Expect(headers).To(Equal(map[string][]string{contentTypeHeader: {"application/json"}}))
+
+	data, headers, err = decodeRedisValues(map[string]interface{}{RedisDataField: "x"})
+	g.Expect(err).To(BeNil())
+	g.Expect(string(data)).To(Equal("x"))
+	g.Expect(headers).To(BeEmpty())
+
+	_, _, err = decodeRedisValues(map[string]interface{}{})
+	g.Expect(err).ToNot(BeNil())
+	_, _, err = decodeRedisValues(map[string]interface{}{RedisDataField: "x", RedisHeadersField: "{"})
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestNatsDurableName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(natsDurableName("p.dep.default")).To(Equal("p-dep-default"))
+}
@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
+	otbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ENV_OTEL_TRACES_EXPORTER               = "OTEL_TRACES_EXPORTER"
+	ENV_OTEL_EXPORTER_OTLP_PROTOCOL        = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	ENV_OTEL_EXPORTER_OTLP_TRACES_PROTOCOL = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+	ENV_OTEL_TRACES_SAMPLER                = "OTEL_TRACES_SAMPLER"
+	ENV_OTEL_TRACES_SAMPLER_ARG            = "OTEL_TRACES_SAMPLER_ARG"
+
+	ExporterOTLP = "otlp"
+
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+
+	// TracerName names the tracer of the executor's OpenTelemetry spans.
+	TracerName = "github.com/seldonio/seldon-core/executor"
+
+	defaultServiceName = "executor"
+	shutdownTimeout    = 10 * time.Second
+)
+
+var openTelemetry bool
+
+// OpenTelemetryEnabled returns whether spans are recorded with the OpenTelemetry SDK, in which case servers and
+// clients propagate W3C trace context and baggage.
+func OpenTelemetryEnabled() bool {
+	return openTelemetry
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// initOpenTelemetry sets up the OpenTelemetry SDK to export spans over OTLP, configured by the standard OTEL_*
+// environment variables. Spans still started through the OpenTracing API are bridged to the SDK, so they share
+// traces with the OpenTelemetry spans.
+func initOpenTelemetry() (io.Closer, error) {
+	ctx := context.Background()
+	exporter, err := newOTLPExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sampler, err := samplerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler))
+
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	bridgeTracer, wrapperProvider := otbridge.NewTracerPair(tp.Tracer(TracerName))
+	bridgeTracer.SetTextMapPropagator(propagator)
+	otel.SetTracerProvider(wrapperProvider)
+	otel.SetTextMapPropagator(propagator)
+	opentracing.SetGlobalTracer(bridgeTracer)
+	openTelemetry = true
+
+	return closerFunc(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return tp.Shutdown(ctx)
+	}), nil
+}
+
+// newResource describes the executor in exported spans and metrics. The service name from OTEL_SERVICE_NAME or
+// OTEL_RESOURCE_ATTRIBUTES overrides the default.
+func newResource(ctx context.Context) (*resource.Resource, error) {
+	return resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String(defaultServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+}
+
+// otlpProtocol returns the protocol spans are exported with, preferring the traces specific setting.
+func otlpProtocol() string {
+	if protocol := os.Getenv(ENV_OTEL_EXPORTER_OTLP_TRACES_PROTOCOL); protocol != "" {
+		return protocol
+	}
+	if protocol := os.Getenv(ENV_OTEL_EXPORTER_OTLP_PROTOCOL); protocol != "" {
+		return protocol
+	}
+	return ProtocolGRPC
+}
+
+func newOTLPExporter(ctx context.Context) (*otlptrace.Exporter, error) {
+	switch protocol := otlpProtocol(); protocol {
+	case ProtocolGRPC:
+		return otlptracegrpc.New(ctx)
+	case ProtocolHTTP:
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %s", protocol)
+	}
+}
+
+// samplerFromEnv returns the sampler named by OTEL_TRACES_SAMPLER, by default sampling the traces whose parent is
+// sampled and all new traces.
+func samplerFromEnv() (sdktrace.Sampler, error) {
+	ratio := 1.0
+	if arg := os.Getenv(ENV_OTEL_TRACES_SAMPLER_ARG); arg != "" {
+		var err error
+		ratio, err = strconv.ParseFloat(arg, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid %s %s, must be a ratio between 0 and 1", ENV_OTEL_TRACES_SAMPLER_ARG, arg)
+		}
+	}
+	switch sampler := os.Getenv(ENV_OTEL_TRACES_SAMPLER); sampler {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("unsupported %s %s", ENV_OTEL_TRACES_SAMPLER, sampler)
+	}
+}
+
+// StartHTTPServerSpan starts the span of a request served over HTTP, continuing the trace and baggage propagated in
+// its headers.
+func StartHTTPServerSpan(ctx context.Context, spanName string, header http.Header) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+	return otel.Tracer(TracerName).Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// StartHTTPClientSpan starts the span of a request sent over HTTP, propagating the trace and baggage in its headers.
+func StartHTTPClientSpan(ctx context.Context, spanName string, header http.Header) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(TracerName).Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+	return ctx, span
+}
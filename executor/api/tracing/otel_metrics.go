@@ -0,0 +1,413 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	ENV_OTEL_METRICS_EXPORTER               = "OTEL_METRICS_EXPORTER"
+	ENV_OTEL_EXPORTER_OTLP_METRICS_PROTOCOL = "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL"
+	ENV_OTEL_EXPORTER_OTLP_ENDPOINT         = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	ENV_OTEL_EXPORTER_OTLP_METRICS_ENDPOINT = "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"
+	ENV_OTEL_EXPORTER_OTLP_HEADERS          = "OTEL_EXPORTER_OTLP_HEADERS"
+	ENV_OTEL_EXPORTER_OTLP_METRICS_HEADERS  = "OTEL_EXPORTER_OTLP_METRICS_HEADERS"
+	ENV_OTEL_EXPORTER_OTLP_INSECURE         = "OTEL_EXPORTER_OTLP_INSECURE"
+	ENV_OTEL_EXPORTER_OTLP_METRICS_INSECURE = "OTEL_EXPORTER_OTLP_METRICS_INSECURE"
+	ENV_OTEL_METRIC_EXPORT_INTERVAL         = "OTEL_METRIC_EXPORT_INTERVAL"
+	ENV_OTEL_METRIC_EXPORT_TIMEOUT          = "OTEL_METRIC_EXPORT_TIMEOUT"
+
+	defaultGRPCEndpoint         = "localhost:4317"
+	defaultHTTPEndpoint         = "http://localhost:4318"
+	otlpMetricsPath             = "/v1/metrics"
+	defaultMetricExportInterval = 60 * time.Second
+	defaultMetricExportTimeout  = 30 * time.Second
+)
+
+var metricExportLog = logf.Log.WithName("MetricExport")
+
+// InitMetricExport periodically exports the metrics registered with Prometheus over OTLP when
+// OTEL_METRICS_EXPORTER is otlp, configured by the standard OTEL_* environment variables. The metrics are still
+// served to Prometheus as before.
+func InitMetricExport() (io.Closer, error) {
+	if os.Getenv(ENV_OTEL_METRICS_EXPORTER) != ExporterOTLP {
+		return closerFunc(func() error { return nil }), nil
+	}
+	res, err := newResource(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := newMetricExporter(prometheus.DefaultGatherer, res)
+	if err != nil {
+		return nil, err
+	}
+	exporter.start()
+	return exporter, nil
+}
+
+// metricExporter gathers Prometheus metrics and sends them to an OTLP endpoint.
+type metricExporter struct {
+	gatherer prometheus.Gatherer
+	resource *resourcepb.Resource
+	// startTime is the start of the cumulative counters, histograms and summaries
+	startTime time.Time
+	interval  time.Duration
+	timeout   time.Duration
+	send      func(ctx context.Context, req *collectormetricspb.ExportMetricsServiceRequest) error
+	close     func() error
+	quit      chan struct{}
+	done      chan struct{}
+}
+
+func newMetricExporter(gatherer prometheus.Gatherer, res *resource.Resource) (*metricExporter, error) {
+	interval, err := durationMsFromEnv(ENV_OTEL_METRIC_EXPORT_INTERVAL, defaultMetricExportInterval)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := durationMsFromEnv(ENV_OTEL_METRIC_EXPORT_TIMEOUT, defaultMetricExportTimeout)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := parseOTLPHeaders(envWithFallback(ENV_OTEL_EXPORTER_OTLP_METRICS_HEADERS, ENV_OTEL_EXPORTER_OTLP_HEADERS))
+	if err != nil {
+		return nil, err
+	}
+	e := &metricExporter{
+		gatherer:  gatherer,
+		resource:  resourceToProto(res),
+		startTime: time.Now(),
+		interval:  interval,
+		timeout:   timeout,
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	switch protocol := envWithFallback(ENV_OTEL_EXPORTER_OTLP_METRICS_PROTOCOL, ENV_OTEL_EXPORTER_OTLP_PROTOCOL); protocol {
+	case "", ProtocolGRPC:
+		err = e.setupGRPC(headers)
+	case ProtocolHTTP:
+		err = e.setupHTTP(headers)
+	default:
+		err = fmt.Errorf("unsupported OTLP protocol %s", protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// setupGRPC sends metrics to the endpoint's OTLP gRPC service. An endpoint with an http scheme, or any endpoint
+// when OTEL_EXPORTER_OTLP_INSECURE is true, is called without TLS.
+func (e *metricExporter) setupGRPC(headers map[string]string) error {
+	endpoint := os.Getenv(ENV_OTEL_EXPORTER_OTLP_METRICS_ENDPOINT)
+	if endpoint == "" {
+		endpoint = os.Getenv(ENV_OTEL_EXPORTER_OTLP_ENDPOINT)
+	}
+	if endpoint == "" {
+		endpoint = defaultGRPCEndpoint
+	}
+	insecure := strings.EqualFold(envWithFallback(ENV_OTEL_EXPORTER_OTLP_METRICS_INSECURE, ENV_OTEL_EXPORTER_OTLP_INSECURE), "true")
+	if u, err := url.Parse(endpoint); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		insecure = u.Scheme == "http"
+		endpoint = u.Host
+	}
+	creds := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+	if insecure {
+		creds = grpc.WithInsecure()
+	}
+	conn, err := grpc.Dial(endpoint, creds)
+	if err != nil {
+		return err
+	}
+	client := collectormetricspb.NewMetricsServiceClient(conn)
+	md := metadata.New(headers)
+	e.send = func(ctx context.Context, req *collectormetricspb.ExportMetricsServiceRequest) error {
+		_, err := client.Export(metadata.NewOutgoingContext(ctx, md), req)
+		return err
+	}
+	e.close = conn.Close
+	return nil
+}
+
+// setupHTTP posts metrics as protobuf to the metrics endpoint, or to /v1/metrics of the OTLP endpoint.
+func (e *metricExporter) setupHTTP(headers map[string]string) error {
+	endpoint := os.Getenv(ENV_OTEL_EXPORTER_OTLP_METRICS_ENDPOINT)
+	if endpoint == "" {
+		endpoint = os.Getenv(ENV_OTEL_EXPORTER_OTLP_ENDPOINT)
+		if endpoint == "" {
+			endpoint = defaultHTTPEndpoint
+		}
+		endpoint = strings.TrimSuffix(endpoint, "/") + otlpMetricsPath
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return fmt.Errorf("invalid OTLP metrics endpoint %s: %w", endpoint, err)
+	}
+	client := &http.Client{}
+	e.send = func(ctx context.Context, req *collectormetricspb.ExportMetricsServiceRequest) error {
+		body, err := proto.Marshal(req)
+		if err != nil {
+			return err
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Content-Type", "application/x-protobuf")
+		for key, value := range headers {
+			httpReq.Header.Set(key, value)
+		}
+		res, err := client.Do(httpReq)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("failed to export metrics to %s: %s", endpoint, res.Status)
+		}
+		return nil
+	}
+	e.close = func() error {
+		client.CloseIdleConnections()
+		return nil
+	}
+	return nil
+}
+
+func (e *metricExporter) start() {
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.export(); err != nil {
+					metricExportLog.Error(err, "Failed to export metrics")
+				}
+			case <-e.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the periodic export and exports the metrics a last time.
+func (e *metricExporter) Close() error {
+	close(e.quit)
+	<-e.done
+	err := e.export()
+	if closeErr := e.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// export gathers the metrics and sends them to the endpoint.
+func (e *metricExporter) export() error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	return e.send(ctx, e.request(families, time.Now()))
+}
+
+// request converts Prometheus metric families to an OTLP export request. Counters are sent as cumulative
+// monotonic sums, gauges and untyped metrics as gauges, and histograms and summaries as their OTLP equivalents.
+func (e *metricExporter) request(families []*dto.MetricFamily, now time.Time) *collectormetricspb.ExportMetricsServiceRequest {
+	start := uint64(e.startTime.UnixNano())
+	ts := uint64(now.UnixNano())
+	metrics := make([]*metricspb.Metric, 0, len(families))
+	for _, mf := range families {
+		metric := &metricspb.Metric{Name: mf.GetName(), Description: mf.GetHelp()}
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			sum := &metricspb.Sum{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				IsMonotonic:            true,
+			}
+			for _, m := range mf.GetMetric() {
+				sum.DataPoints = append(sum.DataPoints, numberDataPoint(m, m.GetCounter().GetValue(), start, ts))
+			}
+			metric.Data = &metricspb.Metric_Sum{Sum: sum}
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			gauge := &metricspb.Gauge{}
+			for _, m := range mf.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if mf.GetType() == dto.MetricType_UNTYPED {
+					value = m.GetUntyped().GetValue()
+				}
+				gauge.DataPoints = append(gauge.DataPoints, numberDataPoint(m, value, 0, ts))
+			}
+			metric.Data = &metricspb.Metric_Gauge{Gauge: gauge}
+		case dto.MetricType_HISTOGRAM:
+			histogram := &metricspb.Histogram{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			}
+			for _, m := range mf.GetMetric() {
+				histogram.DataPoints = append(histogram.DataPoints, histogramDataPoint(m, start, ts))
+			}
+			metric.Data = &metricspb.Metric_Histogram{Histogram: histogram}
+		case dto.MetricType_SUMMARY:
+			summary := &metricspb.Summary{}
+			for _, m := range mf.GetMetric() {
+				dp := &metricspb.SummaryDataPoint{
+					Attributes:        labelAttributes(m),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      ts,
+					Count:             m.GetSummary().GetSampleCount(),
+					Sum:               m.GetSummary().GetSampleSum(),
+				}
+				for _, q := range m.GetSummary().GetQuantile() {
+					dp.QuantileValues = append(dp.QuantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{
+						Quantile: q.GetQuantile(),
+						Value:    q.GetValue(),
+					})
+				}
+				summary.DataPoints = append(summary.DataPoints, dp)
+			}
+			metric.Data = &metricspb.Metric_Summary{Summary: summary}
+		default:
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	return &collectormetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: e.resource,
+			InstrumentationLibraryMetrics: []*metricspb.InstrumentationLibraryMetrics{{
+				InstrumentationLibrary: &commonpb.InstrumentationLibrary{Name: TracerName},
+				Metrics:                metrics,
+			}},
+		}},
+	}
+}
+
+func numberDataPoint(m *dto.Metric, value float64, start uint64, ts uint64) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:        labelAttributes(m),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+// histogramDataPoint converts a Prometheus histogram, whose buckets count every observation up to their bound, to
+// OTLP buckets counting the observations since the previous bound. The last OTLP bucket is above every bound.
+func histogramDataPoint(m *dto.Metric, start uint64, ts uint64) *metricspb.HistogramDataPoint {
+	h := m.GetHistogram()
+	dp := &metricspb.HistogramDataPoint{
+		Attributes:        labelAttributes(m),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      ts,
+		Count:             h.GetSampleCount(),
+		Sum:               h.GetSampleSum(),
+	}
+	var previous uint64
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+		dp.ExplicitBounds = append(dp.ExplicitBounds, b.GetUpperBound())
+		dp.BucketCounts = append(dp.BucketCounts, b.GetCumulativeCount()-previous)
+		previous = b.GetCumulativeCount()
+	}
+	dp.BucketCounts = append(dp.BucketCounts, h.GetSampleCount()-previous)
+	return dp
+}
+
+func labelAttributes(m *dto.Metric) []*commonpb.KeyValue {
+	attributes := make([]*commonpb.KeyValue, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		attributes = append(attributes, &commonpb.KeyValue{
+			Key:   l.GetName(),
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: l.GetValue()}},
+		})
+	}
+	return attributes
+}
+
+func resourceToProto(res *resource.Resource) *resourcepb.Resource {
+	r := &resourcepb.Resource{}
+	for _, kv := range res.Attributes() {
+		value := &commonpb.AnyValue{}
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			value.Value = &commonpb.AnyValue_BoolValue{BoolValue: kv.Value.AsBool()}
+		case attribute.INT64:
+			value.Value = &commonpb.AnyValue_IntValue{IntValue: kv.Value.AsInt64()}
+		case attribute.FLOAT64:
+			value.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: kv.Value.AsFloat64()}
+		default:
+			value.Value = &commonpb.AnyValue_StringValue{StringValue: kv.Value.Emit()}
+		}
+		r.Attributes = append(r.Attributes, &commonpb.KeyValue{Key: string(kv.Key), Value: value})
+	}
+	return r
+}
+
+// parseOTLPHeaders parses headers given as comma separated key=value pairs with URL encoded values.
+func parseOTLPHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid OTLP header %s", pair)
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP header %s: %w", pair, err)
+		}
+		headers[strings.TrimSpace(kv[0])] = v
+	}
+	return headers, nil
+}
+
+// envWithFallback returns the value of the signal specific variable if set, otherwise the general one.
+func envWithFallback(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return os.Getenv(fallback)
+}
+
+func durationMsFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	ms, err := strconv.Atoi(value)
+	if err != nil || ms <= 0 {
+		return 0, fmt.Errorf("invalid %s %s, must be a positive number of milliseconds", key, value)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,200 @@
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func createTestRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total", Help: "requests"}, []string{"model"})
+	counter.WithLabelValues("a").Add(3)
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_queue_depth"})
+	gauge.Set(2)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_latency_seconds", Buckets: []float64{0.1, 1}})
+	for _, v := range []float64{0.05, 0.5, 0.7, 5} {
+		histogram.Observe(v)
+	}
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "test_size", Objectives: map[float64]float64{0.5: 0.05}})
+	summary.Observe(4)
+	registry.MustRegister(counter, gauge, histogram, summary)
+	return registry
+}
+
+func exportedMetrics(req *collectormetricspb.ExportMetricsServiceRequest) map[string]*metricspb.Metric {
+	metrics := map[string]*metricspb.Metric{}
+	for _, rm := range req.GetResourceMetrics() {
+		for _, ilm := range rm.GetInstrumentationLibraryMetrics() {
+			for _, m := range ilm.GetMetrics() {
+				metrics[m.GetName()] = m
+			}
+		}
+	}
+	return metrics
+}
+
+func TestMetricExportRequest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	res, err := newResource(context.Background())
+	g.Expect(err).To(BeNil())
+	start := time.Unix(100, 0)
+	e := &metricExporter{resource: resourceToProto(res), startTime: start}
+	families, err := createTestRegistry().Gather()
+	g.Expect(err).To(BeNil())
+	req := e.request(families, time.Unix(200, 0))
+
+	g.Expect(req.GetResourceMetrics()).To(HaveLen(1))
+	var serviceName string
+	for _, kv := range req.GetResourceMetrics()[0].GetResource().GetAttributes() {
+		if kv.GetKey() == "service.name" {
+			serviceName = kv.GetValue().GetStringValue()
+		}
+	}
+	g.Expect(serviceName).To(Equal(defaultServiceName))
+
+	metrics := exportedMetrics(req)
+	g.Expect(metrics).To(HaveLen(4))
+
+	sum := metrics["test_requests_total"].GetSum()
+	g.Expect(metrics["test_requests_total"].GetDescription()).To(Equal("requests"))
+	g.Expect(sum.GetIsMonotonic()).To(BeTrue())
+	g.Expect(sum.GetAggregationTemporality()).To(Equal(metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE))
+	g.Expect(sum.GetDataPoints()).To(HaveLen(1))
+	g.Expect(sum.GetDataPoints()[0].GetAsDouble()).To(Equal(3.0))
+	g.Expect(sum.GetDataPoints()[0].GetStartTimeUnixNano()).To(Equal(uint64(start.UnixNano())))
+	g.Expect(sum.GetDataPoints()[0].GetAttributes()[0].GetKey()).To(Equal("model"))
+	g.Expect(sum.GetDataPoints()[0].GetAttributes()[0].GetValue().GetStringValue()).To(Equal("a"))
+
+	g.Expect(metrics["test_queue_depth"].GetGauge().GetDataPoints()[0].GetAsDouble()).To(Equal(2.0))
+
+	// Prometheus buckets are cumulative while OTLP buckets count the observations since the previous bound
+	hdp := metrics["test_latency_seconds"].GetHistogram().GetDataPoints()[0]
+	g.Expect(hdp.GetExplicitBounds()).To(Equal([]float64{0.1, 1}))
+	g.Expect(hdp.GetBucketCounts()).To(Equal([]uint64{1, 2, 1}))
+	g.Expect(hdp.GetCount()).To(Equal(uint64(4)))
+	g.Expect(hdp.GetSum()).To(BeNumerically("~", 6.25))
+
+	sdp := metrics["test_size"].GetSummary().GetDataPoints()[0]
+	g.Expect(sdp.GetCount()).To(Equal(uint64(1)))
+	g.Expect(sdp.GetQuantileValues()[0].GetQuantile()).To(Equal(0.5))
+	g.Expect(sdp.GetQuantileValues()[0].GetValue()).To(Equal(4.0))
+}
+
+func TestMetricExportHTTP(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	received := make(chan *collectormetricspb.ExportMetricsServiceRequest, 1)
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal(otlpMetricsPath))
+		body, err := ioutil.ReadAll(r.Body)
+		g.Expect(err).To(BeNil())
+		req := &collectormetricspb.ExportMetricsServiceRequest{}
+		g.Expect(proto.Unmarshal(body, req)).To(BeNil())
+		headers <- r.Header
+		received <- req
+	}))
+	defer server.Close()
+
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_PROTOCOL, ProtocolHTTP)
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_ENDPOINT, server.URL)
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_HEADERS, "api-key=secret%20key")
+	res, err := newResource(context.Background())
+	g.Expect(err).To(BeNil())
+	e, err := newMetricExporter(createTestRegistry(), res)
+	g.Expect(err).To(BeNil())
+	e.start()
+	g.Expect(e.Close()).To(BeNil())
+
+	var h http.Header
+	g.Expect(headers).To(Receive(&h))
+	g.Expect(h.Get("Content-Type")).To(Equal("application/x-protobuf"))
+	g.Expect(h.Get("api-key")).To(Equal("secret key"))
+	var req *collectormetricspb.ExportMetricsServiceRequest
+	g.Expect(received).To(Receive(&req))
+	g.Expect(exportedMetrics(req)).To(HaveKey("test_requests_total"))
+}
+
+type testMetricsService struct {
+	collectormetricspb.UnimplementedMetricsServiceServer
+	received chan *collectormetricspb.ExportMetricsServiceRequest
+	md       chan metadata.MD
+}
+
+func (s *testMetricsService) Export(ctx context.Context, req *collectormetricspb.ExportMetricsServiceRequest) (*collectormetricspb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.md <- md
+	s.received <- req
+	return &collectormetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func TestMetricExportGRPC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(BeNil())
+	service := &testMetricsService{
+		received: make(chan *collectormetricspb.ExportMetricsServiceRequest, 1),
+		md:       make(chan metadata.MD, 1),
+	}
+	server := grpc.NewServer()
+	collectormetricspb.RegisterMetricsServiceServer(server, service)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_METRICS_ENDPOINT, "http://"+lis.Addr().String())
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_METRICS_HEADERS, "api-key=secret")
+	res, err := newResource(context.Background())
+	g.Expect(err).To(BeNil())
+	e, err := newMetricExporter(createTestRegistry(), res)
+	g.Expect(err).To(BeNil())
+	e.start()
+	g.Expect(e.Close()).To(BeNil())
+
+	var md metadata.MD
+	g.Expect(service.md).To(Receive(&md))
+	g.Expect(md.Get("api-key")).To(Equal([]string{"secret"}))
+	var req *collectormetricspb.ExportMetricsServiceRequest
+	g.Expect(service.received).To(Receive(&req))
+	g.Expect(exportedMetrics(req)).To(HaveKey("test_latency_seconds"))
+}
+
+func TestMetricExportConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Metrics are only exported when asked for
+	setEnv(t, ENV_OTEL_METRICS_EXPORTER, "")
+	closer, err := InitMetricExport()
+	g.Expect(err).To(BeNil())
+	g.Expect(closer.Close()).To(BeNil())
+
+	res, err := newResource(context.Background())
+	g.Expect(err).To(BeNil())
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_METRICS_PROTOCOL, "thrift")
+	_, err = newMetricExporter(createTestRegistry(), res)
+	g.Expect(err).ToNot(BeNil())
+
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_METRICS_PROTOCOL, ProtocolHTTP)
+	setEnv(t, ENV_OTEL_METRIC_EXPORT_INTERVAL, "soon")
+	_, err = newMetricExporter(createTestRegistry(), res)
+	g.Expect(err).ToNot(BeNil())
+
+	setEnv(t, ENV_OTEL_METRIC_EXPORT_INTERVAL, "1000")
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_HEADERS, "novalue")
+	_, err = newMetricExporter(createTestRegistry(), res)
+	g.Expect(err).ToNot(BeNil())
+}
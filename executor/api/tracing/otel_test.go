@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testTraceId     = "4bf92f3577b34da6a3ce929d0e0e4736"
+)
+
+func setEnv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestSamplerFromEnv(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		sampler     string
+		arg         string
+		description string
+		err         bool
+	}{
+		{sampler: "", description: "ParentBased{root:AlwaysOnSampler"},
+		{sampler: "always_off", description: "AlwaysOffSampler"},
+		{sampler: "traceidratio", arg: "0.25", description: "TraceIDRatioBased{0.25}"},
+		{sampler: "parentbased_traceidratio", arg: "0.5", description: "ParentBased{root:TraceIDRatioBased{0.5}"},
+		{sampler: "traceidratio", arg: "2", err: true},
+		{sampler: "sometimes", err: true},
+	}
+	for _, test := range tests {
+		setEnv(t, ENV_OTEL_TRACES_SAMPLER, test.sampler)
+		setEnv(t, ENV_OTEL_TRACES_SAMPLER_ARG, test.arg)
+		sampler, err := samplerFromEnv()
+		if test.err {
+			g.Expect(err).ToNot(BeNil())
+		} else {
+			g.Expect(err).To(BeNil())
+			g.Expect(sampler.Description()).To(HavePrefix(test.description))
+		}
+	}
+}
+
+func TestNewOTLPExporterProtocol(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_PROTOCOL, ProtocolHTTP)
+	g.Expect(otlpProtocol()).To(Equal(ProtocolHTTP))
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_TRACES_PROTOCOL, ProtocolGRPC)
+	g.Expect(otlpProtocol()).To(Equal(ProtocolGRPC))
+
+	setEnv(t, ENV_OTEL_EXPORTER_OTLP_TRACES_PROTOCOL, "http/json")
+	_, err := newOTLPExporter(context.Background())
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestInitOpenTelemetry(t *testing.T) {
+	g := NewGomegaWithT(t)
+	setEnv(t, ENV_OTEL_TRACES_EXPORTER, ExporterOTLP)
+	setEnv(t, "OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4317")
+	// Nothing is exported, so closing doesn't wait for the missing collector
+	setEnv(t, ENV_OTEL_TRACES_SAMPLER, "always_off")
+	t.Cleanup(func() {
+		openTelemetry = false
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	closer, err := InitTracing()
+	g.Expect(err).To(BeNil())
+	defer closer.Close()
+	g.Expect(OpenTelemetryEnabled()).To(BeTrue())
+	g.Expect(opentracing.IsGlobalTracerRegistered()).To(BeTrue())
+
+	// The server span continues the propagated trace and baggage
+	header := http.Header{}
+	header.Set("traceparent", testTraceparent)
+	header.Set("baggage", "tenant=acme")
+	ctx, serverSpan := StartHTTPServerSpan(context.Background(), "predictions", header)
+	defer serverSpan.End()
+	g.Expect(serverSpan.SpanContext().TraceID().String()).To(Equal(testTraceId))
+	g.Expect(baggage.FromContext(ctx).Member("tenant").Value()).To(Equal("acme"))
+
+	// OpenTracing spans are bridged into the same trace
+	otSpan := opentracing.SpanFromContext(ctx)
+	g.Expect(otSpan).ToNot(BeNil())
+	childSpan := opentracing.StartSpan("child", opentracing.ChildOf(otSpan.Context()))
+	defer childSpan.Finish()
+	otHeader := http.Header{}
+	err = opentracing.GlobalTracer().Inject(childSpan.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(otHeader))
+	g.Expect(err).To(BeNil())
+	g.Expect(otHeader.Get("traceparent")).To(ContainSubstring(testTraceId))
+
+	// The client span propagates them on
+	clientHeader := http.Header{}
+	_, clientSpan := StartHTTPClientSpan(ctx, "predict", clientHeader)
+	defer clientSpan.End()
+	g.Expect(clientHeader.Get("traceparent")).To(ContainSubstring(testTraceId))
+	g.Expect(clientHeader.Get("baggage")).To(Equal("tenant=acme"))
+}
@@ -10,6 +10,10 @@ import (
 )
 
 func InitTracing() (io.Closer, error) {
+	if os.Getenv(ENV_OTEL_TRACES_EXPORTER) == ExporterOTLP {
+		return initOpenTelemetry()
+	}
+
 	//Initialise tracing
 	cfg, err := jaegercfg.FromEnv()
 	if err != nil {
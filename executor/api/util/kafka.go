@@ -0,0 +1,222 @@
+package util
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/go-logr/logr"
+)
+
+// Environment variables configuring the kafka clients. The SSL settings are read by GetSslElements.
+const (
+	ENV_KAFKA_SECURITY_PROTOCOL           = "KAFKA_SECURITY_PROTOCOL"
+	ENV_KAFKA_SASL_MECHANISM              = "KAFKA_SASL_MECHANISM"
+	ENV_KAFKA_SASL_USERNAME               = "KAFKA_SASL_USERNAME"
+	ENV_KAFKA_SASL_PASSWORD               = "KAFKA_SASL_PASSWORD"
+	ENV_KAFKA_SASL_OAUTHBEARER_TOKEN_FILE = "KAFKA_SASL_OAUTHBEARER_TOKEN_FILE"
+	// ENV_KAFKA_PROPERTIES_FILE is a file of librdkafka properties, one key=value per line
+	ENV_KAFKA_PROPERTIES_FILE = "KAFKA_PROPERTIES_FILE"
+	// ENV_KAFKA_PROPERTY_PREFIX prefixes variables holding a librdkafka property, named by upper casing the property
+	// and replacing dots with underscores, e.g. KAFKA_PROPERTY_SOCKET_KEEPALIVE_ENABLE
+	ENV_KAFKA_PROPERTY_PREFIX = "KAFKA_PROPERTY_"
+)
+
+const defaultOAuthBearerPrincipal = "seldon-executor"
+
+// GetKafkaConfigMap returns the configuration of a kafka producer or consumer, adding the security settings and the
+// passthrough properties from the environment to the client's own properties. Passthrough properties take
+// precedence, properties from variables over those from the file.
+func GetKafkaConfigMap(properties kafka.ConfigMap) (*kafka.ConfigMap, error) {
+	config := kafka.ConfigMap{}
+	for key, value := range properties {
+		config[key] = value
+	}
+	if err := addKafkaSecurity(config); err != nil {
+		return nil, err
+	}
+	if path := GetEnv(ENV_KAFKA_PROPERTIES_FILE, ""); path != "" {
+		if err := addKafkaPropertiesFile(config, path); err != nil {
+			return nil, err
+		}
+	}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, ENV_KAFKA_PROPERTY_PREFIX) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(env, ENV_KAFKA_PROPERTY_PREFIX), "=", 2)
+		key := strings.ToLower(strings.ReplaceAll(parts[0], "_", "."))
+		config[key] = parts[1]
+	}
+	return &config, nil
+}
+
+func addKafkaSecurity(config kafka.ConfigMap) error {
+	protocol := GetKafkaSecurityProtocol()
+	switch protocol {
+	case "":
+		return nil
+	case "PLAINTEXT", "SSL", "SASL_PLAINTEXT", "SASL_SSL":
+	default:
+		return fmt.Errorf("invalid %s %s, must be PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL", ENV_KAFKA_SECURITY_PROTOCOL, protocol)
+	}
+	config["security.protocol"] = protocol
+
+	if protocol == "SSL" || protocol == "SASL_SSL" {
+		sslKafka := GetSslElements()
+		for key, value := range map[string]string{
+			"ssl.ca.location":          sslKafka.CACertFile,
+			"ssl.key.location":         sslKafka.ClientKeyFile,
+			"ssl.certificate.location": sslKafka.ClientCertFile,
+			"ssl.ca.pem":               sslKafka.CACert,
+			"ssl.key.pem":              sslKafka.ClientKey,
+			"ssl.certificate.pem":      sslKafka.ClientCert,
+			"ssl.key.password":         sslKafka.ClientKeyPass,
+		} {
+			if value != "" {
+				config[key] = value
+			}
+		}
+	}
+
+	if strings.HasPrefix(protocol, "SASL_") {
+		mechanism := strings.ToUpper(GetEnv(ENV_KAFKA_SASL_MECHANISM, "PLAIN"))
+		switch mechanism {
+		case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+			username := GetEnv(ENV_KAFKA_SASL_USERNAME, "")
+			if username == "" {
+				return fmt.Errorf("%s is required for SASL mechanism %s", ENV_KAFKA_SASL_USERNAME, mechanism)
+			}
+			config["sasl.username"] = username
+			config["sasl.password"] = GetEnv(ENV_KAFKA_SASL_PASSWORD, "")
+		case "OAUTHBEARER":
+			// Tokens are read from the token file when a client asks for one, see RefreshKafkaOAuthBearerToken
+		default:
+			return fmt.Errorf("invalid %s %s, must be PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER", ENV_KAFKA_SASL_MECHANISM, mechanism)
+		}
+		config["sasl.mechanisms"] = mechanism
+	}
+	return nil
+}
+
+func addKafkaPropertiesFile(config kafka.ConfigMap, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid kafka property %q in %s", line, path)
+		}
+		config[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return scanner.Err()
+}
+
+// KafkaOAuthBearerClient is a kafka producer or consumer that can be given SASL/OAUTHBEARER tokens.
+type KafkaOAuthBearerClient interface {
+	SetOAuthBearerToken(token kafka.OAuthBearerToken) error
+	SetOAuthBearerTokenFailure(errstr string) error
+}
+
+// RefreshKafkaOAuthBearerToken handles a kafka.OAuthBearerTokenRefresh event by giving the client the JWT in the
+// file named by KAFKA_SASL_OAUTHBEARER_TOKEN_FILE, which should be kept up to date, for example by a projected
+// service account token volume.
+func RefreshKafkaOAuthBearerToken(client KafkaOAuthBearerClient) error {
+	path := GetEnv(ENV_KAFKA_SASL_OAUTHBEARER_TOKEN_FILE, "")
+	if path == "" {
+		return nil
+	}
+	token, err := readOAuthBearerToken(path)
+	if err != nil {
+		_ = client.SetOAuthBearerTokenFailure(err.Error())
+		return err
+	}
+	return client.SetOAuthBearerToken(token)
+}
+
+func readOAuthBearerToken(path string) (kafka.OAuthBearerToken, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return kafka.OAuthBearerToken{}, err
+	}
+	value := strings.TrimSpace(string(data))
+	// The token's expiry and principal are claims of the JWT
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return kafka.OAuthBearerToken{}, fmt.Errorf("token in %s is not a JWT", path)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return kafka.OAuthBearerToken{}, fmt.Errorf("invalid JWT in %s: %w", path, err)
+	}
+	var claims struct {
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return kafka.OAuthBearerToken{}, fmt.Errorf("invalid JWT claims in %s: %w", path, err)
+	}
+	if claims.Exp == 0 {
+		return kafka.OAuthBearerToken{}, fmt.Errorf("JWT in %s has no exp claim", path)
+	}
+	principal := claims.Sub
+	if principal == "" {
+		principal = defaultOAuthBearerPrincipal
+	}
+	return kafka.OAuthBearerToken{
+		TokenValue: value,
+		Expiration: time.Unix(claims.Exp, 0),
+		Principal:  principal,
+	}, nil
+}
+
+// NewKafkaProducer creates a producer configured by GetKafkaConfigMap. The producer's events are handled until it is
+// closed, refreshing OAUTHBEARER tokens and logging errors, so producers must use delivery channels to get
+// delivery reports.
+func NewKafkaProducer(properties kafka.ConfigMap, log logr.Logger) (*kafka.Producer, error) {
+	config, err := GetKafkaConfigMap(properties)
+	if err != nil {
+		return nil, err
+	}
+	p, err := kafka.NewProducer(config)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for ev := range p.Events() {
+			switch e := ev.(type) {
+			case kafka.OAuthBearerTokenRefresh:
+				if err := RefreshKafkaOAuthBearerToken(p); err != nil {
+					log.Error(err, "Failed to refresh OAUTHBEARER token")
+				}
+			case kafka.Error:
+				log.Error(e, "Received kafka producer error")
+			}
+		}
+	}()
+	return p, nil
+}
+
+// NewKafkaConsumer creates a consumer configured by GetKafkaConfigMap. Its poll loop should handle
+// kafka.OAuthBearerTokenRefresh events with RefreshKafkaOAuthBearerToken.
+func NewKafkaConsumer(properties kafka.ConfigMap) (*kafka.Consumer, error) {
+	config, err := GetKafkaConfigMap(properties)
+	if err != nil {
+		return nil, err
+	}
+	return kafka.NewConsumer(config)
+}
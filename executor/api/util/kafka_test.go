@@ -0,0 +1,112 @@
+package util
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	. "github.com/onsi/gomega"
+)
+
+func TestGetKafkaConfigMapSasl(t *testing.T) {
+	g := NewGomegaWithT(t)
+	t.Setenv(ENV_KAFKA_SECURITY_PROTOCOL, "sasl_ssl")
+	t.Setenv(ENV_KAFKA_SASL_MECHANISM, "SCRAM-SHA-512")
+	t.Setenv(ENV_KAFKA_SASL_USERNAME, "user")
+	t.Setenv(ENV_KAFKA_SASL_PASSWORD, "pass")
+	t.Setenv("KAFKA_SSL_CA_CERT_FILE", "/certs/ca.pem")
+
+	config, err := GetKafkaConfigMap(kafka.ConfigMap{"bootstrap.servers": "kafka:9093"})
+	g.Expect(err).To(BeNil())
+	g.Expect(*config).To(Equal(kafka.ConfigMap{
+		"bootstrap.servers": "kafka:9093",
+		"security.protocol": "SASL_SSL",
+		"ssl.ca.location":   "/certs/ca.pem",
+		"sasl.mechanisms":   "SCRAM-SHA-512",
+		"sasl.username":     "user",
+		"sasl.password":     "pass",
+	}))
+
+	t.Setenv(ENV_KAFKA_SASL_USERNAME, "")
+	_, err = GetKafkaConfigMap(kafka.ConfigMap{})
+	g.Expect(err).ToNot(BeNil())
+
+	t.Setenv(ENV_KAFKA_SASL_MECHANISM, "GSSAPI")
+	_, err = GetKafkaConfigMap(kafka.ConfigMap{})
+	g.Expect(err).ToNot(BeNil())
+
+	t.Setenv(ENV_KAFKA_SECURITY_PROTOCOL, "TLS")
+	_, err = GetKafkaConfigMap(kafka.ConfigMap{})
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestGetKafkaConfigMapProperties(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "kafka")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kafka.properties")
+	g.Expect(ioutil.WriteFile(path, []byte("# overrides\nsocket.timeout.ms = 1000\nclient.id=executor\n"), 0644)).To(BeNil())
+	t.Setenv(ENV_KAFKA_SECURITY_PROTOCOL, "")
+	t.Setenv(ENV_KAFKA_PROPERTIES_FILE, path)
+	t.Setenv("KAFKA_PROPERTY_CLIENT_ID", "seldon")
+
+	config, err := GetKafkaConfigMap(kafka.ConfigMap{"bootstrap.servers": "kafka:9092", "socket.timeout.ms": 60000})
+	g.Expect(err).To(BeNil())
+	g.Expect(*config).To(Equal(kafka.ConfigMap{
+		"bootstrap.servers": "kafka:9092",
+		"socket.timeout.ms": "1000",
+		"client.id":         "seldon",
+	}))
+
+	g.Expect(ioutil.WriteFile(path, []byte("socket.timeout.ms\n"), 0644)).To(BeNil())
+	_, err = GetKafkaConfigMap(kafka.ConfigMap{})
+	g.Expect(err).ToNot(BeNil())
+}
+
+type oauthTestClient struct {
+	token   kafka.OAuthBearerToken
+	failure string
+}
+
+func (c *oauthTestClient) SetOAuthBearerToken(token kafka.OAuthBearerToken) error {
+	c.token = token
+	return nil
+}
+
+func (c *oauthTestClient) SetOAuthBearerTokenFailure(errstr string) error {
+	c.failure = errstr
+	return nil
+}
+
+func TestRefreshKafkaOAuthBearerToken(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "kafka")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token")
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"system:serviceaccount:default:iris","exp":1893456000}`))
+	jwt := "eyJhbGciOiJSUzI1NiJ9." + claims + ".c2ln"
+	g.Expect(ioutil.WriteFile(path, []byte(jwt+"\n"), 0644)).To(BeNil())
+
+	// No token file configured
+	client := &oauthTestClient{}
+	g.Expect(RefreshKafkaOAuthBearerToken(client)).To(BeNil())
+	g.Expect(client.token.TokenValue).To(Equal(""))
+
+	t.Setenv(ENV_KAFKA_SASL_OAUTHBEARER_TOKEN_FILE, path)
+	g.Expect(RefreshKafkaOAuthBearerToken(client)).To(BeNil())
+	g.Expect(client.token).To(Equal(kafka.OAuthBearerToken{
+		TokenValue: jwt,
+		Expiration: time.Unix(1893456000, 0),
+		Principal:  "system:serviceaccount:default:iris",
+	}))
+
+	g.Expect(ioutil.WriteFile(path, []byte("opaque"), 0644)).To(BeNil())
+	g.Expect(RefreshKafkaOAuthBearerToken(client)).ToNot(BeNil())
+	g.Expect(client.failure).ToNot(BeEmpty())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/payload"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Assumes the byte array is a json list of ints
@@ -123,6 +124,108 @@ func InsertRouteToSeldonPredictPayload(msg payload.SeldonPayload, routing *map[s
 	}
 }
 
+// Add a tag to the meta of a SeldonMessage. Non SeldonMessage JSON payloads are returned unchanged.
+func InsertTagToSeldonPredictPayload(msg payload.SeldonPayload, key string, value interface{}) (payload.SeldonPayload, error) {
+	if msg.GetContentType() == payload.APPLICATION_TYPE_PROTOBUF {
+		sm, ok := msg.GetPayload().(*proto.SeldonMessage)
+		if !ok {
+			return msg, nil
+		}
+		tagValue, err := structpb.NewValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if sm.Meta == nil {
+			sm.Meta = &proto.Meta{}
+		}
+		if sm.Meta.Tags == nil {
+			sm.Meta.Tags = make(map[string]*structpb.Value)
+		}
+		sm.Meta.Tags[key] = tagValue
+		return &payload.ProtoPayload{Msg: sm}, nil
+	} else {
+		if msg.GetContentEncoding() != "" {
+			return msg, nil
+		}
+		var smInterface interface{}
+		smBytes, err := msg.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(smBytes, &smInterface); err != nil {
+			return nil, err
+		}
+		smJson, ok := (smInterface).(map[string]interface{})
+		if !ok {
+			return msg, nil
+		}
+		// Tensorflow and V2 responses have no meta
+		_, isTensorflow := smJson["predictions"]
+		_, isV2 := smJson["outputs"]
+		if isTensorflow || isV2 {
+			return msg, nil
+		}
+		metaJson, ok := smJson["meta"].(map[string]interface{})
+		if !ok {
+			metaJson = make(map[string]interface{})
+			smJson["meta"] = metaJson
+		}
+		tagsJson, ok := metaJson["tags"].(map[string]interface{})
+		if !ok {
+			tagsJson = make(map[string]interface{})
+			metaJson["tags"] = tagsJson
+		}
+		tagsJson[key] = value
+		smOutputBytes, err := json.Marshal(smInterface)
+		if err != nil {
+			return nil, err
+		}
+		return &payload.BytesPayload{Msg: smOutputBytes, ContentType: msg.GetContentType()}, nil
+	}
+}
+
+// Set the puid in the meta of a SeldonMessage. Non SeldonMessage payloads are returned unchanged.
+func InsertPuidToSeldonPredictPayload(msg payload.SeldonPayload, puid string) (payload.SeldonPayload, error) {
+	if msg.GetContentType() == payload.APPLICATION_TYPE_PROTOBUF {
+		sm, ok := msg.GetPayload().(*proto.SeldonMessage)
+		if !ok {
+			return msg, nil
+		}
+		if sm.Meta == nil {
+			sm.Meta = &proto.Meta{}
+		}
+		sm.Meta.Puid = puid
+		return &payload.ProtoPayload{Msg: sm}, nil
+	} else {
+		if msg.GetContentEncoding() != "" {
+			return msg, nil
+		}
+		var smInterface interface{}
+		smBytes, err := msg.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(smBytes, &smInterface); err != nil {
+			return msg, nil
+		}
+		smJson, ok := (smInterface).(map[string]interface{})
+		if !ok {
+			return msg, nil
+		}
+		// Only SeldonMessages carry the puid in their meta
+		metaJson, ok := smJson["meta"].(map[string]interface{})
+		if !ok {
+			return msg, nil
+		}
+		metaJson["puid"] = puid
+		smOutputBytes, err := json.Marshal(smInterface)
+		if err != nil {
+			return nil, err
+		}
+		return &payload.BytesPayload{Msg: smOutputBytes, ContentType: msg.GetContentType()}, nil
+	}
+}
+
 // Get an environment variable given by key or return the fallback.
 func GetEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
@@ -143,6 +246,18 @@ func GetEnvAsBool(key string, fallback bool) bool {
 	return fallback
 }
 
+// Get an integer environment variable given by key or return the fallback.
+func GetEnvAsInt(key string, fallback int) int {
+	if raw, ok := os.LookupEnv(key); ok {
+		val, err := strconv.Atoi(raw)
+		if err == nil {
+			return val
+		}
+	}
+
+	return fallback
+}
+
 type SslKakfa struct {
 	ClientCert     string
 	ClientKey      string
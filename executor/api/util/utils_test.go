@@ -101,6 +101,16 @@ func TestGetEnvAsBool(t *testing.T) {
 	}
 }
 
+func TestGetEnvAsInt(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Setenv("TEST_FOO", "250")
+	g.Expect(GetEnvAsInt("TEST_FOO", 10)).To(Equal(250))
+	t.Setenv("TEST_FOO", "foo")
+	g.Expect(GetEnvAsInt("TEST_FOO", 10)).To(Equal(10))
+	g.Expect(GetEnvAsInt("TEST_FOO_MISSING", 10)).To(Equal(10))
+}
+
 func TestInjectRouteSeldonProto(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -148,3 +158,62 @@ func TestSSLSecurityProtocol(t *testing.T) {
 	val := GetKafkaSecurityProtocol()
 	g.Expect(val).To(Equal("SSL"))
 }
+
+func TestInsertTagToSeldonPredictPayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := []struct {
+		msg      string
+		expected string
+	}{
+		{
+			msg:      `{"data":{"ndarray":[1]}}`,
+			expected: `{"data":{"ndarray":[1]},"meta":{"tags":{"dropped":["a"]}}}`,
+		},
+		{
+			msg:      `{"data":{"ndarray":[1]},"meta":{"tags":{"x":1}}}`,
+			expected: `{"data":{"ndarray":[1]},"meta":{"tags":{"dropped":["a"],"x":1}}}`,
+		},
+		{
+			msg:      `{"outputs":[]}`,
+			expected: `{"outputs":[]}`,
+		},
+	}
+
+	for _, c := range cases {
+		msg := payload.BytesPayload{Msg: []byte(c.msg), ContentType: "application/json"}
+		tagged, err := InsertTagToSeldonPredictPayload(&msg, "dropped", []interface{}{"a"})
+		g.Expect(err).To(BeNil())
+		g.Expect(string(tagged.GetPayload().([]byte))).To(Equal(c.expected))
+	}
+}
+
+func TestInsertPuidToSeldonPredictPayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := []struct {
+		msg      string
+		expected string
+	}{
+		{
+			msg:      `{"data":{"ndarray":[1]},"meta":{"puid":"old","tags":{"x":1}}}`,
+			expected: `{"data":{"ndarray":[1]},"meta":{"puid":"new","tags":{"x":1}}}`,
+		},
+		{
+			msg:      `{"outputs":[]}`,
+			expected: `{"outputs":[]}`,
+		},
+	}
+
+	for _, c := range cases {
+		msg := payload.BytesPayload{Msg: []byte(c.msg), ContentType: "application/json"}
+		res, err := InsertPuidToSeldonPredictPayload(&msg, "new")
+		g.Expect(err).To(BeNil())
+		g.Expect(string(res.GetPayload().([]byte))).To(Equal(c.expected))
+	}
+
+	sm := &proto.SeldonMessage{Meta: &proto.Meta{Puid: "old"}}
+	res, err := InsertPuidToSeldonPredictPayload(&payload.ProtoPayload{Msg: sm}, "new")
+	g.Expect(err).To(BeNil())
+	g.Expect(res.GetPayload().(*proto.SeldonMessage).GetMeta().GetPuid()).To(Equal("new"))
+}
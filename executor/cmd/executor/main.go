@@ -21,6 +21,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/cache"
 	seldonclient "github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/grpc"
 	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving"
@@ -30,6 +31,7 @@ import (
 	"github.com/seldonio/seldon-core/executor/api/grpc/tensorflow"
 	"github.com/seldonio/seldon-core/executor/api/kafka"
 	"github.com/seldonio/seldon-core/executor/api/rest"
+	"github.com/seldonio/seldon-core/executor/api/stream"
 	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/api/util"
 	"github.com/seldonio/seldon-core/executor/k8s"
@@ -54,7 +56,7 @@ const (
 )
 
 var (
-	serverType = flag.String("server_type", "rpc", "Server type: rpc or kafka")
+	serverType = flag.String("server_type", "rpc", "Server type: rpc, kafka or stream")
 
 	debugDefault = false
 
@@ -73,12 +75,27 @@ var (
 	logWorkers        = flag.Int("logger_workers", 10, "Number of workers handling payload logging")
 	logWorkBufferSize = flag.Int("log_work_buffer_size", loghandler.DefaultWorkQueueSize, "Limit of buffered logs in memory while waiting for downstream request ingestion")
 	logWriteTimeoutMs = flag.Int("log_write_timeout_ms", loghandler.DefaultWriteTimeoutMilliseconds, "Timeout before giving up writing log if buffer is full. If <= 0 will immediately drop log on full log buffer.")
+	logSpoolDir       = flag.String("log_spool_dir", "", "Directory to spool payload logs to when the log buffer is full or the log sink is down. If empty logs are dropped.")
+	logSpoolMaxBytes  = flag.Int64("log_spool_max_bytes", loghandler.DefaultSpoolMaxBytes, "Limit on the size of the payload log spool")
+	logSpoolSegment   = flag.Int64("log_spool_segment_bytes", loghandler.DefaultSpoolSegmentBytes, "Size at which the payload log spool starts a new file")
 	prometheusPath    = flag.String("prometheus_path", "/metrics", "The prometheus metrics path")
 	kafkaBroker       = flag.String("kafka_broker", "", "The kafka broker as host:port")
 	kafkaTopicIn      = flag.String("kafka_input_topic", "", "The kafka input topic")
 	kafkaTopicOut     = flag.String("kafka_output_topic", "", "The kafka output topic")
 	kafkaFullGraph    = flag.Bool("kafka_full_graph", false, "Use kafka for internal graph processing")
 	kafkaWorkers      = flag.Int("kafka_workers", 4, "Number of kafka workers")
+	kafkaDelivery     = flag.String("kafka_delivery", "", "Kafka delivery guarantee: at-most-once, at-least-once or exactly-once")
+	kafkaTopicDLQ     = flag.String("kafka_dlq_topic", "", "The kafka topic for requests that fail to be processed")
+	kafkaBatchSize    = flag.Int("kafka_batch_size", 1, "Most kafka requests each worker sends to the graph in one call")
+	kafkaBatchWaitMs  = flag.Int("kafka_batch_wait_ms", 10, "Most milliseconds a kafka worker waits to fill a batch")
+	kafkaKeyOrdering  = flag.Bool("kafka_key_ordering", false, "Process kafka requests with the same key in order")
+	streamUrl         = flag.String("stream_url", "", "The stream server URL, whose scheme chooses nats, redis or amqp")
+	streamInput       = flag.String("stream_input", "", "The subject, stream or queue to consume requests from")
+	streamOutput      = flag.String("stream_output", "", "The subject, stream or queue to publish responses to")
+	streamDLQ         = flag.String("stream_dlq", "", "The subject, stream or queue for requests that fail to be processed")
+	streamWorkers     = flag.Int("stream_workers", 4, "Number of stream workers")
+	banditRedisUrl    = flag.String("bandit_redis_url", "", "The Redis server URL bandit routers share their arm statistics through. If empty each replica keeps its own.")
+	cacheRedisUrl     = flag.String("cache_redis_url", "", "The Redis server URL graph and node caches share their responses through. If empty each replica caches in memory.")
 	logKafkaBroker    = flag.String("log_kafka_broker", "", "The kafka log broker")
 	logKafkaTopic     = flag.String("log_kafka_topic", "", "The kafka log topic")
 	debug             = flag.Bool(
@@ -142,9 +159,14 @@ func runGrpcServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, log
 	if err != nil {
 		log.Fatalf("Failed to create gRPC server: %v", err)
 	}
+	streamWindow, err := grpc.GetStreamWindowFromAnnotations(annotations)
+	if err != nil {
+		log.Fatalf("Failed to parse %s annotation: %v", k8s.ANNOTATION_GRPC_STREAM_WINDOW, err)
+	}
 	switch protocol {
 	case api.ProtocolSeldon:
 		seldonGrpcServer := seldon.NewGrpcSeldonServer(predictor, client, serverUrl, namespace)
+		seldonGrpcServer.StreamWindow = streamWindow
 		proto.RegisterSeldonServer(grpcServer, seldonGrpcServer)
 		// Register reflection service on gRPC server.
 		reflection.Register(grpcServer)
@@ -154,6 +176,7 @@ func runGrpcServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, log
 		serving.RegisterModelServiceServer(grpcServer, tensorflowGrpcServer)
 	case api.ProtocolKFServing:
 		kfservingGrpcServer := kfserving.NewGrpcKFServingServer(predictor, client, serverUrl, namespace)
+		kfservingGrpcServer.StreamWindow = streamWindow
 		kfproto.RegisterGRPCInferenceServiceServer(grpcServer, kfservingGrpcServer)
 	}
 
@@ -294,6 +317,64 @@ func main() {
 				*kafkaWorkers = kafkaWorkersFromEnvInt
 			}
 		}
+
+		// Delivery guarantee and dead letter topic
+		if *kafkaDelivery == "" {
+			*kafkaDelivery = os.Getenv(kafka.ENV_KAFKA_DELIVERY)
+		}
+		if *kafkaTopicDLQ == "" {
+			*kafkaTopicDLQ = os.Getenv(kafka.ENV_KAFKA_DLQ_TOPIC)
+		}
+
+		// Batching and key ordering
+		for env, value := range map[string]*int{kafka.ENV_KAFKA_BATCH_SIZE: kafkaBatchSize, kafka.ENV_KAFKA_BATCH_WAIT: kafkaBatchWaitMs} {
+			if fromEnv := os.Getenv(env); fromEnv != "" {
+				fromEnvInt, err := strconv.Atoi(fromEnv)
+				if err != nil {
+					log.Fatalf("Failed to parse %s %s", env, fromEnv)
+				}
+				*value = fromEnvInt
+			}
+		}
+		if *kafkaBatchSize < 1 || *kafkaBatchWaitMs < 0 {
+			log.Fatalf("Invalid kafka batch size %d or wait %dms", *kafkaBatchSize, *kafkaBatchWaitMs)
+		}
+		if kafkaKeyOrderingFromEnv := os.Getenv(kafka.ENV_KAFKA_KEY_ORDERING); kafkaKeyOrderingFromEnv != "" {
+			kafkaKeyOrderingFromEnvBool, err := strconv.ParseBool(kafkaKeyOrderingFromEnv)
+			if err != nil {
+				log.Fatalf("Failed to parse %s %s", kafka.ENV_KAFKA_KEY_ORDERING, kafkaKeyOrderingFromEnv)
+			}
+			*kafkaKeyOrdering = kafkaKeyOrderingFromEnvBool
+		}
+	}
+
+	if *serverType == "stream" {
+		for _, arg := range []struct {
+			value *string
+			env   string
+			name  string
+		}{
+			{streamUrl, stream.ENV_STREAM_URL, "stream_url"},
+			{streamInput, stream.ENV_STREAM_INPUT, "stream_input"},
+			{streamOutput, stream.ENV_STREAM_OUTPUT, "stream_output"},
+		} {
+			if *arg.value == "" {
+				*arg.value = os.Getenv(arg.env)
+				if *arg.value == "" {
+					log.Fatalf("Required argument %s missing", arg.name)
+				}
+			}
+		}
+		if *streamDLQ == "" {
+			*streamDLQ = os.Getenv(stream.ENV_STREAM_DLQ)
+		}
+		if streamWorkersFromEnv := os.Getenv(stream.ENV_STREAM_WORKERS); streamWorkersFromEnv != "" {
+			streamWorkersFromEnvInt, err := strconv.Atoi(streamWorkersFromEnv)
+			if err != nil {
+				log.Fatalf("Failed to parse %s %s", stream.ENV_STREAM_WORKERS, streamWorkersFromEnv)
+			}
+			*streamWorkers = streamWorkersFromEnvInt
+		}
 	}
 
 	if !(*transport == "rest" || *transport == "grpc") {
@@ -335,6 +416,32 @@ func main() {
 
 	}
 
+	if *banditRedisUrl == "" {
+		*banditRedisUrl = os.Getenv(predictor2.ENV_BANDIT_REDIS_URL)
+	}
+	if *banditRedisUrl != "" {
+		store, err := predictor2.NewRedisBanditStore(*banditRedisUrl, fmt.Sprintf("seldon:bandit:%s:%s:%s:", *namespace, *sdepName, *predictorName))
+		if err != nil {
+			logger.Error(err, "Failed to create bandit store")
+			os.Exit(-1)
+		}
+		predictor2.SetBanditStore(store)
+	}
+
+	if *cacheRedisUrl == "" {
+		*cacheRedisUrl = os.Getenv(cache.ENV_CACHE_REDIS_URL)
+	}
+	if *cacheRedisUrl != "" {
+		store, err := cache.NewRedisStore(*cacheRedisUrl, fmt.Sprintf("seldon:cache:%s:%s:%s:", *namespace, *sdepName, *predictorName))
+		if err != nil {
+			logger.Error(err, "Failed to create cache store")
+			os.Exit(-1)
+		}
+		predictor2.SetCacheStore(store)
+	}
+
+	predictor2.SetGraphMetrics(predictor, *sdepName)
+
 	// Ensure standard OpenAPI seldon API file has this deployment's values
 	err = rest.EmbedSeldonDeploymentValuesInSwaggerFile(*namespace, *sdepName)
 	if err != nil {
@@ -346,6 +453,13 @@ func main() {
 		logger.Error(err, "Failed to load annotations")
 	}
 
+	if *logSpoolDir != "" {
+		if err := loghandler.EnableSpool(*logSpoolDir, *logSpoolMaxBytes, *logSpoolSegment); err != nil {
+			logger.Error(err, "Failed to open log spool", "dir", *logSpoolDir)
+			os.Exit(-1)
+		}
+	}
+
 	//Start Logger Dispacther
 	err = loghandler.StartDispatcher(*logWorkers, *logWorkBufferSize, *logWriteTimeoutMs, logger, *sdepName, *namespace, *predictorName, *logKafkaBroker, *logKafkaTopic)
 	if err != nil {
@@ -355,23 +469,53 @@ func main() {
 	//Init Tracing
 	closer, err := tracing.InitTracing()
 	if err != nil {
-		log.Fatal("Could not initialize jaeger tracer", err.Error())
+		log.Fatal("Could not initialize tracer", err.Error())
 	}
 	defer closer.Close()
 
+	//Init OpenTelemetry metric export
+	metricCloser, err := tracing.InitMetricExport()
+	if err != nil {
+		log.Fatal("Could not initialize metric export", err.Error())
+	}
+	defer metricCloser.Close()
+
+	wg := sync.WaitGroup{}
 	if *serverType == "kafka" {
 		logger.Info("Starting kafka server")
-		kafkaServer, err := kafka.NewKafkaServer(*kafkaFullGraph, *kafkaWorkers, *sdepName, *namespace, *protocol, *transport, annotations, serverUrl, predictor, *kafkaBroker, *kafkaTopicIn, *kafkaTopicOut, logger)
+		kafkaServer, err := kafka.NewKafkaServer(*kafkaFullGraph, *kafkaWorkers, *sdepName, *namespace, *protocol, *transport, annotations, serverUrl, predictor, *kafkaBroker, *kafkaTopicIn, *kafkaTopicOut, *kafkaTopicDLQ, *kafkaDelivery, logger)
 		if err != nil {
 			log.Fatalf("Failed to create kafka server: %v", err)
 		}
+		kafkaServer.DrainTimeout = *wait
+		kafkaServer.BatchSize = *kafkaBatchSize
+		kafkaServer.BatchWait = time.Duration(*kafkaBatchWaitMs) * time.Millisecond
+		kafkaServer.KeyOrdering = *kafkaKeyOrdering
+		// Shutdown waits for the kafka server to drain the requests in flight
+		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			err = kafkaServer.Serve()
 			if err != nil {
 				log.Fatal("Failed to serve kafka", err)
 			}
 		}()
 	}
+	if *serverType == "stream" {
+		logger.Info("Starting stream server")
+		streamServer, err := stream.NewStreamServer(*streamWorkers, *sdepName, *namespace, *protocol, *transport, annotations, serverUrl, predictor, *streamUrl, *streamInput, *streamOutput, *streamDLQ, logger)
+		if err != nil {
+			log.Fatalf("Failed to create stream server: %v", err)
+		}
+		// Shutdown waits for the stream server to finish the requests in flight
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := streamServer.Serve(); err != nil {
+				log.Fatal("Failed to serve stream", err)
+			}
+		}()
+	}
 
 	clientRest, err := rest.NewJSONRestClient(*protocol, *sdepName, predictor, annotations)
 	if err != nil {
@@ -390,7 +534,19 @@ func main() {
 		log.Fatalf("Failed to create grpc client. Unknown protocol %s: %v", *protocol, err)
 	}
 
-	wg := sync.WaitGroup{}
+	// Graphs with both REST and gRPC nodes call each node over the transport of its endpoint
+	if seldonclient.HasMixedTransports(predictor) {
+		mixedRest, err := seldonclient.NewMixedTransportClient(clientRest, clientGrpc, *protocol, predictor)
+		if err != nil {
+			log.Fatalf("Failed to create mixed transport client: %v", err)
+		}
+		mixedGrpc, err := seldonclient.NewMixedTransportClient(clientGrpc, clientRest, *protocol, predictor)
+		if err != nil {
+			log.Fatalf("Failed to create mixed transport client: %v", err)
+		}
+		clientRest, clientGrpc = mixedRest, mixedGrpc
+	}
+
 	logger.Info("Running http server ", "port", *httpPort)
 	httpStop := make(chan bool, 1)
 	go runHttpServer(&wg, httpStop, createListener(*httpPort, logger), logger, predictor, clientRest, *httpPort, false, serverUrl, *namespace, *protocol, *sdepName, *prometheusPath)
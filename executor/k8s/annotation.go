@@ -11,6 +11,7 @@ const (
 	ANNOTATIONS_FILE                 = "/etc/podinfo/annotations"
 	ANNOTATION_GRPC_MAX_MESSAGE_SIZE = "seldon.io/grpc-max-message-size"
 	ANNOTATION_GRPC_TIMEOUT          = "seldon.io/grpc-timeout"
+	ANNOTATION_GRPC_STREAM_WINDOW    = "seldon.io/grpc-stream-window"
 	ANNOTATION_REST_TIMEOUT          = "seldon.io/rest-timeout"
 )
 
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	batchSizeParam   = "batchSize"
+	batchWaitMsParam = "batchWaitMs"
+
+	DefaultBatchWaitMs = 1000
+)
+
+// BatchSink posts logs to an HTTP webhook in batches, as a JSON array of CloudEvents. A batch is posted once it
+// holds batchSize logs or batchWaitMs have passed since its first log was added. Queued logs in a batch that
+// fails to post are spooled if a spool is enabled, and dropped otherwise.
+type BatchSink struct {
+	config    SinkConfig
+	target    string
+	batchSize int
+	batchWait time.Duration
+	client    http.Client
+	logs      chan batchLog
+}
+
+// batchLog is a log waiting in a batch. Its sender waits on done for the result of posting the batch, unless the
+// log was queued.
+type batchLog struct {
+	logReq LogRequest
+	done   chan error
+}
+
+// NewBatchSink creates a sink for a URL such as http://collector/ingest?batchSize=100&batchWaitMs=500. The
+// batching parameters are removed from the URL that is posted to.
+func NewBatchSink(u *url.URL, config SinkConfig) (Sink, error) {
+	query := u.Query()
+	batchSize, err := strconv.Atoi(query.Get(batchSizeParam))
+	if err != nil || batchSize <= 0 {
+		return nil, fmt.Errorf("invalid %s %s in logger URL", batchSizeParam, query.Get(batchSizeParam))
+	}
+	batchWaitMs := DefaultBatchWaitMs
+	if v := query.Get(batchWaitMsParam); v != "" {
+		batchWaitMs, err = strconv.Atoi(v)
+		if err != nil || batchWaitMs <= 0 {
+			return nil, fmt.Errorf("invalid %s %s in logger URL", batchWaitMsParam, v)
+		}
+	}
+	query.Del(batchSizeParam)
+	query.Del(batchWaitMsParam)
+	target := *u
+	target.RawQuery = query.Encode()
+
+	s := &BatchSink{
+		config:    config,
+		target:    target.String(),
+		batchSize: batchSize,
+		batchWait: time.Duration(batchWaitMs) * time.Millisecond,
+		client: http.Client{
+			Timeout: 60 * time.Second,
+		},
+		logs: make(chan batchLog, batchSize),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Send posts a log with the current batch, without waiting for the batch to fill, and returns once it is posted.
+// Logs are sent this way when they are drained from the spool, so they stay in the spool until they are posted.
+func (s *BatchSink) Send(logReq LogRequest) error {
+	done := make(chan error, 1)
+	s.logs <- batchLog{logReq: logReq, done: done}
+	return <-done
+}
+
+// Queue adds a log to the current batch.
+func (s *BatchSink) Queue(logReq LogRequest) {
+	s.logs <- batchLog{logReq: logReq}
+}
+
+func (s *BatchSink) run() {
+	var batch []batchLog
+	var timer <-chan time.Time
+	for {
+		select {
+		case item := <-s.logs:
+			batch = append(batch, item)
+			if len(batch) == 1 {
+				timer = time.After(s.batchWait)
+			}
+			if len(batch) < s.batchSize && item.done == nil {
+				continue
+			}
+		case <-timer:
+		}
+		s.post(batch)
+		batch = nil
+		timer = nil
+	}
+}
+
+func (s *BatchSink) post(batch []batchLog) {
+	events := make([]*event, 0, len(batch))
+	var posted []batchLog
+	for _, item := range batch {
+		e, err := newEvent(item.logReq, s.config)
+		if err != nil {
+			s.config.Log.Error(err, "Failed to create log event", "id", item.logReq.Id)
+			if item.done != nil {
+				item.done <- err
+			} else {
+				loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSendFailed).Inc()
+			}
+			continue
+		}
+		events = append(events, e)
+		posted = append(posted, item)
+	}
+	err := s.postEvents(events)
+	if err != nil {
+		s.config.Log.Error(err, "Failed to post log batch", "URL", s.target, "size", len(posted))
+	}
+	for _, item := range posted {
+		if item.done != nil {
+			item.done <- err
+		} else if err == nil {
+			continue
+		} else if spool == nil {
+			loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSendFailed).Inc()
+		} else if err := spool.Write(item.logReq); err != nil {
+			s.config.Log.Error(err, "Failed to spool log")
+		}
+	}
+}
+
+func (s *BatchSink) postEvents(events []*event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package logger
 
 import (
-	"github.com/pkg/errors"
 	"time"
+
+	"github.com/pkg/errors"
+	"github.com/seldonio/seldon-core/executor/api/metric"
 )
 
 const (
@@ -16,13 +18,33 @@ var (
 	workQueue = make(chan LogRequest, DefaultWorkQueueSize)
 	// writeTimeoutMilliseconds is the timeout for waiting for work to be written to the queue. If 0, will not wait if buffer is full.
 	writeTimeoutMilliseconds = DefaultWriteTimeoutMilliseconds
+	// spool holds logs that could not be queued or sent when it is enabled with EnableSpool.
+	spool *Spool
+
+	loggerMetrics = metric.NewLoggerMetrics()
 )
 
+// EnableSpool keeps payload logs in a spool on disk in dir when the work queue is full or they fail to send, rather
+// than dropping them. It must be called before StartDispatcher.
+func EnableSpool(dir string, maxBytes int64, segmentBytes int64) error {
+	s, err := NewSpool(dir, maxBytes, segmentBytes)
+	if err != nil {
+		return err
+	}
+	spool = s
+	return nil
+}
+
 func QueueLogRequest(req LogRequest) error {
 	select {
 	case workQueue <- req:
+		loggerMetrics.QueueDepthGauge.Set(float64(len(workQueue)))
 		return nil
 	case <-time.After(time.Duration(writeTimeoutMilliseconds) * time.Millisecond):
+		if spool != nil {
+			return spool.Write(req)
+		}
+		loggerMetrics.DroppedCounter.WithLabelValues(DropReasonQueueFull).Inc()
 		return errors.New("timed out waiting to queue log request: buffer is full")
 	}
 }
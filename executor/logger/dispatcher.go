@@ -34,6 +34,10 @@ func StartDispatcher(nworkers int, logBufferSize int, writeTimeoutMs int, log lo
 			return err
 		}
 		worker.Start()
+		if i == 0 && spool != nil {
+			log.Info("Draining payload log spool", "dir", spool.dir)
+			go spool.Drain(worker.send, nil)
+		}
 	}
 
 	return nil
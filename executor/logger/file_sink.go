@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	fileMaxBytesParam = "maxBytes"
+	fileMaxFilesParam = "maxFiles"
+
+	DefaultFileSinkMaxBytes = 100 << 20
+	DefaultFileSinkMaxFiles = 5
+)
+
+// FileSink appends logs as lines of CloudEvents JSON to a file, such as one on a mounted volume. When the file
+// reaches maxBytes it is renamed with a .1 suffix, older files are shifted up and at most maxFiles old files
+// are kept.
+type FileSink struct {
+	config   SinkConfig
+	path     string
+	maxBytes int64
+	maxFiles int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink creates a sink for a URL such as file:///var/log/seldon/payloads.jsonl?maxBytes=1048576&maxFiles=3.
+func NewFileSink(u *url.URL, config SinkConfig) (Sink, error) {
+	if u.Path == "" {
+		return nil, fmt.Errorf("file logger URL %s has no path", u.Redacted())
+	}
+	s := &FileSink{
+		config:   config,
+		path:     u.Path,
+		maxBytes: DefaultFileSinkMaxBytes,
+		maxFiles: DefaultFileSinkMaxFiles,
+	}
+	query := u.Query()
+	if v := query.Get(fileMaxBytesParam); v != "" {
+		maxBytes, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || maxBytes <= 0 {
+			return nil, fmt.Errorf("invalid %s %s in file logger URL", fileMaxBytesParam, v)
+		}
+		s.maxBytes = maxBytes
+	}
+	if v := query.Get(fileMaxFilesParam); v != "" {
+		maxFiles, err := strconv.Atoi(v)
+		if err != nil || maxFiles < 0 {
+			return nil, fmt.Errorf("invalid %s %s in file logger URL", fileMaxFilesParam, v)
+		}
+		s.maxFiles = maxFiles
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+// rotate renames the current file and opens a new one.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if s.maxFiles == 0 {
+		if err := os.Remove(s.path); err != nil {
+			return err
+		}
+	} else {
+		for i := s.maxFiles - 1; i > 0; i-- {
+			old := fmt.Sprintf("%s.%d", s.path, i)
+			if _, err := os.Stat(old); err == nil {
+				if err := os.Rename(old, fmt.Sprintf("%s.%d", s.path, i+1)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	}
+	return s.open()
+}
+
+func (s *FileSink) Send(logReq LogRequest) error {
+	line, err := encodeEvent(logReq, s.config)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
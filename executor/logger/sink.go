@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Sink sends payload logs to a store.
+type Sink interface {
+	Send(logReq LogRequest) error
+}
+
+// QueueingSink is a Sink that can take a log without waiting for it to be sent. The sink owns queued logs, so it
+// spools or drops those it fails to send, while Send returns once the log is sent.
+type QueueingSink interface {
+	Sink
+	Queue(logReq LogRequest)
+}
+
+// SinkConfig describes the deployment whose payloads a sink logs.
+type SinkConfig struct {
+	Log           logr.Logger
+	SdepName      string
+	Namespace     string
+	PredictorName string
+}
+
+// SinkFactory creates the sink for a logger URL.
+type SinkFactory func(u *url.URL, config SinkConfig) (Sink, error)
+
+var (
+	sinkFactoriesMu sync.RWMutex
+	sinkFactories   = map[string]SinkFactory{
+		"http":  NewHTTPSink,
+		"https": NewHTTPSink,
+		"file":  NewFileSink,
+		"nats":  NewNatsSink,
+	}
+
+	sinksMu sync.Mutex
+	// sinks holds the sink for each logger URL, shared by all workers.
+	sinks = map[string]Sink{}
+)
+
+// RegisterSink sets the factory creating sinks for logger URLs with the scheme.
+func RegisterSink(scheme string, factory SinkFactory) {
+	sinkFactoriesMu.Lock()
+	defer sinkFactoriesMu.Unlock()
+	sinkFactories[scheme] = factory
+}
+
+// getSink returns the sink for a logger URL, creating it on first use.
+func getSink(u *url.URL, config SinkConfig) (Sink, error) {
+	key := u.String()
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if sink, ok := sinks[key]; ok {
+		return sink, nil
+	}
+	sinkFactoriesMu.RLock()
+	factory, ok := sinkFactories[u.Scheme]
+	sinkFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no payload logger sink for URL scheme %q", u.Scheme)
+	}
+	sink, err := factory(u, config)
+	if err != nil {
+		return nil, err
+	}
+	sinks[key] = sink
+	return sink, nil
+}
+
+// NewHTTPSink sends CloudEvents to an HTTP endpoint, one event per request, unless the URL has a batchSize
+// query parameter in which case a BatchSink is returned.
+func NewHTTPSink(u *url.URL, config SinkConfig) (Sink, error) {
+	if u.Query().Get(batchSizeParam) != "" {
+		return NewBatchSink(u, config)
+	}
+	return newCloudEventSink(config), nil
+}
+
+// event is a payload log in the CloudEvents JSON event format.
+type event struct {
+	SpecVersion          string              `json:"specversion"`
+	Id                   string              `json:"id"`
+	Type                 string              `json:"type"`
+	Source               string              `json:"source"`
+	Time                 string              `json:"time"`
+	DataContentType      string              `json:"datacontenttype,omitempty"`
+	ContentEncoding      string              `json:"contentencoding,omitempty"`
+	ModelId              string              `json:"modelid"`
+	RequestId            string              `json:"requestid"`
+	InferenceServiceName string              `json:"inferenceservicename"`
+	Namespace            string              `json:"namespace"`
+	Endpoint             string              `json:"endpoint"`
+	Headers              map[string][]string `json:"headers,omitempty"`
+	Data                 json.RawMessage     `json:"data,omitempty"`
+	DataBase64           []byte              `json:"data_base64,omitempty"`
+}
+
+// newEvent converts a log into the CloudEvents JSON event format. Uncompressed JSON payloads are embedded in the
+// event data and other payloads are base64 encoded, with any content encoding in the contentencoding attribute.
+func newEvent(logReq LogRequest, config SinkConfig) (*event, error) {
+	ceType, err := getCEType(logReq)
+	if err != nil {
+		return nil, err
+	}
+	data := *logReq.Bytes
+	e := &event{
+		SpecVersion:          "1.0",
+		Id:                   logReq.Id,
+		Type:                 ceType,
+		Time:                 time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType:      logReq.ContentType,
+		ContentEncoding:      logReq.ContentEncoding,
+		ModelId:              logReq.ModelId,
+		RequestId:            logReq.RequestId,
+		InferenceServiceName: config.SdepName,
+		Namespace:            config.Namespace,
+		Endpoint:             config.PredictorName,
+		Headers:              logReq.Headers,
+	}
+	if logReq.SourceUri != nil {
+		e.Source = logReq.SourceUri.String()
+	}
+	if logReq.ContentEncoding == "" && strings.Contains(logReq.ContentType, "json") && json.Valid(data) {
+		e.Data = data
+	} else {
+		e.DataBase64 = data
+	}
+	return e, nil
+}
+
+func encodeEvent(logReq LogRequest, config SinkConfig) ([]byte, error) {
+	e, err := newEvent(logReq, config)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(e)
+}
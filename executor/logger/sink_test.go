@@ -0,0 +1,267 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func createSinkConfig() SinkConfig {
+	return SinkConfig{
+		Log:           logf.Log.WithName("SinkTest"),
+		SdepName:      "mydep",
+		Namespace:     "default",
+		PredictorName: "default",
+	}
+}
+
+func readEvents(g *GomegaWithT, path string) []map[string]interface{} {
+	f, err := os.Open(path)
+	g.Expect(err).To(BeNil())
+	defer f.Close()
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e map[string]interface{}
+		g.Expect(json.Unmarshal(scanner.Bytes(), &e)).To(BeNil())
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestEncodeEvent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	data, err := encodeEvent(createSpoolLogRequest("1"), createSinkConfig())
+	g.Expect(err).To(BeNil())
+	var e map[string]interface{}
+	g.Expect(json.Unmarshal(data, &e)).To(BeNil())
+	g.Expect(e["specversion"]).To(Equal("1.0"))
+	g.Expect(e["type"]).To(Equal(CEInferenceRequest))
+	g.Expect(e["source"]).To(Equal("http://source"))
+	g.Expect(e["modelid"]).To(Equal("model"))
+	g.Expect(e["requestid"]).To(Equal("puid-1"))
+	g.Expect(e["inferenceservicename"]).To(Equal("mydep"))
+	g.Expect(e["data"]).To(Equal(map[string]interface{}{"data": map[string]interface{}{"ndarray": []interface{}{1.0, 2.0}}}))
+
+	logReq := createSpoolLogRequest("2")
+	raw := []byte{0x0a, 0x01}
+	logReq.Bytes = &raw
+	logReq.ContentType = "application/protobuf"
+	data, err = encodeEvent(logReq, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	g.Expect(json.Unmarshal(data, &e)).To(BeNil())
+	g.Expect(e["data_base64"]).To(Equal("CgE="))
+}
+
+func TestGetSink(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, _ := url.Parse("http://logger")
+	sink, err := getSink(u, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	g.Expect(sink).To(BeAssignableToTypeOf(&cloudEventSink{}))
+	same, err := getSink(u, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	g.Expect(same).To(BeIdenticalTo(sink))
+
+	u, _ = url.Parse("http://logger?batchSize=10")
+	sink, err = getSink(u, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	g.Expect(sink).To(BeAssignableToTypeOf(&BatchSink{}))
+
+	u, _ = url.Parse("s3://bucket/logs")
+	_, err = getSink(u, createSinkConfig())
+	g.Expect(err).ToNot(BeNil())
+
+	sent := make(chan LogRequest, 1)
+	RegisterSink("test", func(u *url.URL, config SinkConfig) (Sink, error) {
+		return sinkFunc(func(logReq LogRequest) error {
+			sent <- logReq
+			return nil
+		}), nil
+	})
+	u, _ = url.Parse("test://sink")
+	sink, err = getSink(u, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	g.Expect(sink.Send(createSpoolLogRequest("1"))).To(BeNil())
+	g.Expect(sent).To(Receive())
+}
+
+type sinkFunc func(logReq LogRequest) error
+
+func (f sinkFunc) Send(logReq LogRequest) error {
+	return f(logReq)
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "filesink")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs", "payloads.jsonl")
+
+	u, _ := url.Parse("file://" + path + "?maxBytes=1000&maxFiles=2")
+	sink, err := NewFileSink(u, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+		g.Expect(sink.Send(createSpoolLogRequest(id))).To(BeNil())
+	}
+
+	events := readEvents(g, path)
+	g.Expect(events).ToNot(BeEmpty())
+	g.Expect(events[len(events)-1]["id"]).To(Equal("8"))
+	g.Expect(readEvents(g, path+".1")).ToNot(BeEmpty())
+	g.Expect(readEvents(g, path+".2")).ToNot(BeEmpty())
+	_, err = os.Stat(path + ".3")
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		g.Expect(err).To(BeNil())
+		g.Expect(info.Size()).To(BeNumerically("<=", 1000))
+	}
+
+	u, _ = url.Parse("file://" + path + "?maxBytes=big")
+	_, err = NewFileSink(u, createSinkConfig())
+	g.Expect(err).ToNot(BeNil())
+}
+
+type natsTestPublisher struct {
+	subject string
+	data    []byte
+}
+
+func (p *natsTestPublisher) Publish(subject string, data []byte) error {
+	p.subject = subject
+	p.data = data
+	return nil
+}
+
+func TestNatsSink(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, _ := url.Parse("nats://nats:4222/seldon.logs.iris")
+	g.Expect(natsSubject(u)).To(Equal("seldon.logs.iris"))
+	u, _ = url.Parse("nats://nats:4222")
+	g.Expect(natsSubject(u)).To(Equal(DefaultNatsSubject))
+
+	publisher := &natsTestPublisher{}
+	sink := newNatsSink(publisher, "seldon.logs.iris", createSinkConfig())
+	g.Expect(sink.Send(createSpoolLogRequest("1"))).To(BeNil())
+	g.Expect(publisher.subject).To(Equal("seldon.logs.iris"))
+	var e map[string]interface{}
+	g.Expect(json.Unmarshal(publisher.data, &e)).To(BeNil())
+	g.Expect(e["id"]).To(Equal("1"))
+}
+
+func TestBatchSink(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var mu sync.Mutex
+	var batches [][]map[string]interface{}
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []map[string]interface{}
+		g.Expect(json.NewDecoder(r.Body).Decode(&batch)).To(BeNil())
+		mu.Lock()
+		batches = append(batches, batch)
+		query = r.URL.Query()
+		mu.Unlock()
+	}))
+	defer server.Close()
+	getBatches := func() [][]map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]map[string]interface{}{}, batches...)
+	}
+
+	u, _ := url.Parse(server.URL + "/ingest?token=abc&batchSize=3&batchWaitMs=100")
+	s, err := NewBatchSink(u, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	sink := s.(QueueingSink)
+
+	// A full batch is posted at once
+	for _, id := range []string{"1", "2", "3"} {
+		sink.Queue(createSpoolLogRequest(id))
+	}
+	g.Eventually(getBatches, time.Second).Should(HaveLen(1))
+	g.Expect(getBatches()[0]).To(HaveLen(3))
+	g.Expect(getBatches()[0][2]["id"]).To(Equal("3"))
+	mu.Lock()
+	g.Expect(query).To(Equal(url.Values{"token": {"abc"}}))
+	mu.Unlock()
+
+	// A partial batch is posted after the wait
+	sink.Queue(createSpoolLogRequest("4"))
+	g.Eventually(getBatches, time.Second).Should(HaveLen(2))
+	g.Expect(getBatches()[1]).To(HaveLen(1))
+
+	// A sent log is posted with the queued logs without waiting, and Send returns once it is posted
+	sink.Queue(createSpoolLogRequest("5"))
+	g.Expect(sink.Send(createSpoolLogRequest("6"))).To(BeNil())
+	g.Expect(getBatches()).To(HaveLen(3))
+	g.Expect(getBatches()[2]).To(HaveLen(2))
+	g.Expect(getBatches()[2][1]["id"]).To(Equal("6"))
+
+	// Send returns the error when the batch fails to post
+	u, _ = url.Parse("http://127.0.0.1:1/ingest?batchSize=3")
+	failing, err := NewBatchSink(u, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	g.Expect(failing.Send(createSpoolLogRequest("7"))).ToNot(BeNil())
+
+	u, _ = url.Parse(server.URL + "?batchSize=0")
+	_, err = NewBatchSink(u, createSinkConfig())
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestEncodeEventCompressed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	logReq := createSpoolLogRequest("1")
+	gzipped := []byte{0x1f, 0x8b, 0x08}
+	logReq.Bytes = &gzipped
+	logReq.ContentEncoding = "gzip"
+	data, err := encodeEvent(logReq, createSinkConfig())
+	g.Expect(err).To(BeNil())
+	var e map[string]interface{}
+	g.Expect(json.Unmarshal(data, &e)).To(BeNil())
+	g.Expect(e["contentencoding"]).To(Equal("gzip"))
+	g.Expect(e["datacontenttype"]).To(Equal("application/json"))
+	g.Expect(e["data_base64"]).To(Equal("H4sI"))
+	g.Expect(e).ToNot(HaveKey("data"))
+}
+
+func TestCloudEventSinkCompressed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gzipped := []byte{0x1f, 0x8b, 0x08}
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		g.Expect(err).To(BeNil())
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	logReq := createSpoolLogRequest("1")
+	logReq.Url, _ = url.Parse(server.URL)
+	logReq.Bytes = &gzipped
+	logReq.ContentEncoding = "gzip"
+	g.Expect(newCloudEventSink(createSinkConfig()).Send(logReq)).To(BeNil())
+	var r *http.Request
+	g.Eventually(received).Should(Receive(&r))
+	g.Expect(r.Header.Get("ce-" + ContentEncodingAttr)).To(Equal("gzip"))
+	g.Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+	g.Expect(<-bodies).To(Equal(gzipped))
+}
@@ -0,0 +1,412 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultSpoolMaxBytes     = 1 << 30
+	DefaultSpoolSegmentBytes = 64 << 20
+
+	spoolSegmentSuffix = ".spool"
+	spoolOffsetFile    = "offset"
+	spoolTempSuffix    = ".tmp"
+
+	spoolInitialBackoff = 100 * time.Millisecond
+	spoolMaxBackoff     = 30 * time.Second
+	spoolPollInterval   = time.Second
+	spoolSyncInterval   = 200 * time.Millisecond
+
+	DropReasonQueueFull  = "queue_full"
+	DropReasonSendFailed = "send_failed"
+	DropReasonSpoolFull  = "spool_full"
+	DropReasonSpoolError = "spool_error"
+)
+
+// spoolRecord is the form of a LogRequest written to the spool.
+type spoolRecord struct {
+	Url             string              `json:"url"`
+	Bytes           []byte              `json:"bytes"`
+	ContentType     string              `json:"contentType"`
+	ContentEncoding string              `json:"contentEncoding,omitempty"`
+	ReqType         LogRequestType      `json:"reqType"`
+	Id              string              `json:"id"`
+	SourceUri       string              `json:"sourceUri"`
+	ModelId         string              `json:"modelId"`
+	RequestId       string              `json:"requestId"`
+	Headers         map[string][]string `json:"headers,omitempty"`
+}
+
+// Spool is a write-ahead log on local disk for payload logs that could not be sent. Logs are appended as
+// lines of JSON to numbered segment files, which are deleted once every log in them has been sent. Writes are
+// synced to disk when a segment is finished and at most spoolSyncInterval after they are made. The position
+// of the oldest unsent log is kept in an offset file so logs are not sent twice after a restart.
+type Spool struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+
+	mu       sync.Mutex
+	segments []int64
+	size     int64
+	nextSeq  int64
+	notify   chan struct{}
+
+	writer     *os.File
+	writerSeq  int64
+	writerSize int64
+	syncTimer  *time.Timer
+
+	readerFile   *os.File
+	reader       *bufio.Reader
+	readerSeq    int64
+	readerOffset int64
+	pending      *LogRequest
+	pendingLen   int64
+}
+
+// NewSpool opens the spool in dir, creating the directory if needed. Logs left in the directory by a previous
+// run are sent first.
+func NewSpool(dir string, maxBytes int64, segmentBytes int64) (*Spool, error) {
+	if maxBytes <= 0 || segmentBytes <= 0 {
+		return nil, fmt.Errorf("spool sizes must be positive, got max %d and segment %d", maxBytes, segmentBytes)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spool{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		segmentBytes: segmentBytes,
+		notify:       make(chan struct{}, 1),
+		writerSeq:    -1,
+		readerSeq:    -1,
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), spoolSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(file.Name(), spoolSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, seq)
+		s.size += file.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+	if data, err := ioutil.ReadFile(filepath.Join(dir, spoolOffsetFile)); err == nil && len(s.segments) > 0 {
+		var seq, offset int64
+		if _, err := fmt.Sscanf(string(data), "%d %d", &seq, &offset); err == nil && seq == s.segments[0] {
+			s.readerSeq = seq
+			s.readerOffset = offset
+		}
+	}
+	loggerMetrics.SpooledBytesGauge.Set(float64(s.size))
+	return s, nil
+}
+
+func (s *Spool) segmentPath(seq int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentSuffix))
+}
+
+// Write appends a log to the spool. The log is dropped if the spool is full.
+func (s *Spool) Write(req LogRequest) error {
+	record := spoolRecord{
+		Bytes:           *req.Bytes,
+		ContentType:     req.ContentType,
+		ContentEncoding: req.ContentEncoding,
+		ReqType:         req.ReqType,
+		Id:              req.Id,
+		ModelId:         req.ModelId,
+		RequestId:       req.RequestId,
+		Headers:         req.Headers,
+	}
+	if req.Url != nil {
+		record.Url = req.Url.String()
+	}
+	if req.SourceUri != nil {
+		record.SourceUri = req.SourceUri.String()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolError).Inc()
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size+int64(len(line)) > s.maxBytes {
+		loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolFull).Inc()
+		return errors.New("payload log spool is full")
+	}
+	if s.writer == nil || (s.writerSize > 0 && s.writerSize+int64(len(line)) > s.segmentBytes) {
+		if err := s.rotate(); err != nil {
+			loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolError).Inc()
+			return err
+		}
+	}
+	n, err := s.writer.Write(line)
+	s.writerSize += int64(n)
+	s.size += int64(n)
+	loggerMetrics.SpooledBytesGauge.Set(float64(s.size))
+	if err != nil {
+		loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolError).Inc()
+		return err
+	}
+	if s.syncTimer == nil {
+		s.syncTimer = time.AfterFunc(spoolSyncInterval, s.sync)
+	}
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// sync flushes writes to the segment being written to disk.
+func (s *Spool) sync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncTimer = nil
+	if s.writer != nil {
+		// Writes that fail to sync are still flushed by the OS unless the node crashes
+		_ = s.writer.Sync()
+	}
+}
+
+// rotate starts a new segment for writing.
+func (s *Spool) rotate() error {
+	if s.writer != nil {
+		if err := s.writer.Sync(); err != nil {
+			return err
+		}
+		if err := s.writer.Close(); err != nil {
+			return err
+		}
+		s.writer = nil
+	}
+	seq := s.nextSeq
+	f, err := os.OpenFile(s.segmentPath(seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := syncDir(s.dir); err != nil {
+		f.Close()
+		return err
+	}
+	s.nextSeq++
+	s.segments = append(s.segments, seq)
+	s.writer = f
+	s.writerSeq = seq
+	s.writerSize = 0
+	return nil
+}
+
+// next returns the oldest unsent log, or false if the spool is empty.
+func (s *Spool) next() (*LogRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if s.pending != nil {
+			return s.pending, true
+		}
+		if len(s.segments) == 0 {
+			return nil, false
+		}
+		if s.reader == nil {
+			if err := s.openReader(); err != nil {
+				// An unreadable segment can never be sent
+				loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolError).Inc()
+				s.removeSegment()
+				continue
+			}
+		}
+		line, err := s.reader.ReadBytes('\n')
+		if err == nil {
+			req, err := decodeSpoolRecord(line)
+			if err != nil {
+				loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolError).Inc()
+				s.readerOffset += int64(len(line))
+				continue
+			}
+			s.pending = req
+			s.pendingLen = int64(len(line))
+			return req, true
+		}
+		if err != io.EOF {
+			loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolError).Inc()
+			s.removeSegment()
+			continue
+		}
+		if s.readerSeq == s.writerSeq {
+			// Writes are whole lines so the segment being written has been fully read. It is only removed
+			// once it holds no unsent logs so its space is reclaimed.
+			if s.readerOffset == s.writerSize {
+				s.removeSegment()
+			}
+			return nil, false
+		}
+		// Any partial line left at the end of an older segment was from a write interrupted by a crash
+		if len(line) > 0 {
+			loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolError).Inc()
+		}
+		s.removeSegment()
+	}
+}
+
+func (s *Spool) openReader() error {
+	seq := s.segments[0]
+	if seq != s.readerSeq {
+		s.readerSeq = seq
+		s.readerOffset = 0
+	}
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(s.readerOffset, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	s.readerFile = f
+	s.reader = bufio.NewReader(f)
+	return nil
+}
+
+// removeSegment deletes the oldest segment, closing it first if it is being written.
+func (s *Spool) removeSegment() {
+	seq := s.segments[0]
+	if s.readerFile != nil {
+		s.readerFile.Close()
+		s.readerFile = nil
+		s.reader = nil
+	}
+	if seq == s.writerSeq && s.writer != nil {
+		s.writer.Close()
+		s.writer = nil
+		s.writerSeq = -1
+		s.writerSize = 0
+	}
+	path := s.segmentPath(seq)
+	if info, err := os.Stat(path); err == nil {
+		s.size -= info.Size()
+	}
+	os.Remove(path)
+	s.segments = s.segments[1:]
+	s.readerSeq = -1
+	s.readerOffset = 0
+	loggerMetrics.SpooledBytesGauge.Set(float64(s.size))
+}
+
+// ack marks the log returned by next as sent.
+func (s *Spool) ack() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readerOffset += s.pendingLen
+	s.pending = nil
+	s.pendingLen = 0
+	offset := fmt.Sprintf("%d %d", s.readerSeq, s.readerOffset)
+	_ = writeFileAtomic(filepath.Join(s.dir, spoolOffsetFile), []byte(offset))
+}
+
+// writeFileAtomic replaces the file at path by renaming a new file over it, so a crash leaves either the old or
+// the new contents rather than a partly written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + spoolTempSuffix
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// syncDir flushes the entries of a directory to disk so files created in it survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func decodeSpoolRecord(line []byte) (*LogRequest, error) {
+	var record spoolRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, err
+	}
+	logUrl, err := url.Parse(record.Url)
+	if err != nil {
+		return nil, err
+	}
+	sourceUri, err := url.Parse(record.SourceUri)
+	if err != nil {
+		return nil, err
+	}
+	return &LogRequest{
+		Url:             logUrl,
+		Bytes:           &record.Bytes,
+		ContentType:     record.ContentType,
+		ContentEncoding: record.ContentEncoding,
+		ReqType:         record.ReqType,
+		Id:              record.Id,
+		SourceUri:       sourceUri,
+		ModelId:         record.ModelId,
+		RequestId:       record.RequestId,
+		Headers:         record.Headers,
+	}, nil
+}
+
+// Drain sends spooled logs in the order they were written until quit is closed. A log that fails to send is
+// retried with exponential backoff, so the spool is drained once the sink recovers.
+func (s *Spool) Drain(send func(LogRequest) error, quit <-chan struct{}) {
+	backoff := spoolInitialBackoff
+	for {
+		req, ok := s.next()
+		if !ok {
+			select {
+			case <-s.notify:
+			case <-time.After(spoolPollInterval):
+			case <-quit:
+				return
+			}
+			continue
+		}
+		if err := send(*req); err != nil {
+			loggerMetrics.RetriesCounter.Inc()
+			select {
+			case <-time.After(backoff):
+			case <-quit:
+				return
+			}
+			backoff *= 2
+			if backoff > spoolMaxBackoff {
+				backoff = spoolMaxBackoff
+			}
+			continue
+		}
+		backoff = spoolInitialBackoff
+		s.ack()
+	}
+}
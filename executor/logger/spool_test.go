@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func createSpoolLogRequest(id string) LogRequest {
+	logUrl, _ := url.Parse("http://logger")
+	sourceUri, _ := url.Parse("http://source")
+	data := []byte(`{"data":{"ndarray":[1,2]}}`)
+	return LogRequest{
+		Url:         logUrl,
+		Bytes:       &data,
+		ContentType: "application/json",
+		ReqType:     InferenceRequest,
+		Id:          id,
+		SourceUri:   sourceUri,
+		ModelId:     "model",
+		RequestId:   "puid-" + id,
+		Headers:     map[string][]string{"X-User-Id": {"user1"}},
+	}
+}
+
+type spoolTestSink struct {
+	mu       sync.Mutex
+	failures int
+	sent     []string
+}
+
+func (s *spoolTestSink) send(req LogRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink down")
+	}
+	s.sent = append(s.sent, req.Id)
+	return nil
+}
+
+func (s *spoolTestSink) getSent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.sent...)
+}
+
+func spoolSegmentFiles(g *GomegaWithT, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentSuffix))
+	g.Expect(err).To(BeNil())
+	return files
+}
+
+func TestSpoolDrainsWithRetries(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "spool")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	spool, err := NewSpool(dir, 1<<20, 300)
+	g.Expect(err).To(BeNil())
+	for _, id := range []string{"1", "2", "3", "4"} {
+		g.Expect(spool.Write(createSpoolLogRequest(id))).To(BeNil())
+	}
+	// Small segments rotate so the logs are spread over several files
+	g.Expect(len(spoolSegmentFiles(g, dir))).To(BeNumerically(">", 1))
+
+	retries := testutil.ToFloat64(loggerMetrics.RetriesCounter)
+	sink := &spoolTestSink{failures: 2}
+	quit := make(chan struct{})
+	defer close(quit)
+	go spool.Drain(sink.send, quit)
+
+	g.Eventually(sink.getSent, 5*time.Second).Should(Equal([]string{"1", "2", "3", "4"}))
+	g.Expect(testutil.ToFloat64(loggerMetrics.RetriesCounter) - retries).To(Equal(2.0))
+	g.Eventually(func() []string { return spoolSegmentFiles(g, dir) }, 5*time.Second).Should(BeEmpty())
+	g.Eventually(func() float64 { return testutil.ToFloat64(loggerMetrics.SpooledBytesGauge) }).Should(Equal(0.0))
+
+	// Logs written after the spool was emptied are sent too
+	g.Expect(spool.Write(createSpoolLogRequest("5"))).To(BeNil())
+	g.Eventually(sink.getSent, 5*time.Second).Should(ContainElement("5"))
+}
+
+func TestSpoolResumesAfterRestart(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "spool")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	spool, err := NewSpool(dir, 1<<20, 1<<20)
+	g.Expect(err).To(BeNil())
+	for _, id := range []string{"1", "2", "3"} {
+		g.Expect(spool.Write(createSpoolLogRequest(id))).To(BeNil())
+	}
+	req, ok := spool.next()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(req.Id).To(Equal("1"))
+	g.Expect(req.Headers).To(Equal(map[string][]string{"X-User-Id": {"user1"}}))
+	g.Expect(string(*req.Bytes)).To(Equal(`{"data":{"ndarray":[1,2]}}`))
+	g.Expect(req.Url.String()).To(Equal("http://logger"))
+	spool.ack()
+	_, err = os.Stat(filepath.Join(dir, spoolOffsetFile+spoolTempSuffix))
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+
+	// The sent log is not sent again when the spool is reopened
+	reopened, err := NewSpool(dir, 1<<20, 1<<20)
+	g.Expect(err).To(BeNil())
+	g.Expect(reopened.Write(createSpoolLogRequest("4"))).To(BeNil())
+	var ids []string
+	for {
+		req, ok := reopened.next()
+		if !ok {
+			break
+		}
+		ids = append(ids, req.Id)
+		reopened.ack()
+	}
+	g.Expect(ids).To(Equal([]string{"2", "3", "4"}))
+	g.Expect(spoolSegmentFiles(g, dir)).To(BeEmpty())
+}
+
+func TestSpoolFull(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "spool")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	spool, err := NewSpool(dir, 300, 100)
+	g.Expect(err).To(BeNil())
+	dropped := testutil.ToFloat64(loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolFull))
+	g.Expect(spool.Write(createSpoolLogRequest("1"))).To(BeNil())
+	g.Expect(spool.Write(createSpoolLogRequest("2"))).ToNot(BeNil())
+	g.Expect(testutil.ToFloat64(loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSpoolFull)) - dropped).To(Equal(1.0))
+
+	_, err = NewSpool(dir, 0, 100)
+	g.Expect(err).ToNot(BeNil())
+}
@@ -10,6 +10,8 @@ const (
 	InferenceRequest  LogRequestType = "Request"
 	InferenceResponse LogRequestType = "Response"
 	InferenceFeedback LogRequestType = "Feedback"
+	// InferencePair logs a node call with its request and response in one event
+	InferencePair LogRequestType = "Pair"
 )
 
 type LogRequest struct {
@@ -22,4 +24,6 @@ type LogRequest struct {
 	SourceUri       *url.URL
 	ModelId         string
 	RequestId       string
+	// Request headers logged with the payload
+	Headers map[string][]string
 }
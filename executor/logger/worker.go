@@ -8,10 +8,9 @@ import (
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go"
-	"github.com/cloudevents/sdk-go/pkg/cloudevents/transport"
+	cehttp "github.com/cloudevents/sdk-go/pkg/cloudevents/transport/http"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/go-logr/logr"
-	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/util"
 )
 
@@ -19,12 +18,14 @@ const (
 	CEInferenceRequest  = "io.seldon.serving.inference.request"
 	CEInferenceResponse = "io.seldon.serving.inference.response"
 	CEFeedback          = "io.seldon.serving.feedback"
+	CEInferencePair     = "io.seldon.serving.inference.pair"
 	// cloud events extension attributes have to be lowercase alphanumeric
 	RequestIdAttr            = "requestid"
 	ModelIdAttr              = "modelid"
 	InferenceServiceNameAttr = "inferenceservicename"
 	NamespaceAttr            = "namespace"
 	EndpointAttr             = "endpoint"
+	ContentEncodingAttr      = "contentencoding"
 	KafkaTypeHeader          = "type"
 	KafkaContentTypeHeader   = "content-type"
 )
@@ -38,28 +39,12 @@ func NewWorker(id int, workQueue chan LogRequest, log logr.Logger, sdepName stri
 	var err error
 	if kafkaBroker != "" {
 		log.Info("Creating producer", "broker", kafkaBroker, "topic", kafkaTopic)
+		// Delivery reports are sent to a channel for each log, so logs that fail to deliver can be spooled
 		var producerConfigMap = kafka.ConfigMap{"bootstrap.servers": kafkaBroker,
-			"go.delivery.reports": false, // Need this othewise will get memory leak
+			"go.delivery.reports": true,
 		}
 		log.Info("kafkaSecurityProtocol", "kafkaSecurityProtocol", util.GetKafkaSecurityProtocol())
-		if util.GetKafkaSecurityProtocol() == "SSL" {
-			sslKafka := util.GetSslElements()
-			producerConfigMap["security.protocol"] = util.GetKafkaSecurityProtocol()
-			if sslKafka.CACertFile != "" && sslKafka.ClientCertFile != "" {
-				producerConfigMap["ssl.ca.location"] = sslKafka.CACertFile
-				producerConfigMap["ssl.key.location"] = sslKafka.ClientKeyFile
-				producerConfigMap["ssl.certificate.location"] = sslKafka.ClientCertFile
-			}
-			if sslKafka.CACert != "" && sslKafka.ClientCert != "" {
-				producerConfigMap["ssl.ca.pem"] = sslKafka.CACert
-				producerConfigMap["ssl.key.pem"] = sslKafka.ClientKey
-				producerConfigMap["ssl.certificate.pem"] = sslKafka.ClientCert
-			}
-			producerConfigMap["ssl.key.password"] = sslKafka.ClientKeyPass // Key password, if any
-
-		}
-
-		producer, err = kafka.NewProducer(&producerConfigMap)
+		producer, err = util.NewKafkaProducer(producerConfigMap, log)
 		if err != nil {
 			return nil, err
 		}
@@ -75,7 +60,6 @@ func NewWorker(id int, workQueue chan LogRequest, log logr.Logger, sdepName stri
 		Client: http.Client{
 			Timeout: 60 * time.Second,
 		},
-		CeCtx:         cloudevents.ContextWithEncoding(context.Background(), cloudevents.Binary),
 		SdepName:      sdepName,
 		Namespace:     namespace,
 		PredictorName: predictorName,
@@ -90,8 +74,6 @@ type Worker struct {
 	Work          chan LogRequest
 	QuitChan      chan bool
 	Client        http.Client
-	CeCtx         context.Context
-	CeTransport   transport.Transport
 	SdepName      string
 	Namespace     string
 	PredictorName string
@@ -107,6 +89,8 @@ func getCEType(logReq LogRequest) (string, error) {
 		return CEInferenceResponse, nil
 	case InferenceFeedback:
 		return CEFeedback, nil
+	case InferencePair:
+		return CEInferencePair, nil
 	default:
 		return "", fmt.Errorf("Incorrect log request type: %s", errors.New("Incorrect log request type"))
 	}
@@ -127,31 +111,47 @@ func (w *Worker) sendKafkaEvent(logReq LogRequest) error {
 		{Key: NamespaceAttr, Value: []byte(w.Namespace)},
 		{Key: EndpointAttr, Value: []byte(w.PredictorName)},
 	}
+	if logReq.ContentEncoding != "" {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: ContentEncodingAttr, Value: []byte(logReq.ContentEncoding)})
+	}
+	for name, values := range logReq.Headers {
+		for _, value := range values {
+			kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: name, Value: []byte(value)})
+		}
+	}
 	w.Log.Info("kafkaHeaders is", "kafkaHeaders", kafkaHeaders)
+	deliveryChan := make(chan kafka.Event, 1)
 	err = w.Producer.Produce(&kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &w.KafkaTopic, Partition: kafka.PartitionAny},
 		Value:          *logReq.Bytes,
 		Headers:        kafkaHeaders,
-	}, nil)
+	}, deliveryChan)
 	if err != nil {
 		w.Log.Error(err, "Failed to produce response")
 		return err
 	}
+	m := (<-deliveryChan).(*kafka.Message)
+	if m.TopicPartition.Error != nil {
+		return fmt.Errorf("failed to deliver to %s: %w", w.KafkaTopic, m.TopicPartition.Error)
+	}
 
 	return nil
 }
 
-func (w *Worker) sendCloudEvent(logReq LogRequest) error {
+// cloudEventSink sends each log to an HTTP endpoint as a CloudEvent in binary mode.
+type cloudEventSink struct {
+	config SinkConfig
+	ceCtx  context.Context
+}
 
-	// This temporary fix related to the fact that Triton server responses
-	// are now gzipped compressed. Until we introduce support for gzip
-	// compressed payloads in the logger / adserver and include content-encoding
-	// header in the CloudEvent messages this can serve as temporary solution.
-	data, err := payload.DecompressBytes(*logReq.Bytes, logReq.ContentEncoding)
-	if err != nil {
-		return fmt.Errorf("while creating http transport: %s", err)
+func newCloudEventSink(config SinkConfig) *cloudEventSink {
+	return &cloudEventSink{
+		config: config,
+		ceCtx:  cloudevents.ContextWithEncoding(context.Background(), cloudevents.Binary),
 	}
+}
 
+func (s *cloudEventSink) Send(logReq LogRequest) error {
 	t, err := cloudevents.NewHTTPTransport(
 		cloudevents.WithTarget(logReq.Url.String()),
 		cloudevents.WithEncoding(cloudevents.HTTPBinaryV1),
@@ -176,25 +176,84 @@ func (w *Worker) sendCloudEvent(logReq LogRequest) error {
 
 	event.SetExtension(ModelIdAttr, logReq.ModelId)
 	event.SetExtension(RequestIdAttr, logReq.RequestId)
-	event.SetExtension(InferenceServiceNameAttr, w.SdepName)
-	event.SetExtension(NamespaceAttr, w.Namespace)
+	event.SetExtension(InferenceServiceNameAttr, s.config.SdepName)
+	event.SetExtension(NamespaceAttr, s.config.Namespace)
 	//use 'endpoint' for the header to align with kfserving - https://github.com/kubeflow/kfserving/pull/699/files#r385360114
-	event.SetExtension(EndpointAttr, w.PredictorName)
+	event.SetExtension(EndpointAttr, s.config.PredictorName)
 
 	event.SetSource(logReq.SourceUri.String())
 	event.SetDataContentType(logReq.ContentType)
-	if err := event.SetData(data); err != nil {
+	// Compressed payloads, such as gzipped Triton responses, are sent as they are with their encoding
+	if logReq.ContentEncoding != "" {
+		event.SetExtension(ContentEncodingAttr, logReq.ContentEncoding)
+	}
+	if err := event.SetData(*logReq.Bytes); err != nil {
 		return fmt.Errorf("while setting cloudevents data: %s", err)
 	}
 
 	//fmt.Printf("%+v\n", event)
 
-	if _, _, err := c.Send(w.CeCtx, event); err != nil {
+	ctx := s.ceCtx
+	for name, values := range logReq.Headers {
+		for _, value := range values {
+			ctx = cehttp.ContextWithHeader(ctx, name, value)
+		}
+	}
+	if _, _, err := c.Send(ctx, event); err != nil {
 		return fmt.Errorf("while sending event: %s", err)
 	}
 	return nil
 }
 
+func (w *Worker) getSink(work LogRequest) (Sink, error) {
+	return getSink(work.Url, SinkConfig{
+		Log:           w.Log,
+		SdepName:      w.SdepName,
+		Namespace:     w.Namespace,
+		PredictorName: w.PredictorName,
+	})
+}
+
+// send sends a log to Kafka if a broker is configured, otherwise to the sink for the log URL. It returns once the
+// log is sent, or for Kafka once the broker has acknowledged it.
+func (w *Worker) send(work LogRequest) error {
+	if w.KafkaTopic != "" {
+		if err := w.sendKafkaEvent(work); err != nil {
+			w.Log.Error(err, "Failed to send kafka log", "Topic", w.KafkaTopic)
+			return err
+		}
+		return nil
+	}
+	sink, err := w.getSink(work)
+	if err == nil {
+		err = sink.Send(work)
+	}
+	if err != nil {
+		w.Log.Error(err, "Failed to send log", "URL", work.Url.Redacted())
+	}
+	return err
+}
+
+// handle sends a log from the work queue, spooling it if it fails to send. A queueing sink is handed the log
+// without waiting for it to be sent.
+func (w *Worker) handle(work LogRequest) {
+	if w.KafkaTopic == "" {
+		if sink, err := w.getSink(work); err == nil {
+			if qs, ok := sink.(QueueingSink); ok {
+				qs.Queue(work)
+				return
+			}
+		}
+	}
+	if err := w.send(work); err != nil {
+		if spool == nil {
+			loggerMetrics.DroppedCounter.WithLabelValues(DropReasonSendFailed).Inc()
+		} else if err := spool.Write(work); err != nil {
+			w.Log.Error(err, "Failed to spool log")
+		}
+	}
+}
+
 // This function "starts" the worker by starting a goroutine, that is
 // an infinite "for-select" loop.
 func (w *Worker) Start() {
@@ -203,16 +262,8 @@ func (w *Worker) Start() {
 			select {
 			case work := <-w.Work:
 				// Receive a work request.
-
-				if w.KafkaTopic != "" {
-					if err := w.sendKafkaEvent(work); err != nil {
-						w.Log.Error(err, "Failed to send kafka log", "Topic", w.KafkaTopic)
-					}
-				} else {
-					if err := w.sendCloudEvent(work); err != nil {
-						w.Log.Error(err, "Failed to send cloudevent log", "URL", work.Url.String())
-					}
-				}
+				loggerMetrics.QueueDepthGauge.Set(float64(len(w.Work)))
+				w.handle(work)
 
 			case <-w.QuitChan:
 				// We have been asked to stop.
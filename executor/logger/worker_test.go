@@ -10,6 +10,7 @@ import (
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 const (
@@ -258,3 +259,34 @@ func TestWorkerKafkaConfigurationsString(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkerSpoolsUndeliveredKafkaLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+
+	s, err := NewSpool(dir, 1<<20, 1<<20)
+	g.Expect(err).To(BeNil())
+	spool = s
+	defer func() { spool = nil }()
+
+	// No broker listens on the port, so the log fails to deliver once the message times out
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers":   "localhost:1",
+		"go.delivery.reports": true,
+		"message.timeout.ms":  100,
+	})
+	g.Expect(err).To(BeNil())
+	defer producer.Close()
+	w := &Worker{
+		Log:        logf.Log.WithName("WorkerTest"),
+		SdepName:   "mydep",
+		Namespace:  "default",
+		KafkaTopic: "logs",
+		Producer:   producer,
+	}
+
+	w.handle(createSpoolLogRequest("1"))
+	req, ok := spool.next()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(req.Id).To(Equal("1"))
+}
@@ -0,0 +1,359 @@
+package predictor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// averagePayloads returns the element-wise mean of the outputs of children responses. SeldonMessage
+// ndarray and tensor data as well as V2 (kfserving) outputs are supported in both JSON and proto form.
+func averagePayloads(msgs []payload.SeldonPayload) (payload.SeldonPayload, error) {
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("no payloads to average")
+	}
+	for _, msg := range msgs {
+		if msg.GetContentEncoding() != "" {
+			return nil, fmt.Errorf("unable to average payloads with content encoding %s", msg.GetContentEncoding())
+		}
+	}
+	if msgs[0].GetContentType() == payload.APPLICATION_TYPE_PROTOBUF {
+		switch msgs[0].GetPayload().(type) {
+		case *proto.SeldonMessage:
+			sms := make([]*proto.SeldonMessage, len(msgs))
+			for i, msg := range msgs {
+				sm, ok := msg.GetPayload().(*proto.SeldonMessage)
+				if !ok {
+					return nil, fmt.Errorf("unable to average mixed payload types")
+				}
+				sms[i] = sm
+			}
+			sm, err := averageSeldonMessages(sms)
+			if err != nil {
+				return nil, err
+			}
+			return &payload.ProtoPayload{Msg: sm}, nil
+		case *inference.ModelInferResponse:
+			resps := make([]*inference.ModelInferResponse, len(msgs))
+			for i, msg := range msgs {
+				resp, ok := msg.GetPayload().(*inference.ModelInferResponse)
+				if !ok {
+					return nil, fmt.Errorf("unable to average mixed payload types")
+				}
+				resps[i] = resp
+			}
+			resp, err := averageInferResponses(resps)
+			if err != nil {
+				return nil, err
+			}
+			return &payload.ProtoPayload{Msg: resp}, nil
+		default:
+			return nil, fmt.Errorf("unable to average payload of type %T", msgs[0].GetPayload())
+		}
+	}
+	return averageJsonPayloads(msgs)
+}
+
+func averageJsonPayloads(msgs []payload.SeldonPayload) (payload.SeldonPayload, error) {
+	docs := make([]map[string]interface{}, len(msgs))
+	for i, msg := range msgs {
+		b, err := msg.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &docs[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, isV2 := docs[0]["outputs"]; isV2 {
+		out, err := averageV2Json(docs)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		return &payload.BytesPayload{Msg: b, ContentType: msgs[0].GetContentType()}, nil
+	}
+
+	sms := make([]*proto.SeldonMessage, len(msgs))
+	for i, msg := range msgs {
+		b, _ := msg.GetBytes()
+		sms[i] = &proto.SeldonMessage{}
+		if err := jsonpb.UnmarshalString(string(b), sms[i]); err != nil {
+			return nil, err
+		}
+	}
+	sm, err := averageSeldonMessages(sms)
+	if err != nil {
+		return nil, err
+	}
+	ma := jsonpb.Marshaler{}
+	s, err := ma.MarshalToString(sm)
+	if err != nil {
+		return nil, err
+	}
+	return &payload.BytesPayload{Msg: []byte(s), ContentType: msgs[0].GetContentType()}, nil
+}
+
+func averageSeldonMessages(sms []*proto.SeldonMessage) (*proto.SeldonMessage, error) {
+	first := sms[0].GetData()
+	if first == nil {
+		return nil, fmt.Errorf("unable to average SeldonMessage without data")
+	}
+	data := &proto.DefaultData{Names: first.GetNames()}
+	switch first.DataOneof.(type) {
+	case *proto.DefaultData_Ndarray:
+		values := make([]*structpb.Value, len(sms))
+		for i, sm := range sms {
+			ndarray := sm.GetData().GetNdarray()
+			if ndarray == nil {
+				return nil, fmt.Errorf("unable to average ndarray with other data types")
+			}
+			values[i] = structpb.NewListValue(ndarray)
+		}
+		avg, err := averageValues(values)
+		if err != nil {
+			return nil, err
+		}
+		data.DataOneof = &proto.DefaultData_Ndarray{Ndarray: avg.GetListValue()}
+	case *proto.DefaultData_Tensor:
+		shape := first.GetTensor().GetShape()
+		values := make([][]float64, len(sms))
+		for i, sm := range sms {
+			tensor := sm.GetData().GetTensor()
+			if tensor == nil {
+				return nil, fmt.Errorf("unable to average tensor with other data types")
+			}
+			if !equalShapes(shape, tensor.GetShape()) {
+				return nil, fmt.Errorf("unable to average tensors with shapes %v and %v", shape, tensor.GetShape())
+			}
+			values[i] = tensor.GetValues()
+		}
+		avg, err := averageFloats(values)
+		if err != nil {
+			return nil, err
+		}
+		data.DataOneof = &proto.DefaultData_Tensor{Tensor: &proto.Tensor{Shape: shape, Values: avg}}
+	default:
+		return nil, fmt.Errorf("unable to average SeldonMessage data of type %T", first.DataOneof)
+	}
+	return &proto.SeldonMessage{Meta: sms[0].GetMeta(), DataOneof: &proto.SeldonMessage_Data{Data: data}}, nil
+}
+
+// averageValues averages nested lists of numbers which must all have the same structure.
+func averageValues(values []*structpb.Value) (*structpb.Value, error) {
+	switch values[0].GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		sum := 0.0
+		for _, v := range values {
+			n, ok := v.GetKind().(*structpb.Value_NumberValue)
+			if !ok {
+				return nil, fmt.Errorf("unable to average payloads with different shapes")
+			}
+			sum += n.NumberValue
+		}
+		return structpb.NewNumberValue(sum / float64(len(values))), nil
+	case *structpb.Value_ListValue:
+		size := len(values[0].GetListValue().GetValues())
+		for _, v := range values {
+			if v.GetListValue() == nil || len(v.GetListValue().GetValues()) != size {
+				return nil, fmt.Errorf("unable to average payloads with different shapes")
+			}
+		}
+		avg := &structpb.ListValue{Values: make([]*structpb.Value, size)}
+		for i := 0; i < size; i++ {
+			elems := make([]*structpb.Value, len(values))
+			for j, v := range values {
+				elems[j] = v.GetListValue().GetValues()[i]
+			}
+			elem, err := averageValues(elems)
+			if err != nil {
+				return nil, err
+			}
+			avg.Values[i] = elem
+		}
+		return structpb.NewListValue(avg), nil
+	default:
+		return nil, fmt.Errorf("unable to average non numeric value %v", values[0])
+	}
+}
+
+func averageFloats(values [][]float64) ([]float64, error) {
+	avg := make([]float64, len(values[0]))
+	for _, vals := range values {
+		if len(vals) != len(avg) {
+			return nil, fmt.Errorf("unable to average payloads with different sizes")
+		}
+		for i, v := range vals {
+			avg[i] += v
+		}
+	}
+	for i := range avg {
+		avg[i] /= float64(len(values))
+	}
+	return avg, nil
+}
+
+func equalShapes(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func averageV2Json(docs []map[string]interface{}) (map[string]interface{}, error) {
+	outputs, ok := docs[0]["outputs"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to average V2 response without outputs list")
+	}
+	avgOutputs := make([]interface{}, len(outputs))
+	for i, o := range outputs {
+		output, ok := o.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid V2 output %v", o)
+		}
+		datas := make([]*structpb.Value, len(docs))
+		for j, doc := range docs {
+			other, err := findV2JsonOutput(doc, output["name"], i)
+			if err != nil {
+				return nil, err
+			}
+			datas[j], err = structpb.NewValue(other["data"])
+			if err != nil {
+				return nil, err
+			}
+		}
+		avg, err := averageValues(datas)
+		if err != nil {
+			return nil, err
+		}
+		avgOutput := make(map[string]interface{}, len(output))
+		for k, v := range output {
+			avgOutput[k] = v
+		}
+		avgOutput["data"] = avg.AsInterface()
+		if datatype, ok := output["datatype"]; ok && datatype != "FP32" {
+			avgOutput["datatype"] = "FP64"
+		}
+		avgOutputs[i] = avgOutput
+	}
+	avgDoc := make(map[string]interface{}, len(docs[0]))
+	for k, v := range docs[0] {
+		avgDoc[k] = v
+	}
+	avgDoc["outputs"] = avgOutputs
+	return avgDoc, nil
+}
+
+// findV2JsonOutput finds the output matching the given name or, for unnamed outputs, position.
+func findV2JsonOutput(doc map[string]interface{}, name interface{}, idx int) (map[string]interface{}, error) {
+	outputs, _ := doc["outputs"].([]interface{})
+	if name != nil {
+		for _, o := range outputs {
+			if output, ok := o.(map[string]interface{}); ok && output["name"] == name {
+				return output, nil
+			}
+		}
+		return nil, fmt.Errorf("V2 output %v missing from response", name)
+	}
+	if idx < len(outputs) {
+		if output, ok := outputs[idx].(map[string]interface{}); ok {
+			return output, nil
+		}
+	}
+	return nil, fmt.Errorf("V2 output %d missing from response", idx)
+}
+
+func averageInferResponses(resps []*inference.ModelInferResponse) (*inference.ModelInferResponse, error) {
+	first := resps[0]
+	if len(first.GetRawOutputContents()) > 0 {
+		return nil, fmt.Errorf("unable to average V2 responses with raw output contents")
+	}
+	avg := &inference.ModelInferResponse{
+		ModelName:    first.GetModelName(),
+		ModelVersion: first.GetModelVersion(),
+		Id:           first.GetId(),
+		Parameters:   first.GetParameters(),
+		Outputs:      make([]*inference.ModelInferResponse_InferOutputTensor, len(first.GetOutputs())),
+	}
+	for i, output := range first.GetOutputs() {
+		values := make([][]float64, len(resps))
+		for j, resp := range resps {
+			other := findInferOutput(resp, output.GetName(), i)
+			if other == nil {
+				return nil, fmt.Errorf("V2 output %s missing from response", output.GetName())
+			}
+			values[j] = inferContentsAsFloats(other.GetContents())
+		}
+		avgValues, err := averageFloats(values)
+		if err != nil {
+			return nil, err
+		}
+		avgOutput := &inference.ModelInferResponse_InferOutputTensor{
+			Name:       output.GetName(),
+			Shape:      output.GetShape(),
+			Parameters: output.GetParameters(),
+		}
+		if output.GetDatatype() == "FP32" {
+			fp32 := make([]float32, len(avgValues))
+			for k, v := range avgValues {
+				fp32[k] = float32(v)
+			}
+			avgOutput.Datatype = "FP32"
+			avgOutput.Contents = &inference.InferTensorContents{Fp32Contents: fp32}
+		} else {
+			avgOutput.Datatype = "FP64"
+			avgOutput.Contents = &inference.InferTensorContents{Fp64Contents: avgValues}
+		}
+		avg.Outputs[i] = avgOutput
+	}
+	return avg, nil
+}
+
+func findInferOutput(resp *inference.ModelInferResponse, name string, idx int) *inference.ModelInferResponse_InferOutputTensor {
+	for _, output := range resp.GetOutputs() {
+		if name != "" && output.GetName() == name {
+			return output
+		}
+	}
+	if name == "" && idx < len(resp.GetOutputs()) {
+		return resp.GetOutputs()[idx]
+	}
+	return nil
+}
+
+func inferContentsAsFloats(contents *inference.InferTensorContents) []float64 {
+	var values []float64
+	for _, v := range contents.GetFp64Contents() {
+		values = append(values, v)
+	}
+	for _, v := range contents.GetFp32Contents() {
+		values = append(values, float64(v))
+	}
+	for _, v := range contents.GetIntContents() {
+		values = append(values, float64(v))
+	}
+	for _, v := range contents.GetInt64Contents() {
+		values = append(values, float64(v))
+	}
+	for _, v := range contents.GetUintContents() {
+		values = append(values, float64(v))
+	}
+	for _, v := range contents.GetUint64Contents() {
+		values = append(values, float64(v))
+	}
+	return values
+}
@@ -0,0 +1,109 @@
+package predictor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+func createSeldonMessagePayload(g *GomegaWithT, data string) payload.SeldonPayload {
+	var sm proto.SeldonMessage
+	err := jsonpb.UnmarshalString(data, &sm)
+	g.Expect(err).Should(BeNil())
+	return &payload.ProtoPayload{Msg: &sm}
+}
+
+func TestAverageSeldonMessageNdarray(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msgs := []payload.SeldonPayload{
+		createSeldonMessagePayload(g, `{"data":{"names":["a","b"],"ndarray":[[1.0,2.0],[3.0,4.0]]}}`),
+		createSeldonMessagePayload(g, `{"data":{"names":["a","b"],"ndarray":[[3.0,4.0],[5.0,6.0]]}}`),
+	}
+
+	res, err := averagePayloads(msgs)
+	g.Expect(err).Should(BeNil())
+	sm := res.GetPayload().(*proto.SeldonMessage)
+	g.Expect(sm.GetData().GetNames()).Should(Equal([]string{"a", "b"}))
+	values := sm.GetData().GetNdarray().GetValues()
+	g.Expect(values[0].GetListValue().GetValues()[0].GetNumberValue()).Should(Equal(2.0))
+	g.Expect(values[0].GetListValue().GetValues()[1].GetNumberValue()).Should(Equal(3.0))
+	g.Expect(values[1].GetListValue().GetValues()[0].GetNumberValue()).Should(Equal(4.0))
+	g.Expect(values[1].GetListValue().GetValues()[1].GetNumberValue()).Should(Equal(5.0))
+}
+
+func TestAverageSeldonMessageTensorJson(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msgs := []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"data":{"tensor":{"shape":[1,2],"values":[1.0,2.0]}}}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"data":{"tensor":{"shape":[1,2],"values":[2.0,4.0]}}}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"data":{"tensor":{"shape":[1,2],"values":[3.0,6.0]}}}`), ContentType: "application/json"},
+	}
+
+	res, err := averagePayloads(msgs)
+	g.Expect(err).Should(BeNil())
+	g.Expect(res.GetContentType()).Should(Equal("application/json"))
+	var sm proto.SeldonMessage
+	err = jsonpb.UnmarshalString(string(res.GetPayload().([]byte)), &sm)
+	g.Expect(err).Should(BeNil())
+	g.Expect(sm.GetData().GetTensor().GetShape()).Should(Equal([]int32{1, 2}))
+	g.Expect(sm.GetData().GetTensor().GetValues()).Should(Equal([]float64{2.0, 4.0}))
+}
+
+func TestAverageV2Json(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msgs := []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"model_name":"m1","outputs":[{"name":"predict","shape":[2],"datatype":"INT64","data":[1,2]}]}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"model_name":"m2","outputs":[{"name":"predict","shape":[2],"datatype":"INT64","data":[2,4]}]}`), ContentType: "application/json"},
+	}
+
+	res, err := averagePayloads(msgs)
+	g.Expect(err).Should(BeNil())
+	var resp map[string]interface{}
+	err = json.Unmarshal(res.GetPayload().([]byte), &resp)
+	g.Expect(err).Should(BeNil())
+	output := resp["outputs"].([]interface{})[0].(map[string]interface{})
+	g.Expect(output["name"]).Should(Equal("predict"))
+	g.Expect(output["datatype"]).Should(Equal("FP64"))
+	g.Expect(output["data"]).Should(Equal([]interface{}{1.5, 3.0}))
+}
+
+func TestAverageV2Proto(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msgs := []payload.SeldonPayload{
+		&payload.ProtoPayload{Msg: &inference.ModelInferResponse{
+			ModelName: "m1",
+			Outputs: []*inference.ModelInferResponse_InferOutputTensor{
+				{Name: "predict", Datatype: "FP32", Shape: []int64{2}, Contents: &inference.InferTensorContents{Fp32Contents: []float32{1, 2}}},
+			},
+		}},
+		&payload.ProtoPayload{Msg: &inference.ModelInferResponse{
+			ModelName: "m2",
+			Outputs: []*inference.ModelInferResponse_InferOutputTensor{
+				{Name: "predict", Datatype: "FP32", Shape: []int64{2}, Contents: &inference.InferTensorContents{Fp32Contents: []float32{3, 4}}},
+			},
+		}},
+	}
+
+	res, err := averagePayloads(msgs)
+	g.Expect(err).Should(BeNil())
+	resp := res.GetPayload().(*inference.ModelInferResponse)
+	g.Expect(resp.GetOutputs()[0].GetDatatype()).Should(Equal("FP32"))
+	g.Expect(resp.GetOutputs()[0].GetShape()).Should(Equal([]int64{2}))
+	g.Expect(resp.GetOutputs()[0].GetContents().GetFp32Contents()).Should(Equal([]float32{2, 3}))
+}
+
+func TestAverageDifferentShapes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msgs := []payload.SeldonPayload{
+		createSeldonMessagePayload(g, `{"data":{"ndarray":[1.0,2.0]}}`),
+		createSeldonMessagePayload(g, `{"data":{"ndarray":[1.0,2.0,3.0]}}`),
+	}
+
+	_, err := averagePayloads(msgs)
+	g.Expect(err).ShouldNot(BeNil())
+}
@@ -0,0 +1,307 @@
+package predictor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const (
+	DefaultBanditEpsilon = 0.1
+
+	// ENV_BANDIT_REDIS_URL is the Redis server, e.g. redis://:password@host:6379/0, that bandit routers keep their
+	// arm statistics in so they are shared between executor replicas.
+	ENV_BANDIT_REDIS_URL = "BANDIT_REDIS_URL"
+)
+
+// ArmStats holds the number of rewarded routings and the sum of their rewards for one child of a bandit router.
+type ArmStats struct {
+	Pulls   float64
+	Rewards float64
+}
+
+// BanditStore keeps the per-arm state of bandit routers. Implementations shared between executor
+// replicas let all replicas learn from feedback sent to any one of them.
+type BanditStore interface {
+	GetArms(ctx context.Context, key string, arms int) ([]ArmStats, error)
+	Update(ctx context.Context, key string, arm int, reward float64) error
+}
+
+type InMemoryBanditStore struct {
+	mu   sync.Mutex
+	arms map[string][]ArmStats
+}
+
+func NewInMemoryBanditStore() *InMemoryBanditStore {
+	return &InMemoryBanditStore{arms: make(map[string][]ArmStats)}
+}
+
+func (s *InMemoryBanditStore) getArms(key string, arms int) []ArmStats {
+	stats := s.arms[key]
+	if len(stats) < arms {
+		stats = append(stats, make([]ArmStats, arms-len(stats))...)
+		s.arms[key] = stats
+	}
+	return stats
+}
+
+func (s *InMemoryBanditStore) GetArms(ctx context.Context, key string, arms int) ([]ArmStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]ArmStats, arms)
+	copy(stats, s.getArms(key, arms))
+	return stats, nil
+}
+
+func (s *InMemoryBanditStore) Update(ctx context.Context, key string, arm int, reward float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.getArms(key, arm+1)
+	stats[arm].Pulls++
+	stats[arm].Rewards += reward
+	return nil
+}
+
+// RedisClient is the subset of a Redis client needed by RedisBanditStore.
+type RedisClient interface {
+	// HIncrByFloats increments the fields of a hash in a single transaction.
+	HIncrByFloats(ctx context.Context, key string, incrs map[string]float64) error
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+}
+
+type goRedisClient struct {
+	client redis.UniversalClient
+}
+
+func (c *goRedisClient) HIncrByFloats(ctx context.Context, key string, incrs map[string]float64) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for field, incr := range incrs {
+			pipe.HIncrByFloat(ctx, key, field, incr)
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *goRedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.client.HGetAll(ctx, key).Result()
+}
+
+// RedisBanditStore keeps arm state in a Redis hash per router with fields "<arm>:pulls" and "<arm>:rewards".
+type RedisBanditStore struct {
+	Client RedisClient
+	Prefix string
+}
+
+// NewRedisBanditStore returns a store keeping arm state in the Redis server at redisUrl, under keys starting with
+// prefix.
+func NewRedisBanditStore(redisUrl string, prefix string) (*RedisBanditStore, error) {
+	opts, err := redis.ParseURL(redisUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisBanditStore{Client: &goRedisClient{client: redis.NewClient(opts)}, Prefix: prefix}, nil
+}
+
+func (s *RedisBanditStore) GetArms(ctx context.Context, key string, arms int) ([]ArmStats, error) {
+	fields, err := s.Client.HGetAll(ctx, s.Prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]ArmStats, arms)
+	for i := range stats {
+		if v, ok := fields[fmt.Sprintf("%d:pulls", i)]; ok {
+			if stats[i].Pulls, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, err
+			}
+		}
+		if v, ok := fields[fmt.Sprintf("%d:rewards", i)]; ok {
+			if stats[i].Rewards, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return stats, nil
+}
+
+// Update counts the pull and its reward together so concurrent readers never see one without the other.
+func (s *RedisBanditStore) Update(ctx context.Context, key string, arm int, reward float64) error {
+	return s.Client.HIncrByFloats(ctx, s.Prefix+key, map[string]float64{
+		fmt.Sprintf("%d:pulls", arm):   1,
+		fmt.Sprintf("%d:rewards", arm): reward,
+	})
+}
+
+var (
+	banditStore      BanditStore = NewInMemoryBanditStore()
+	banditStoreMutex             = &sync.RWMutex{}
+)
+
+// SetBanditStore replaces the store used by all bandit routers, e.g. with a RedisBanditStore when running
+// several executor replicas.
+func SetBanditStore(store BanditStore) {
+	banditStoreMutex.Lock()
+	defer banditStoreMutex.Unlock()
+	banditStore = store
+}
+
+func getBanditStore() BanditStore {
+	banditStoreMutex.RLock()
+	defer banditStoreMutex.RUnlock()
+	return banditStore
+}
+
+func (p *PredictorProcess) banditRouter(node *v1.PredictiveUnit) (int, error) {
+	arms, err := getBanditStore().GetArms(p.Ctx, node.Name, len(node.Children))
+	if err != nil {
+		return 0, err
+	}
+	switch *node.Implementation {
+	case v1.EPSILON_GREEDY:
+		epsilon := DefaultBanditEpsilon
+		for _, param := range node.Parameters {
+			if param.Name == v1.BanditEpsilonParameter {
+				if epsilon, err = strconv.ParseFloat(param.Value, 64); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return epsilonGreedy(arms, epsilon), nil
+	case v1.THOMPSON_SAMPLING:
+		return thompsonSampling(arms), nil
+	case v1.UCB:
+		return ucb(arms), nil
+	}
+	return 0, fmt.Errorf("unknown bandit implementation %s", *node.Implementation)
+}
+
+// banditFeedback records the reward of a feedback request against the child that served the original request.
+func (p *PredictorProcess) banditFeedback(node *v1.PredictiveUnit, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	route, err := p.routeFeedback(node, msg)
+	if err != nil {
+		return nil, err
+	}
+	if route < 0 || route >= len(node.Children) {
+		p.Log.Info("Ignoring feedback without routing for bandit router", "node", node.Name, "route", route)
+		return msg, nil
+	}
+	reward, err := rewardFromFeedback(msg)
+	if err != nil {
+		return nil, err
+	}
+	if *node.Implementation == v1.THOMPSON_SAMPLING {
+		// The Beta posterior counts rewards as successes, so each must lie between failure and success
+		reward = math.Min(math.Max(reward, 0), 1)
+	}
+	if err := getBanditStore().Update(p.Ctx, node.Name, route, reward); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func rewardFromFeedback(msg payload.SeldonPayload) (float64, error) {
+	if fb, ok := msg.GetPayload().(*proto.Feedback); ok {
+		return float64(fb.GetReward()), nil
+	}
+	b, err := msg.GetBytes()
+	if err != nil {
+		return 0, err
+	}
+	var fb proto.Feedback
+	if err := jsonpb.UnmarshalString(string(b), &fb); err != nil {
+		return 0, err
+	}
+	return float64(fb.GetReward()), nil
+}
+
+func meanReward(arm ArmStats) float64 {
+	if arm.Pulls == 0 {
+		return 0
+	}
+	return arm.Rewards / arm.Pulls
+}
+
+func epsilonGreedy(arms []ArmStats, epsilon float64) int {
+	if rand.Float64() < epsilon {
+		return rand.Intn(len(arms))
+	}
+	best := 0
+	for i, arm := range arms {
+		if meanReward(arm) > meanReward(arms[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// thompsonSampling treats rewards as Bernoulli successes and samples from each arm's Beta posterior.
+func thompsonSampling(arms []ArmStats) int {
+	best := 0
+	bestSample := -1.0
+	for i, arm := range arms {
+		failures := math.Max(arm.Pulls-arm.Rewards, 0)
+		sample := sampleBeta(arm.Rewards+1, failures+1)
+		if sample > bestSample {
+			best = i
+			bestSample = sample
+		}
+	}
+	return best
+}
+
+// ucb implements UCB1, trying every arm once before choosing by upper confidence bound.
+func ucb(arms []ArmStats) int {
+	total := 0.0
+	for i, arm := range arms {
+		if arm.Pulls == 0 {
+			return i
+		}
+		total += arm.Pulls
+	}
+	best := 0
+	bestBound := math.Inf(-1)
+	for i, arm := range arms {
+		bound := meanReward(arm) + math.Sqrt(2*math.Log(total)/arm.Pulls)
+		if bound > bestBound {
+			best = i
+			bestBound = bound
+		}
+	}
+	return best
+}
+
+func sampleBeta(alpha, beta float64) float64 {
+	x := sampleGamma(alpha)
+	y := sampleGamma(beta)
+	return x / (x + y)
+}
+
+// sampleGamma uses the Marsaglia and Tsang method for shape >= 1.
+func sampleGamma(shape float64) float64 {
+	if shape < 1 {
+		return sampleGamma(shape+1) * math.Pow(rand.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rand.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rand.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package predictor
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+type mapRedisClient struct {
+	hashes map[string]map[string]string
+}
+
+func (c *mapRedisClient) HIncrByFloats(ctx context.Context, key string, incrs map[string]float64) error {
+	if c.hashes[key] == nil {
+		c.hashes[key] = make(map[string]string)
+	}
+	for field, incr := range incrs {
+		v, _ := strconv.ParseFloat(c.hashes[key][field], 64)
+		c.hashes[key][field] = strconv.FormatFloat(v+incr, 'f', -1, 64)
+	}
+	return nil
+}
+
+func (c *mapRedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.hashes[key], nil
+}
+
+func createBanditGraph(impl v1.PredictiveUnitImplementation) *v1.PredictiveUnit {
+	graph := createSimpleRouterGraph()
+	graph.Implementation = &impl
+	return graph
+}
+
+func TestBanditStores(t *testing.T) {
+	g := NewGomegaWithT(t)
+	for _, store := range []BanditStore{
+		NewInMemoryBanditStore(),
+		&RedisBanditStore{Client: &mapRedisClient{hashes: map[string]map[string]string{}}, Prefix: "seldon:"},
+	} {
+		ctx := context.Background()
+		g.Expect(store.Update(ctx, "router", 1, 1)).To(BeNil())
+		g.Expect(store.Update(ctx, "router", 1, 0)).To(BeNil())
+		arms, err := store.GetArms(ctx, "router", 3)
+		g.Expect(err).To(BeNil())
+		g.Expect(arms).To(Equal([]ArmStats{{}, {Pulls: 2, Rewards: 1}, {}}))
+	}
+}
+
+func TestEpsilonGreedy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	arms := []ArmStats{{Pulls: 10, Rewards: 2}, {Pulls: 10, Rewards: 8}, {Pulls: 10, Rewards: 5}}
+	g.Expect(epsilonGreedy(arms, 0)).To(Equal(1))
+}
+
+func TestUCB(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(ucb([]ArmStats{{Pulls: 10, Rewards: 8}, {}})).To(Equal(1))
+	g.Expect(ucb([]ArmStats{{Pulls: 100, Rewards: 80}, {Pulls: 100, Rewards: 20}})).To(Equal(0))
+}
+
+func TestThompsonSampling(t *testing.T) {
+	g := NewGomegaWithT(t)
+	arms := []ArmStats{{Pulls: 100, Rewards: 5}, {Pulls: 100, Rewards: 95}}
+	chosen := 0
+	for i := 0; i < 100; i++ {
+		chosen += thompsonSampling(arms)
+	}
+	g.Expect(chosen).To(BeNumerically(">", 95))
+}
+
+func TestBanditFeedbackUpdatesArm(t *testing.T) {
+	g := NewGomegaWithT(t)
+	store := NewInMemoryBanditStore()
+	SetBanditStore(store)
+	defer SetBanditStore(NewInMemoryBanditStore())
+
+	graph := createBanditGraph(v1.EPSILON_GREEDY)
+	var fb proto.Feedback
+	err := jsonpb.UnmarshalString(`{"request":{"data":{"ndarray":[1.1,2.0]}},"response":{"meta":{"routing":{"router":1}}},"reward":1}`, &fb)
+	g.Expect(err).To(BeNil())
+	_, err = createPredictorProcess(t).Feedback(graph, &payload.ProtoPayload{Msg: &fb})
+	g.Expect(err).To(BeNil())
+
+	arms, err := store.GetArms(context.Background(), "router", 2)
+	g.Expect(err).To(BeNil())
+	g.Expect(arms).To(Equal([]ArmStats{{}, {Pulls: 1, Rewards: 1}}))
+
+	graph.Parameters = []v1.Parameter{{Name: v1.BanditEpsilonParameter, Value: "0", Type: v1.FLOAT}}
+	pp := createPredictorProcess(t)
+	_, err = pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).To(BeNil())
+	g.Expect(pp.Routing["router"]).To(Equal(int32(1)))
+}
+
+func TestThompsonSamplingClampsRewards(t *testing.T) {
+	g := NewGomegaWithT(t)
+	store := NewInMemoryBanditStore()
+	SetBanditStore(store)
+	defer SetBanditStore(NewInMemoryBanditStore())
+
+	graph := createBanditGraph(v1.THOMPSON_SAMPLING)
+	for _, reward := range []string{"5", "-3", "0.5"} {
+		var fb proto.Feedback
+		err := jsonpb.UnmarshalString(`{"response":{"meta":{"routing":{"router":0}}},"reward":`+reward+`}`, &fb)
+		g.Expect(err).To(BeNil())
+		_, err = createPredictorProcess(t).Feedback(graph, &payload.ProtoPayload{Msg: &fb})
+		g.Expect(err).To(BeNil())
+	}
+
+	arms, err := store.GetArms(context.Background(), "router", 2)
+	g.Expect(err).To(BeNil())
+	g.Expect(arms).To(Equal([]ArmStats{{Pulls: 3, Rewards: 1.5}, {}}))
+	g.Expect(thompsonSampling(arms)).To(BeNumerically("<", 2))
+}
+
+func TestNewRedisBanditStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	store, err := NewRedisBanditStore("redis://localhost:6379/1", "seldon:bandit:")
+	g.Expect(err).To(BeNil())
+	g.Expect(store.Prefix).To(Equal("seldon:bandit:"))
+
+	_, err = NewRedisBanditStore("localhost:6379", "seldon:bandit:")
+	g.Expect(err).ToNot(BeNil())
+}
@@ -0,0 +1,832 @@
+package predictor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	protoV1 "github.com/golang/protobuf/proto"
+	guuid "github.com/google/uuid"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// SpanBatch is the name of the span of a batched call, which is linked to the spans of the batch's requests.
+const SpanBatch = "batch"
+
+// batchPayload is a request or response decoded into a form that can be joined with others, or split,
+// along its first dimension. One of a SeldonMessage, a V2 JSON document or a V2 gRPC request or response is set.
+type batchPayload struct {
+	sm          *proto.SeldonMessage
+	v2          map[string]interface{}
+	inferReq    *inference.ModelInferRequest
+	inferRes    *inference.ModelInferResponse
+	contentType string
+}
+
+// batchRequestHeaders identify a single request rather than describe it, so requests which only differ in these
+// headers are batched together.
+var batchRequestHeaders = map[string]bool{
+	strings.ToLower(payload.SeldonPUIDHeader): true,
+	"traceparent":       true,
+	"tracestate":        true,
+	"uber-trace-id":     true,
+	"x-request-id":      true,
+	"x-b3-traceid":      true,
+	"x-b3-spanid":       true,
+	"x-b3-parentspanid": true,
+	"x-b3-sampled":      true,
+	"content-length":    true,
+}
+
+type batchResult struct {
+	msg payload.SeldonPayload
+	err error
+}
+
+// batchCall predicts a batch of the given number of requests within ctx, with the headers of the batch's requests
+// and a puid of its own.
+type batchCall func(ctx context.Context, meta map[string][]string, msg payload.SeldonPayload, requests int) (payload.SeldonPayload, error)
+
+type batchItem struct {
+	ctx  context.Context
+	req  *batchPayload
+	rows int
+	puid string
+	meta map[string][]string
+	call batchCall
+	done chan batchResult
+}
+
+type batcher struct {
+	mu      sync.Mutex
+	maxSize int
+	maxWait time.Duration
+	pending []*batchItem
+	rows    int
+	gen     int
+}
+
+var (
+	batchers      = make(map[string]*batcher)
+	batchersMutex = &sync.Mutex{}
+)
+
+// Requests are only batched with others of the same signature, so each batch can be joined safely.
+func getBatcher(node *v1.PredictiveUnit, signature string) *batcher {
+	batchersMutex.Lock()
+	defer batchersMutex.Unlock()
+	key := node.Name + "/" + signature
+	b, ok := batchers[key]
+	if !ok {
+		b = &batcher{
+			maxSize: int(node.Batching.MaxBatchSize),
+			maxWait: time.Duration(node.Batching.MaxWaitMs) * time.Millisecond,
+		}
+		batchers[key] = b
+	}
+	return b
+}
+
+// batchedPredict sends a prediction as part of a batch with concurrent requests to the same node.
+// Payloads that can't be batched are sent on their own.
+func (p *PredictorProcess) batchedPredict(node *v1.PredictiveUnit, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	req, err := decodeBatchPayload(msg)
+	if err != nil {
+		return p.predictNode(p.Ctx, node, modelName, msg, p.Meta.Meta, 1)
+	}
+	signature, rows, ok := req.signature(p.Meta.Meta)
+	if !ok {
+		return p.predictNode(p.Ctx, node, modelName, msg, p.Meta.Meta, 1)
+	}
+	puid, err := p.getPUIDHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	item := &batchItem{
+		ctx:  p.Ctx,
+		req:  req,
+		rows: rows,
+		puid: puid,
+		meta: p.Meta.Meta,
+		done: make(chan batchResult, 1),
+		call: func(ctx context.Context, meta map[string][]string, msg payload.SeldonPayload, requests int) (payload.SeldonPayload, error) {
+			return p.predictNode(ctx, node, modelName, msg, meta, requests)
+		},
+	}
+	b := getBatcher(node, signature)
+	b.add(item)
+
+	select {
+	case res := <-item.done:
+		return res.msg, res.err
+	case <-p.Ctx.Done():
+		b.remove(item)
+		return nil, p.Ctx.Err()
+	}
+}
+
+// predictNode calls a model to predict the given number of requests, applying the node's resilience settings
+// within the given context.
+func (p *PredictorProcess) predictNode(ctx context.Context, node *v1.PredictiveUnit, modelName string, msg payload.SeldonPayload, meta map[string][]string, requests int) (payload.SeldonPayload, error) {
+	start := time.Now()
+	np := *p
+	np.Ctx = ctx
+	var tmsg payload.SeldonPayload
+	err := np.callNode(node, func(ctx context.Context) error {
+		var err error
+		tmsg, err = p.Client.Predict(ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, meta)
+		return err
+	})
+	recordPrediction(node, start, requests, err)
+	return tmsg, err
+}
+
+// PredictBatch predicts the requests with as few calls as possible. Requests with the same signature, including
+// their headers, are joined along their first dimension into one request whose response is split back into a
+// response for each, with the request's puid. Requests that can't be batched are predicted on their own with their
+// own headers. It returns the response and error of each request.
+func PredictBatch(msgs []payload.SeldonPayload, metas []map[string][]string, predict func(meta map[string][]string, msg payload.SeldonPayload) (payload.SeldonPayload, error)) ([]payload.SeldonPayload, []error) {
+	responses := make([]payload.SeldonPayload, len(msgs))
+	errs := make([]error, len(msgs))
+	groups := make(map[string][]*batchItem)
+	indexes := make(map[string][]int)
+	var signatures []string
+	call := func(ctx context.Context, meta map[string][]string, msg payload.SeldonPayload, requests int) (payload.SeldonPayload, error) {
+		return predict(meta, msg)
+	}
+	for i, msg := range msgs {
+		req, err := decodeBatchPayload(msg)
+		if err != nil {
+			responses[i], errs[i] = predict(metas[i], msg)
+			continue
+		}
+		signature, rows, ok := req.signature(metas[i])
+		if !ok {
+			responses[i], errs[i] = predict(metas[i], msg)
+			continue
+		}
+		if _, seen := groups[signature]; !seen {
+			signatures = append(signatures, signature)
+		}
+		var puid string
+		if puids := metas[i][payload.SeldonPUIDHeader]; len(puids) > 0 {
+			puid = puids[0]
+		}
+		groups[signature] = append(groups[signature], &batchItem{ctx: context.Background(), req: req, rows: rows, puid: puid, meta: metas[i], call: call})
+		indexes[signature] = append(indexes[signature], i)
+	}
+	for _, signature := range signatures {
+		results := (&batcher{}).run(groups[signature])
+		for j, i := range indexes[signature] {
+			responses[i], errs[i] = results[j].msg, results[j].err
+		}
+	}
+	return responses, errs
+}
+
+func (b *batcher) add(item *batchItem) {
+	b.mu.Lock()
+	var full []*batchItem
+	if len(b.pending) > 0 && b.rows+item.rows > b.maxSize {
+		full = b.take()
+	}
+	b.pending = append(b.pending, item)
+	b.rows += item.rows
+	if b.rows >= b.maxSize {
+		ready := b.take()
+		b.mu.Unlock()
+		if full != nil {
+			go b.flush(full)
+		}
+		go b.flush(ready)
+		return
+	}
+	if len(b.pending) == 1 {
+		gen := b.gen
+		time.AfterFunc(b.maxWait, func() {
+			b.mu.Lock()
+			if b.gen != gen || len(b.pending) == 0 {
+				b.mu.Unlock()
+				return
+			}
+			items := b.take()
+			b.mu.Unlock()
+			b.flush(items)
+		})
+	}
+	b.mu.Unlock()
+	if full != nil {
+		go b.flush(full)
+	}
+}
+
+// remove drops the request of a caller that went away from the pending batch, so its rows neither count towards
+// nor are sent in the next batch.
+func (b *batcher) remove(item *batchItem) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, pending := range b.pending {
+		if pending == item {
+			b.pending = append(b.pending[:i:i], b.pending[i+1:]...)
+			b.rows -= item.rows
+			return
+		}
+	}
+}
+
+// take must be called holding the lock.
+func (b *batcher) take() []*batchItem {
+	items := b.pending
+	b.pending = nil
+	b.rows = 0
+	b.gen++
+	return items
+}
+
+func (b *batcher) flush(items []*batchItem) {
+	results := b.run(items)
+	for i, item := range items {
+		item.done <- results[i]
+	}
+}
+
+// batchContext returns the context of a batch's call, which is cancelled once every caller in the batch has gone
+// away. The batch has a puid of its own as it is a request of its own, and a span of its own linked to the span of
+// each caller so the call can be found from the trace of any request in the batch. The returned function cancels the
+// context and ends the span.
+func batchContext(items []*batchItem, puid string) (context.Context, func()) {
+	var links []trace.Link
+	for _, item := range items {
+		if link := trace.LinkFromContext(item.ctx); link.SpanContext.IsValid() {
+			links = append(links, link)
+		}
+	}
+	ctx, span := otel.Tracer(tracing.TracerName).Start(context.Background(), SpanBatch,
+		trace.WithNewRoot(), trace.WithLinks(links...), trace.WithAttributes(attribute.Int(AttributeBatchSize, len(items))))
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, payload.SeldonPUIDHeader, puid))
+	go func() {
+		for _, item := range items {
+			select {
+			case <-item.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+	return ctx, func() {
+		cancel()
+		span.End()
+	}
+}
+
+func (b *batcher) run(all []*batchItem) []batchResult {
+	results := make([]batchResult, len(all))
+	// Callers that went away since their request was queued are left out of the batch
+	var items []*batchItem
+	var indexes []int
+	for i, item := range all {
+		if err := item.ctx.Err(); err != nil {
+			results[i] = batchResult{err: err}
+		} else {
+			items = append(items, item)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(items) == 0 {
+		return results
+	}
+	fail := func(err error) []batchResult {
+		for _, i := range indexes {
+			results[i] = batchResult{err: err}
+		}
+		return results
+	}
+
+	reqs := make([]*batchPayload, len(items))
+	rows := make([]int, len(items))
+	for i, item := range items {
+		reqs[i] = item.req
+		rows[i] = item.rows
+	}
+	joined, err := joinBatch(reqs)
+	if err != nil {
+		return fail(err)
+	}
+	msg, err := joined.encode()
+	if err != nil {
+		return fail(err)
+	}
+	// All requests of a batch have the same headers but for those identifying the request
+	batchPuid := guuid.New().String()
+	meta := payload.NewFromMap(items[0].meta).Meta
+	for name := range meta {
+		if batchRequestHeaders[strings.ToLower(name)] {
+			delete(meta, name)
+		}
+	}
+	meta[payload.SeldonPUIDHeader] = []string{batchPuid}
+	ctx, cancel := batchContext(items, batchPuid)
+	defer cancel()
+	res, err := items[0].call(ctx, meta, msg, len(items))
+	if err != nil {
+		// Error payloads can't be split so every caller gets the same response
+		for _, i := range indexes {
+			results[i] = batchResult{msg: res, err: err}
+		}
+		return results
+	}
+	resBatch, err := decodeBatchPayload(res)
+	if err != nil {
+		return fail(err)
+	}
+	parts, err := resBatch.split(rows)
+	if err != nil {
+		return fail(err)
+	}
+	for j, part := range parts {
+		part.setId(items[j].puid, reqs[j])
+		i := indexes[j]
+		results[i].msg, results[i].err = part.encode()
+	}
+	return results
+}
+
+func decodeBatchPayload(msg payload.SeldonPayload) (*batchPayload, error) {
+	if msg.GetContentEncoding() != "" {
+		return nil, fmt.Errorf("unable to batch payloads with content encoding %s", msg.GetContentEncoding())
+	}
+	if msg.GetContentType() == payload.APPLICATION_TYPE_PROTOBUF {
+		switch m := msg.GetPayload().(type) {
+		case *proto.SeldonMessage:
+			return &batchPayload{sm: m, contentType: msg.GetContentType()}, nil
+		case *inference.ModelInferRequest:
+			return &batchPayload{inferReq: m, contentType: msg.GetContentType()}, nil
+		case *inference.ModelInferResponse:
+			return &batchPayload{inferRes: m, contentType: msg.GetContentType()}, nil
+		default:
+			return nil, fmt.Errorf("unable to batch payload of type %T", msg.GetPayload())
+		}
+	}
+	b, err := msg.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if _, ok := doc["inputs"]; ok {
+		return &batchPayload{v2: doc, contentType: msg.GetContentType()}, nil
+	}
+	if _, ok := doc["outputs"]; ok {
+		return &batchPayload{v2: doc, contentType: msg.GetContentType()}, nil
+	}
+	var sm proto.SeldonMessage
+	if err := jsonpb.UnmarshalString(string(b), &sm); err != nil {
+		return nil, err
+	}
+	return &batchPayload{sm: &sm, contentType: msg.GetContentType()}, nil
+}
+
+func (b *batchPayload) encode() (payload.SeldonPayload, error) {
+	if b.inferReq != nil {
+		return &payload.ProtoPayload{Msg: b.inferReq}, nil
+	}
+	if b.inferRes != nil {
+		return &payload.ProtoPayload{Msg: b.inferRes}, nil
+	}
+	if b.sm != nil {
+		if b.contentType == payload.APPLICATION_TYPE_PROTOBUF {
+			return &payload.ProtoPayload{Msg: b.sm}, nil
+		}
+		ma := jsonpb.Marshaler{}
+		s, err := ma.MarshalToString(b.sm)
+		if err != nil {
+			return nil, err
+		}
+		return &payload.BytesPayload{Msg: []byte(s), ContentType: b.contentType}, nil
+	}
+	data, err := json.Marshal(b.v2)
+	if err != nil {
+		return nil, err
+	}
+	return &payload.BytesPayload{Msg: data, ContentType: b.contentType}, nil
+}
+
+// signature describes everything but the first dimension of a request, including the request's meta and headers
+// so every request of a batch can be sent with those of the first. ok is false for requests which can't be
+// batched, such as those without an explicit row dimension.
+func (b *batchPayload) signature(headers map[string][]string) (signature string, rows int, ok bool) {
+	parts := []string{b.contentType, headerSignature(headers)}
+	if b.sm != nil {
+		if b.sm.GetMeta() != nil {
+			meta := protoV1.Clone(b.sm.GetMeta()).(*proto.Meta)
+			meta.Puid = ""
+			ma := jsonpb.Marshaler{}
+			s, err := ma.MarshalToString(meta)
+			if err != nil {
+				return "", 0, false
+			}
+			parts = append(parts, s)
+		}
+		data := b.sm.GetData()
+		names := strings.Join(data.GetNames(), ",")
+		switch data.GetDataOneof().(type) {
+		case *proto.DefaultData_Ndarray:
+			values := data.GetNdarray().GetValues()
+			if len(values) == 0 || values[0].GetListValue() == nil {
+				return "", 0, false
+			}
+			parts = append(parts, "seldon/ndarray", names, fmt.Sprintf("%v", valueShape(values[0])))
+			return strings.Join(parts, "/"), len(values), true
+		case *proto.DefaultData_Tensor:
+			shape := data.GetTensor().GetShape()
+			if len(shape) < 2 || shape[0] <= 0 {
+				return "", 0, false
+			}
+			parts = append(parts, "seldon/tensor", names, fmt.Sprintf("%v", shape[1:]))
+			return strings.Join(parts, "/"), int(shape[0]), true
+		}
+		return "", 0, false
+	}
+	if b.inferReq != nil {
+		return b.inferSignature(parts)
+	}
+
+	inputs, ok := b.v2["inputs"].([]interface{})
+	if !ok || len(inputs) == 0 {
+		return "", 0, false
+	}
+	parts = append(parts, "v2")
+	for _, in := range inputs {
+		input, ok := in.(map[string]interface{})
+		if !ok {
+			return "", 0, false
+		}
+		shape, ok := v2Shape(input)
+		if !ok || len(shape) < 2 || (rows > 0 && shape[0] != rows) || shape[0] <= 0 {
+			return "", 0, false
+		}
+		rows = shape[0]
+		parts = append(parts, fmt.Sprintf("%v/%v/%v", input["name"], input["datatype"], shape[1:]))
+	}
+	for _, key := range []string{"outputs", "parameters"} {
+		if v, ok := b.v2[key]; ok {
+			encoded, _ := json.Marshal(v)
+			parts = append(parts, key, string(encoded))
+		}
+	}
+	return strings.Join(parts, "/"), rows, true
+}
+
+// inferSignature describes a V2 gRPC request by everything but its id and the contents and first dimension of its
+// inputs.
+func (b *batchPayload) inferSignature(parts []string) (signature string, rows int, ok bool) {
+	if len(b.inferReq.GetInputs()) == 0 {
+		return "", 0, false
+	}
+	req := protoV1.Clone(b.inferReq).(*inference.ModelInferRequest)
+	req.Id = ""
+	for _, input := range req.GetInputs() {
+		shape := input.GetShape()
+		if len(shape) < 2 || shape[0] <= 0 || (rows > 0 && int(shape[0]) != rows) || input.GetContents() == nil {
+			return "", 0, false
+		}
+		rows = int(shape[0])
+		input.Shape = shape[1:]
+		input.Contents = nil
+	}
+	ma := jsonpb.Marshaler{}
+	s, err := ma.MarshalToString(req)
+	if err != nil {
+		return "", 0, false
+	}
+	parts = append(parts, "v2grpc", s)
+	return strings.Join(parts, "/"), rows, true
+}
+
+// headerSignature describes the headers of a request but for those identifying it.
+func headerSignature(headers map[string][]string) string {
+	var names []string
+	for name := range headers {
+		if !batchRequestHeaders[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s=%q;", strings.ToLower(name), headers[name])
+	}
+	return sb.String()
+}
+
+func valueShape(v *structpb.Value) []int {
+	var shape []int
+	for v.GetListValue() != nil {
+		values := v.GetListValue().GetValues()
+		shape = append(shape, len(values))
+		if len(values) == 0 {
+			break
+		}
+		v = values[0]
+	}
+	return shape
+}
+
+func v2Shape(tensor map[string]interface{}) ([]int, bool) {
+	raw, ok := tensor["shape"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	shape := make([]int, len(raw))
+	for i, d := range raw {
+		f, ok := d.(float64)
+		if !ok {
+			return nil, false
+		}
+		shape[i] = int(f)
+	}
+	return shape, true
+}
+
+func joinBatch(reqs []*batchPayload) (*batchPayload, error) {
+	first := reqs[0]
+	if first.sm != nil {
+		data := &proto.DefaultData{Names: first.sm.GetData().GetNames()}
+		switch first.sm.GetData().GetDataOneof().(type) {
+		case *proto.DefaultData_Ndarray:
+			var values []*structpb.Value
+			for _, req := range reqs {
+				values = append(values, req.sm.GetData().GetNdarray().GetValues()...)
+			}
+			data.DataOneof = &proto.DefaultData_Ndarray{Ndarray: &structpb.ListValue{Values: values}}
+		case *proto.DefaultData_Tensor:
+			shape := append([]int32{0}, first.sm.GetData().GetTensor().GetShape()[1:]...)
+			var values []float64
+			for _, req := range reqs {
+				shape[0] += req.sm.GetData().GetTensor().GetShape()[0]
+				values = append(values, req.sm.GetData().GetTensor().GetValues()...)
+			}
+			data.DataOneof = &proto.DefaultData_Tensor{Tensor: &proto.Tensor{Shape: shape, Values: values}}
+		}
+		var meta *proto.Meta
+		if first.sm.GetMeta() != nil {
+			meta = protoV1.Clone(first.sm.GetMeta()).(*proto.Meta)
+			meta.Puid = ""
+		}
+		return &batchPayload{
+			sm:          &proto.SeldonMessage{Meta: meta, DataOneof: &proto.SeldonMessage_Data{Data: data}},
+			contentType: first.contentType,
+		}, nil
+	}
+
+	if first.inferReq != nil {
+		return joinInferBatch(reqs), nil
+	}
+
+	doc := make(map[string]interface{}, len(first.v2))
+	for k, v := range first.v2 {
+		doc[k] = v
+	}
+	delete(doc, "id")
+	firstInputs := first.v2["inputs"].([]interface{})
+	inputs := make([]interface{}, len(firstInputs))
+	for i := range firstInputs {
+		input := make(map[string]interface{})
+		for k, v := range firstInputs[i].(map[string]interface{}) {
+			input[k] = v
+		}
+		shape, _ := v2Shape(input)
+		var data []interface{}
+		rows := 0
+		for _, req := range reqs {
+			reqInput := req.v2["inputs"].([]interface{})[i].(map[string]interface{})
+			reqShape, _ := v2Shape(reqInput)
+			reqData, ok := reqInput["data"].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unable to batch V2 input %v without data list", reqInput["name"])
+			}
+			data = append(data, reqData...)
+			rows += reqShape[0]
+		}
+		newShape := make([]interface{}, len(shape))
+		newShape[0] = float64(rows)
+		for j := 1; j < len(shape); j++ {
+			newShape[j] = float64(shape[j])
+		}
+		input["shape"] = newShape
+		input["data"] = data
+		inputs[i] = input
+	}
+	doc["inputs"] = inputs
+	return &batchPayload{v2: doc, contentType: first.contentType}, nil
+}
+
+// joinInferBatch joins the contents of each input of V2 gRPC requests with the same signature.
+func joinInferBatch(reqs []*batchPayload) *batchPayload {
+	joined := protoV1.Clone(reqs[0].inferReq).(*inference.ModelInferRequest)
+	joined.Id = ""
+	for i, input := range joined.GetInputs() {
+		input.Shape[0] = 0
+		input.Contents = &inference.InferTensorContents{}
+		for _, req := range reqs {
+			reqInput := req.inferReq.GetInputs()[i]
+			input.Shape[0] += reqInput.GetShape()[0]
+			appendContents(input.Contents, reqInput.GetContents())
+		}
+	}
+	return &batchPayload{inferReq: joined, contentType: reqs[0].contentType}
+}
+
+func appendContents(dst *inference.InferTensorContents, src *inference.InferTensorContents) {
+	dst.BoolContents = append(dst.BoolContents, src.GetBoolContents()...)
+	dst.IntContents = append(dst.IntContents, src.GetIntContents()...)
+	dst.Int64Contents = append(dst.Int64Contents, src.GetInt64Contents()...)
+	dst.UintContents = append(dst.UintContents, src.GetUintContents()...)
+	dst.Uint64Contents = append(dst.Uint64Contents, src.GetUint64Contents()...)
+	dst.Fp32Contents = append(dst.Fp32Contents, src.GetFp32Contents()...)
+	dst.Fp64Contents = append(dst.Fp64Contents, src.GetFp64Contents()...)
+	dst.ByteContents = append(dst.ByteContents, src.GetByteContents()...)
+}
+
+// sliceContents returns the rows from start to end of contents holding total rows. Only one of the contents lists
+// is set, so each is sliced by its own row size.
+func sliceContents(c *inference.InferTensorContents, total int, start int, end int) *inference.InferTensorContents {
+	from := func(n int) int { return start * n / total }
+	to := func(n int) int { return end * n / total }
+	return &inference.InferTensorContents{
+		BoolContents:   c.GetBoolContents()[from(len(c.GetBoolContents())):to(len(c.GetBoolContents()))],
+		IntContents:    c.GetIntContents()[from(len(c.GetIntContents())):to(len(c.GetIntContents()))],
+		Int64Contents:  c.GetInt64Contents()[from(len(c.GetInt64Contents())):to(len(c.GetInt64Contents()))],
+		UintContents:   c.GetUintContents()[from(len(c.GetUintContents())):to(len(c.GetUintContents()))],
+		Uint64Contents: c.GetUint64Contents()[from(len(c.GetUint64Contents())):to(len(c.GetUint64Contents()))],
+		Fp32Contents:   c.GetFp32Contents()[from(len(c.GetFp32Contents())):to(len(c.GetFp32Contents()))],
+		Fp64Contents:   c.GetFp64Contents()[from(len(c.GetFp64Contents())):to(len(c.GetFp64Contents()))],
+		ByteContents:   c.GetByteContents()[from(len(c.GetByteContents())):to(len(c.GetByteContents()))],
+	}
+}
+
+func contentsLen(c *inference.InferTensorContents) int {
+	return len(c.GetBoolContents()) + len(c.GetIntContents()) + len(c.GetInt64Contents()) + len(c.GetUintContents()) +
+		len(c.GetUint64Contents()) + len(c.GetFp32Contents()) + len(c.GetFp64Contents()) + len(c.GetByteContents())
+}
+
+// splitInfer divides a batched V2 gRPC response into one part per request with the given number of rows.
+func (b *batchPayload) splitInfer(rows []int, total int) ([]*batchPayload, error) {
+	parts := make([]*batchPayload, len(rows))
+	for i := range parts {
+		res := protoV1.Clone(b.inferRes).(*inference.ModelInferResponse)
+		res.Outputs = make([]*inference.ModelInferResponse_InferOutputTensor, len(b.inferRes.GetOutputs()))
+		parts[i] = &batchPayload{inferRes: res, contentType: b.contentType}
+	}
+	for o, output := range b.inferRes.GetOutputs() {
+		shape := output.GetShape()
+		if len(shape) == 0 || int(shape[0]) != total || contentsLen(output.GetContents())%total != 0 {
+			return nil, fmt.Errorf("batched V2 output %s does not match %d requested rows", output.GetName(), total)
+		}
+		start := 0
+		for i, r := range rows {
+			partOutput := protoV1.Clone(output).(*inference.ModelInferResponse_InferOutputTensor)
+			partOutput.Shape[0] = int64(r)
+			partOutput.Contents = sliceContents(output.GetContents(), total, start, start+r)
+			parts[i].inferRes.Outputs[o] = partOutput
+			start += r
+		}
+	}
+	return parts, nil
+}
+
+// split divides a batched response into one part per request with the given number of rows.
+func (b *batchPayload) split(rows []int) ([]*batchPayload, error) {
+	total := 0
+	for _, r := range rows {
+		total += r
+	}
+	if b.inferRes != nil {
+		return b.splitInfer(rows, total)
+	}
+	parts := make([]*batchPayload, len(rows))
+
+	if b.sm != nil {
+		data := b.sm.GetData()
+		for i := range parts {
+			sm := &proto.SeldonMessage{}
+			if b.sm.GetMeta() != nil {
+				sm.Meta = protoV1.Clone(b.sm.GetMeta()).(*proto.Meta)
+			}
+			parts[i] = &batchPayload{sm: sm, contentType: b.contentType}
+		}
+		switch data.GetDataOneof().(type) {
+		case *proto.DefaultData_Ndarray:
+			values := data.GetNdarray().GetValues()
+			if len(values) != total {
+				return nil, fmt.Errorf("batched response has %d rows but %d were requested", len(values), total)
+			}
+			start := 0
+			for i, r := range rows {
+				parts[i].sm.DataOneof = &proto.SeldonMessage_Data{Data: &proto.DefaultData{
+					Names:     data.GetNames(),
+					DataOneof: &proto.DefaultData_Ndarray{Ndarray: &structpb.ListValue{Values: values[start : start+r]}},
+				}}
+				start += r
+			}
+		case *proto.DefaultData_Tensor:
+			shape := data.GetTensor().GetShape()
+			values := data.GetTensor().GetValues()
+			if len(shape) == 0 || int(shape[0]) != total || len(values)%total != 0 {
+				return nil, fmt.Errorf("batched response tensor shape %v does not match %d requested rows", shape, total)
+			}
+			rowSize := len(values) / total
+			start := 0
+			for i, r := range rows {
+				partShape := append([]int32{int32(r)}, shape[1:]...)
+				parts[i].sm.DataOneof = &proto.SeldonMessage_Data{Data: &proto.DefaultData{
+					Names:     data.GetNames(),
+					DataOneof: &proto.DefaultData_Tensor{Tensor: &proto.Tensor{Shape: partShape, Values: values[start*rowSize : (start+r)*rowSize]}},
+				}}
+				start += r
+			}
+		default:
+			return nil, fmt.Errorf("unable to split batched response data of type %T", data.GetDataOneof())
+		}
+		return parts, nil
+	}
+
+	outputs, ok := b.v2["outputs"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to split V2 response without outputs")
+	}
+	for i := range parts {
+		doc := make(map[string]interface{}, len(b.v2))
+		for k, v := range b.v2 {
+			doc[k] = v
+		}
+		doc["outputs"] = make([]interface{}, len(outputs))
+		parts[i] = &batchPayload{v2: doc, contentType: b.contentType}
+	}
+	for o, out := range outputs {
+		output, ok := out.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid V2 output %v", out)
+		}
+		shape, ok := v2Shape(output)
+		data, dataOk := output["data"].([]interface{})
+		if !ok || !dataOk || len(shape) == 0 || shape[0] != total || len(data)%total != 0 {
+			return nil, fmt.Errorf("batched V2 output %v does not match %d requested rows", output["name"], total)
+		}
+		rowSize := len(data) / total
+		start := 0
+		for i, r := range rows {
+			partOutput := make(map[string]interface{}, len(output))
+			for k, v := range output {
+				partOutput[k] = v
+			}
+			partShape := make([]interface{}, len(shape))
+			partShape[0] = float64(r)
+			for j := 1; j < len(shape); j++ {
+				partShape[j] = float64(shape[j])
+			}
+			partOutput["shape"] = partShape
+			partOutput["data"] = data[start*rowSize : (start+r)*rowSize]
+			parts[i].v2["outputs"].([]interface{})[o] = partOutput
+			start += r
+		}
+	}
+	return parts, nil
+}
+
+// setId restores the identifiers of the original request on its part of a batched response.
+func (b *batchPayload) setId(puid string, req *batchPayload) {
+	if b.inferRes != nil {
+		b.inferRes.Id = req.inferReq.GetId()
+		return
+	}
+	if b.sm != nil {
+		if b.sm.Meta == nil {
+			b.sm.Meta = &proto.Meta{}
+		}
+		b.sm.Meta.Puid = puid
+		return
+	}
+	if id, ok := req.v2["id"]; ok {
+		b.v2["id"] = id
+	} else {
+		delete(b.v2, "id")
+	}
+}
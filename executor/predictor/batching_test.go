@@ -0,0 +1,354 @@
+package predictor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type batchRecordingTestClient struct {
+	test.SeldonMessageTestClient
+	mu    *sync.Mutex
+	calls *[]payload.SeldonPayload
+}
+
+func (c batchRecordingTestClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.calls = append(*c.calls, msg)
+	return msg, nil
+}
+
+func createBatchingModel(name string, maxBatchSize int32, maxWaitMs int32) *v1.PredictiveUnit {
+	node := createResilientModel(name)
+	node.Batching = &v1.BatchingPolicy{MaxBatchSize: maxBatchSize, MaxWaitMs: maxWaitMs}
+	return node
+}
+
+func predictConcurrently(t *testing.T, client batchRecordingTestClient, node *v1.PredictiveUnit, msgs []payload.SeldonPayload) ([]payload.SeldonPayload, []error) {
+	url, _ := url.Parse(testSourceUrl)
+	res := make([]payload.SeldonPayload, len(msgs))
+	errs := make([]error, len(msgs))
+	wg := sync.WaitGroup{}
+	for i, msg := range msgs {
+		wg.Add(1)
+		go func(i int, msg payload.SeldonPayload) {
+			defer wg.Done()
+			ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, fmt.Sprintf("puid-%d", i))
+			pp := NewPredictorProcess(ctx, client, logf.Log.WithName("SeldonMessageRestClient"), url, "default", map[string][]string{}, "")
+			res[i], errs[i] = pp.Predict(node, msg)
+		}(i, msg)
+	}
+	wg.Wait()
+	return res, errs
+}
+
+func TestBatchingNdarray(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls []payload.SeldonPayload
+	client := batchRecordingTestClient{mu: &sync.Mutex{}, calls: &calls}
+	node := createBatchingModel("batch-ndarray", 4, 10000)
+
+	var msgs []payload.SeldonPayload
+	for i := 0; i < 4; i++ {
+		var sm proto.SeldonMessage
+		err := jsonpb.UnmarshalString(fmt.Sprintf(`{"data":{"ndarray":[[%d.0, 1.0]]}}`, i), &sm)
+		g.Expect(err).Should(BeNil())
+		msgs = append(msgs, &payload.ProtoPayload{Msg: &sm})
+	}
+
+	res, errs := predictConcurrently(t, client, node, msgs)
+	g.Expect(calls).Should(HaveLen(1))
+	g.Expect(calls[0].GetPayload().(*proto.SeldonMessage).GetData().GetNdarray().GetValues()).Should(HaveLen(4))
+	for i := range msgs {
+		g.Expect(errs[i]).Should(BeNil())
+		sm := res[i].GetPayload().(*proto.SeldonMessage)
+		g.Expect(sm.GetMeta().GetPuid()).Should(Equal(fmt.Sprintf("puid-%d", i)))
+		values := sm.GetData().GetNdarray().GetValues()
+		g.Expect(values).Should(HaveLen(1))
+		g.Expect(values[0].GetListValue().GetValues()[0].GetNumberValue()).Should(Equal(float64(i)))
+	}
+}
+
+func TestBatchingTensorJsonMaxWait(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls []payload.SeldonPayload
+	client := batchRecordingTestClient{mu: &sync.Mutex{}, calls: &calls}
+	node := createBatchingModel("batch-tensor", 100, 50)
+
+	msgs := []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"data":{"tensor":{"shape":[1,2],"values":[1,2]}}}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"data":{"tensor":{"shape":[2,2],"values":[3,4,5,6]}}}`), ContentType: "application/json"},
+	}
+
+	res, errs := predictConcurrently(t, client, node, msgs)
+	g.Expect(calls).Should(HaveLen(1))
+	for i := range msgs {
+		g.Expect(errs[i]).Should(BeNil())
+	}
+	var sm proto.SeldonMessage
+	err := jsonpb.UnmarshalString(string(res[1].GetPayload().([]byte)), &sm)
+	g.Expect(err).Should(BeNil())
+	g.Expect(sm.GetData().GetTensor().GetShape()).Should(Equal([]int32{2, 2}))
+	g.Expect(sm.GetData().GetTensor().GetValues()).Should(Equal([]float64{3, 4, 5, 6}))
+	g.Expect(sm.GetMeta().GetPuid()).Should(Equal("puid-1"))
+}
+
+func TestBatchingV2(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	msgs := []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"id":"a","inputs":[{"name":"x","shape":[1,2],"datatype":"FP32","data":[1,2]}]}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"id":"b","inputs":[{"name":"x","shape":[2,2],"datatype":"FP32","data":[3,4,5,6]}]}`), ContentType: "application/json"},
+	}
+
+	reqs := make([]*batchPayload, len(msgs))
+	for i, msg := range msgs {
+		var err error
+		reqs[i], err = decodeBatchPayload(msg)
+		g.Expect(err).Should(BeNil())
+	}
+	joined, err := joinBatch(reqs)
+	g.Expect(err).Should(BeNil())
+	input := joined.v2["inputs"].([]interface{})[0].(map[string]interface{})
+	g.Expect(input["shape"]).Should(Equal([]interface{}{3.0, 2.0}))
+	g.Expect(input["data"]).Should(Equal([]interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}))
+
+	// The test client echoes requests so rename inputs to outputs for the split
+	joined.v2["outputs"] = joined.v2["inputs"]
+	delete(joined.v2, "inputs")
+	parts, err := joined.split([]int{1, 2})
+	g.Expect(err).Should(BeNil())
+	parts[1].setId("puid-1", reqs[1])
+	msg, err := parts[1].encode()
+	g.Expect(err).Should(BeNil())
+	var doc map[string]interface{}
+	g.Expect(json.Unmarshal(msg.GetPayload().([]byte), &doc)).Should(BeNil())
+	g.Expect(doc["id"]).Should(Equal("b"))
+	output := doc["outputs"].([]interface{})[0].(map[string]interface{})
+	g.Expect(output["shape"]).Should(Equal([]interface{}{2.0, 2.0}))
+	g.Expect(output["data"]).Should(Equal([]interface{}{3.0, 4.0, 5.0, 6.0}))
+}
+
+func TestBatchingV2Grpc(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	msgs := []payload.SeldonPayload{
+		&payload.ProtoPayload{Msg: &inference.ModelInferRequest{Id: "a", Inputs: []*inference.ModelInferRequest_InferInputTensor{
+			{Name: "x", Datatype: "FP32", Shape: []int64{1, 2}, Contents: &inference.InferTensorContents{Fp32Contents: []float32{1, 2}}},
+		}}},
+		&payload.ProtoPayload{Msg: &inference.ModelInferRequest{Id: "b", Inputs: []*inference.ModelInferRequest_InferInputTensor{
+			{Name: "x", Datatype: "FP32", Shape: []int64{2, 2}, Contents: &inference.InferTensorContents{Fp32Contents: []float32{3, 4, 5, 6}}},
+		}}},
+	}
+
+	reqs := make([]*batchPayload, len(msgs))
+	signatures := make([]string, len(msgs))
+	for i, msg := range msgs {
+		var err error
+		reqs[i], err = decodeBatchPayload(msg)
+		g.Expect(err).Should(BeNil())
+		var ok bool
+		signatures[i], _, ok = reqs[i].signature(map[string][]string{})
+		g.Expect(ok).Should(BeTrue())
+	}
+	g.Expect(signatures[0]).Should(Equal(signatures[1]))
+	joined, err := joinBatch(reqs)
+	g.Expect(err).Should(BeNil())
+	g.Expect(joined.inferReq.GetId()).Should(BeEmpty())
+	g.Expect(joined.inferReq.GetInputs()[0].GetShape()).Should(Equal([]int64{3, 2}))
+	g.Expect(joined.inferReq.GetInputs()[0].GetContents().GetFp32Contents()).Should(Equal([]float32{1, 2, 3, 4, 5, 6}))
+	// The original requests are left unchanged
+	g.Expect(reqs[0].inferReq.GetInputs()[0].GetShape()).Should(Equal([]int64{1, 2}))
+
+	input := joined.inferReq.GetInputs()[0]
+	res := &batchPayload{inferRes: &inference.ModelInferResponse{Outputs: []*inference.ModelInferResponse_InferOutputTensor{
+		{Name: "y", Datatype: "FP32", Shape: input.GetShape(), Contents: input.GetContents()},
+	}}, contentType: joined.contentType}
+	parts, err := res.split([]int{1, 2})
+	g.Expect(err).Should(BeNil())
+	parts[1].setId("puid-1", reqs[1])
+	msg, err := parts[1].encode()
+	g.Expect(err).Should(BeNil())
+	out := msg.GetPayload().(*inference.ModelInferResponse)
+	g.Expect(out.GetId()).Should(Equal("b"))
+	g.Expect(out.GetOutputs()[0].GetShape()).Should(Equal([]int64{2, 2}))
+	g.Expect(out.GetOutputs()[0].GetContents().GetFp32Contents()).Should(Equal([]float32{3, 4, 5, 6}))
+	g.Expect(parts[0].inferRes.GetOutputs()[0].GetContents().GetFp32Contents()).Should(Equal([]float32{1, 2}))
+
+	// Requests without contents can't be batched
+	_, _, ok := (&batchPayload{inferReq: &inference.ModelInferRequest{Inputs: []*inference.ModelInferRequest_InferInputTensor{
+		{Name: "x", Datatype: "FP32", Shape: []int64{1, 2}},
+	}}}).signature(map[string][]string{})
+	g.Expect(ok).Should(BeFalse())
+}
+
+func TestBatchSpanLinksCallers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := recordSpans(t)
+
+	tracer := otel.Tracer("test")
+	var items []*batchItem
+	var callers []trace.SpanContext
+	for i := 0; i < 2; i++ {
+		ctx, span := tracer.Start(context.Background(), fmt.Sprintf("request-%d", i))
+		defer span.End()
+		items = append(items, &batchItem{ctx: ctx})
+		callers = append(callers, span.SpanContext())
+	}
+	items = append(items, &batchItem{ctx: context.Background()})
+
+	ctx, done := batchContext(items, "batch-puid")
+	g.Expect(ctx.Value(payload.SeldonPUIDHeader)).Should(Equal("batch-puid"))
+	batchSpan := trace.SpanContextFromContext(ctx)
+	g.Expect(batchSpan.TraceID()).ShouldNot(Equal(callers[0].TraceID()))
+	done()
+	g.Expect(ctx.Err()).ShouldNot(BeNil())
+
+	spans := recorder.Ended()
+	g.Expect(spans).Should(HaveLen(1))
+	g.Expect(spans[0].Name()).Should(Equal(SpanBatch))
+	g.Expect(spanAttributes(spans[0])[AttributeBatchSize].AsInt64()).Should(Equal(int64(3)))
+	var linked []trace.SpanContext
+	for _, link := range spans[0].Links() {
+		linked = append(linked, link.SpanContext)
+	}
+	g.Expect(linked).Should(Equal(callers))
+}
+
+func TestBatchingUnbatchablePayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls []payload.SeldonPayload
+	client := batchRecordingTestClient{mu: &sync.Mutex{}, calls: &calls}
+	node := createBatchingModel("batch-unbatchable", 10, 10000)
+
+	msgs := []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"strData":"a"}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"strData":"b"}`), ContentType: "application/json"},
+	}
+	_, errs := predictConcurrently(t, client, node, msgs)
+	g.Expect(errs[0]).Should(BeNil())
+	g.Expect(errs[1]).Should(BeNil())
+	g.Expect(calls).Should(HaveLen(2))
+}
+
+func TestPredictBatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls []payload.SeldonPayload
+	var callMetas []map[string][]string
+	predict := func(meta map[string][]string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+		calls = append(calls, msg)
+		callMetas = append(callMetas, meta)
+		return msg, nil
+	}
+
+	msgs := []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[1.0, 2.0]]}}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"strData":"a"}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[3.0, 4.0],[5.0, 6.0]]}}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[7.0, 8.0]]}}`), ContentType: "application/json"},
+	}
+	metas := []map[string][]string{
+		{payload.SeldonPUIDHeader: {"puid-0"}, "X-Tenant": {"a"}},
+		{payload.SeldonPUIDHeader: {"puid-1"}, "X-Tenant": {"a"}},
+		{payload.SeldonPUIDHeader: {"puid-2"}, "X-Tenant": {"a"}},
+		{payload.SeldonPUIDHeader: {"puid-3"}, "X-Tenant": {"b"}},
+	}
+	res, errs := PredictBatch(msgs, metas, predict)
+	g.Expect(calls).Should(HaveLen(3))
+	for i := range msgs {
+		g.Expect(errs[i]).Should(BeNil())
+	}
+	var sm proto.SeldonMessage
+	err := jsonpb.UnmarshalString(string(res[2].GetPayload().([]byte)), &sm)
+	g.Expect(err).Should(BeNil())
+	g.Expect(sm.GetData().GetNdarray().GetValues()).Should(HaveLen(2))
+	g.Expect(sm.GetMeta().GetPuid()).Should(Equal("puid-2"))
+	g.Expect(string(res[1].GetPayload().([]byte))).Should(Equal(`{"strData":"a"}`))
+	g.Expect(callMetas[0]).Should(Equal(metas[1]))
+	// The batch is sent with the headers of its requests and a puid of its own
+	g.Expect(callMetas[1]["X-Tenant"]).Should(Equal([]string{"a"}))
+	g.Expect(callMetas[1][payload.SeldonPUIDHeader][0]).ShouldNot(BeElementOf("puid-0", "puid-2"))
+	g.Expect(callMetas[2]["X-Tenant"]).Should(Equal([]string{"b"}))
+
+	failed := fmt.Errorf("model unavailable")
+	_, errs = PredictBatch(msgs[:1], metas[:1], func(meta map[string][]string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+		return nil, failed
+	})
+	g.Expect(errs[0]).Should(Equal(failed))
+}
+
+func TestBatchingRequiresRowDimension(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msgs := []string{
+		`{"data":{"ndarray":[1.0, 2.0]}}`,
+		`{"data":{"tensor":{"shape":[2],"values":[1,2]}}}`,
+		`{"inputs":[{"name":"x","shape":[2],"datatype":"FP32","data":[1,2]}]}`,
+	}
+	for _, msg := range msgs {
+		req, err := decodeBatchPayload(&payload.BytesPayload{Msg: []byte(msg), ContentType: "application/json"})
+		g.Expect(err).Should(BeNil())
+		_, _, ok := req.signature(nil)
+		g.Expect(ok).Should(BeFalse(), msg)
+	}
+}
+
+func TestBatchingSeparatesMeta(t *testing.T) {
+	g := NewGomegaWithT(t)
+	signatures := make(map[string]bool)
+	msgs := []string{
+		`{"meta":{"puid":"a","tags":{"user":"x"}},"data":{"ndarray":[[1.0]]}}`,
+		`{"meta":{"puid":"b","tags":{"user":"x"}},"data":{"ndarray":[[2.0]]}}`,
+		`{"meta":{"puid":"c","tags":{"user":"y"}},"data":{"ndarray":[[3.0]]}}`,
+	}
+	for _, msg := range msgs {
+		req, err := decodeBatchPayload(&payload.BytesPayload{Msg: []byte(msg), ContentType: "application/json"})
+		g.Expect(err).Should(BeNil())
+		signature, _, ok := req.signature(map[string][]string{payload.SeldonPUIDHeader: {msg}})
+		g.Expect(ok).Should(BeTrue())
+		signatures[signature] = true
+	}
+	g.Expect(signatures).Should(HaveLen(2))
+}
+
+func TestBatchingLeavesOutCancelledCallers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls []payload.SeldonPayload
+	client := batchRecordingTestClient{mu: &sync.Mutex{}, calls: &calls}
+	node := createBatchingModel("batch-cancelled", 2, 100)
+
+	url, _ := url.Parse(testSourceUrl)
+	ctx, cancel := context.WithCancel(context.WithValue(context.TODO(), payload.SeldonPUIDHeader, "puid-cancelled"))
+	pp := NewPredictorProcess(ctx, client, logf.Log.WithName("SeldonMessageRestClient"), url, "default", map[string][]string{}, "")
+	done := make(chan error)
+	go func() {
+		_, err := pp.Predict(node, &payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[1.0]]}}`), ContentType: "application/json"})
+		done <- err
+	}()
+	cancel()
+	g.Expect(<-done).Should(Equal(context.Canceled))
+
+	res, errs := predictConcurrently(t, client, node, []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[2.0]]}}`), ContentType: "application/json"},
+	})
+	g.Expect(errs[0]).Should(BeNil())
+	g.Expect(calls).Should(HaveLen(1))
+	var sm proto.SeldonMessage
+	g.Expect(jsonpb.UnmarshalString(string(res[0].GetPayload().([]byte)), &sm)).Should(BeNil())
+	g.Expect(sm.GetData().GetNdarray().GetValues()).Should(HaveLen(1))
+	g.Expect(sm.GetData().GetNdarray().GetValues()[0].GetListValue().GetValues()[0].GetNumberValue()).Should(Equal(2.0))
+}
@@ -0,0 +1,119 @@
+package predictor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seldonio/seldon-core/executor/api/cache"
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/util"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const GraphCachePrefix = "graph:"
+
+var (
+	cacheStores      = make(map[string]cache.Store)
+	sharedCacheStore cache.Store
+	cacheStoresMutex = &sync.Mutex{}
+
+	cacheMetrics     *metric.CacheMetrics
+	cacheMetricsOnce sync.Once
+)
+
+func getCacheMetrics() *metric.CacheMetrics {
+	cacheMetricsOnce.Do(func() {
+		cacheMetrics = metric.NewCacheMetrics()
+	})
+	return cacheMetrics
+}
+
+// SetCacheStore makes all graph and node caches use the given store, e.g. one backed by an external
+// service shared between executor replicas. Keys are prefixed with the cache name so caches do not clash.
+func SetCacheStore(store cache.Store) {
+	cacheStoresMutex.Lock()
+	defer cacheStoresMutex.Unlock()
+	sharedCacheStore = store
+}
+
+// Caches are kept for the lifetime of the executor as a new PredictorProcess is created for each request.
+func getCacheStore(name string, policy *v1.CachePolicy) cache.Store {
+	cacheStoresMutex.Lock()
+	defer cacheStoresMutex.Unlock()
+	if sharedCacheStore != nil {
+		return sharedCacheStore
+	}
+	store, ok := cacheStores[name]
+	if !ok {
+		store = cache.NewLRUStore(int(policy.MaxEntries))
+		cacheStores[name] = store
+	}
+	return store
+}
+
+// cached returns the cached response for a request or calls through and caches a successful response.
+// A cached response has its puid replaced by that of the current request and, when withRouting is set,
+// restores the routing decisions made by the call it was cached from. Failures of the store are logged
+// and the call made as if caching were disabled.
+func (p *PredictorProcess) cached(name string, method string, policy *v1.CachePolicy, withRouting bool, msg payload.SeldonPayload, call func() (payload.SeldonPayload, error)) (payload.SeldonPayload, error) {
+	if policy == nil {
+		return call()
+	}
+	key, err := cache.Key(method+":"+p.ModelNameOverride, msg, p.Meta.Meta, policy.Headers)
+	if err != nil {
+		p.Log.Error(err, "Failed to create cache key", "cache", name)
+		return call()
+	}
+	store := getCacheStore(name, policy)
+	if entry, found, err := store.Get(p.Ctx, name+":"+key); err != nil {
+		p.Log.Error(err, "Failed to read from cache", "cache", name)
+	} else if found {
+		getCacheMetrics().HitsCounter.WithLabelValues(name).Inc()
+		if withRouting {
+			p.RoutingMutex.Lock()
+			for node, route := range entry.Routing {
+				p.Routing[node] = route
+			}
+			p.RoutingMutex.Unlock()
+		}
+		res := entry.Response
+		if puid, err := p.getPUIDHeader(); err == nil {
+			if res, err = util.InsertPuidToSeldonPredictPayload(res, puid); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	getCacheMetrics().MissesCounter.WithLabelValues(name).Inc()
+
+	res, err := call()
+	if err == nil && res != nil {
+		entry := &cache.Entry{Response: res}
+		if withRouting {
+			p.RoutingMutex.RLock()
+			entry.Routing = make(map[string]int32, len(p.Routing))
+			for node, route := range p.Routing {
+				entry.Routing[node] = route
+			}
+			p.RoutingMutex.RUnlock()
+		}
+		ttl := time.Duration(policy.TtlMs) * time.Millisecond
+		if err := store.Set(p.Ctx, name+":"+key, entry, ttl); err != nil {
+			p.Log.Error(err, "Failed to write to cache", "cache", name)
+		}
+	}
+	return res, err
+}
+
+// PredictGraph runs a prediction through the predictor's graph, answering from the predictor's
+// response cache when one is configured.
+func (p *PredictorProcess) PredictGraph(spec *v1.PredictorSpec, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	return p.cached(GraphCachePrefix+spec.Name, client.SeldonPredictPath, spec.Cache, true, msg, func() (payload.SeldonPayload, error) {
+		if spec.IsDAG() {
+			return p.predictDAG(spec, msg)
+		}
+		return p.Predict(&spec.Graph, msg)
+	})
+}
@@ -0,0 +1,95 @@
+package predictor
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestGraphCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls int32
+	spec := &v1.PredictorSpec{
+		Name:  "graph-cache",
+		Graph: *createResilientModel("graph-cache-model"),
+		Cache: &v1.CachePolicy{TtlMs: 60000, Headers: []string{"X-User-Id"}},
+	}
+
+	for i := 0; i < 3; i++ {
+		pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls})
+		pp.Meta.Meta["X-User-Id"] = []string{"alice"}
+		msg := &payload.BytesPayload{Msg: []byte(`{"data": {"ndarray": [1, 2]}}`), ContentType: "application/json"}
+		res, err := pp.PredictGraph(spec, msg)
+		g.Expect(err).Should(BeNil())
+		g.Expect(res.GetPayload()).Should(Equal([]byte(`{"data": {"ndarray": [1, 2]}}`)))
+	}
+	g.Expect(calls).Should(Equal(int32(1)))
+
+	pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls})
+	pp.Meta.Meta["X-User-Id"] = []string{"bob"}
+	_, err := pp.PredictGraph(spec, &payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1,2]}}`), ContentType: "application/json"})
+	g.Expect(err).Should(BeNil())
+	g.Expect(calls).Should(Equal(int32(2)))
+}
+
+func TestGraphCacheSkipsErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls int32
+	spec := &v1.PredictorSpec{
+		Name:  "graph-cache-errors",
+		Graph: *createResilientModel("graph-cache-errors-model"),
+		Cache: &v1.CachePolicy{},
+	}
+
+	pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls, failures: 1})
+	_, err := pp.PredictGraph(spec, createPredictPayload(g))
+	g.Expect(err).ShouldNot(BeNil())
+	_, err = pp.PredictGraph(spec, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	_, err = pp.PredictGraph(spec, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(calls).Should(Equal(int32(2)))
+}
+
+func TestNodeCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls int32
+	node := createResilientModel("node-cache-model")
+	node.Cache = &v1.CachePolicy{MaxEntries: 10}
+
+	for i := 0; i < 3; i++ {
+		pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls})
+		_, err := pp.Predict(node, createPredictPayload(g))
+		g.Expect(err).Should(BeNil())
+	}
+	g.Expect(calls).Should(Equal(int32(1)))
+}
+
+func TestGraphCacheRestoresRoutingAndPuid(t *testing.T) {
+	g := NewGomegaWithT(t)
+	policy := &v1.CachePolicy{}
+	var calls int32
+	call := func(pp *PredictorProcess) func() (payload.SeldonPayload, error) {
+		return func() (payload.SeldonPayload, error) {
+			calls++
+			pp.Routing["router"] = 1
+			return &payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1]},"meta":{"puid":"first"}}`), ContentType: "application/json"}, nil
+		}
+	}
+
+	pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls})
+	pp.Ctx = context.WithValue(pp.Ctx, payload.SeldonPUIDHeader, "first")
+	_, err := pp.cached("graph:routing", "predict", policy, true, createPredictPayload(g), call(pp))
+	g.Expect(err).Should(BeNil())
+
+	pp = createPredictorProcessWithClient(t, flakyTestClient{calls: &calls})
+	pp.Ctx = context.WithValue(pp.Ctx, payload.SeldonPUIDHeader, "second")
+	res, err := pp.cached("graph:routing", "predict", policy, true, createPredictPayload(g), call(pp))
+	g.Expect(err).Should(BeNil())
+	g.Expect(calls).Should(Equal(int32(1)))
+	g.Expect(pp.Routing).Should(Equal(map[string]int32{"router": 1}))
+	g.Expect(string(res.GetPayload().([]byte))).Should(Equal(`{"data":{"ndarray":[1]},"meta":{"puid":"second"}}`))
+}
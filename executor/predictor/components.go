@@ -1,24 +1,228 @@
 package predictor
 
 import (
-	"github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"math/rand"
 	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 )
 
-func (p *PredictorProcess) abTestRouter(node *v1.PredictiveUnit) (int, error) {
-	ratioA := 0.5
-	var err error
-	if len(node.Parameters) == 1 && node.Parameters[0].Name == "ratioA" {
-		ratioA, err = strconv.ParseFloat(node.Parameters[0].Value, 32)
-		if err != nil {
-			return 0, err
+const (
+	ABTestStickyHeaderParameter = "stickyHeader"
+	ABTestStickyTagParameter    = "stickyTag"
+	SimpleRouterHeaderParameter = "header"
+	SimpleRouterTagParameter    = "tag"
+	DefaultSimpleRouterHeader   = "Seldon-Route"
+	DefaultSimpleRouterTag      = "route"
+)
+
+// The fixed class probabilities returned by a SIMPLE_MODEL, a stub for trying out graphs without deploying models.
+var (
+	SimpleModelNames  = []string{"class0", "class1", "class2"}
+	SimpleModelValues = []float64{0.1, 0.9, 0.5}
+)
+
+// simpleModel returns the SIMPLE_MODEL response as a SeldonMessage with a single row tensor, in the same form as
+// the request.
+func simpleModel(msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	sm := &proto.SeldonMessage{
+		Meta: &proto.Meta{},
+		DataOneof: &proto.SeldonMessage_Data{
+			Data: &proto.DefaultData{
+				Names: SimpleModelNames,
+				DataOneof: &proto.DefaultData_Tensor{
+					Tensor: &proto.Tensor{
+						Shape:  []int32{1, int32(len(SimpleModelValues))},
+						Values: SimpleModelValues,
+					},
+				},
+			},
+		},
+	}
+	if msg.GetContentType() == payload.APPLICATION_TYPE_PROTOBUF {
+		if _, ok := msg.GetPayload().(*proto.SeldonMessage); !ok {
+			return nil, fmt.Errorf("SIMPLE_MODEL only supports the seldon protocol")
 		}
+		return &payload.ProtoPayload{Msg: sm}, nil
+	}
+	ma := jsonpb.Marshaler{}
+	s, err := ma.MarshalToString(sm)
+	if err != nil {
+		return nil, err
+	}
+	return &payload.BytesPayload{Msg: []byte(s), ContentType: msg.GetContentType()}, nil
+}
+
+// abTestRouter picks a child at random using the weights parameter, or ratioA for two children. When a
+// sticky header or meta tag is configured its value is hashed instead so callers always get the same child.
+func (p *PredictorProcess) abTestRouter(node *v1.PredictiveUnit, msg payload.SeldonPayload) (int, error) {
+	weights := []float64{0.5, 0.5}
+	stickyHeader := ""
+	stickyTag := ""
+	for _, param := range node.Parameters {
+		switch param.Name {
+		case v1.ABTestRatioAParameter:
+			ratioA, err := strconv.ParseFloat(param.Value, 32)
+			if err != nil {
+				return 0, err
+			}
+			weights = []float64{ratioA, 1 - ratioA}
+		case v1.ABTestWeightsParameter:
+			var err error
+			weights, err = parseWeights(param.Value)
+			if err != nil {
+				return 0, err
+			}
+		case ABTestStickyHeaderParameter:
+			stickyHeader = param.Value
+		case ABTestStickyTagParameter:
+			stickyTag = param.Value
+		}
+	}
+	if len(weights) > len(node.Children) {
+		return 0, fmt.Errorf("%s has %d weights but only %d children", node.Name, len(weights), len(node.Children))
 	}
 
-	if rand.Float64() < ratioA {
-		return 0, nil
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+
+	var x float64
+	var stickyKey string
+	var sticky bool
+	if stickyHeader != "" {
+		stickyKey, sticky = p.getHeader(stickyHeader)
+	}
+	if !sticky && stickyTag != "" {
+		if value := getMetaTagValue(msg, stickyTag); value != nil {
+			stickyKey, sticky = fmt.Sprint(value), true
+		}
+	}
+	if sticky {
+		x = hashToUnitInterval(node.Name+":"+stickyKey) * sum
 	} else {
-		return 1, nil
+		x = rand.Float64() * sum
+	}
+
+	for i, w := range weights {
+		if x < w {
+			return i, nil
+		}
+		x -= w
+	}
+	return len(weights) - 1, nil
+}
+
+func parseWeights(value string) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	weights := make([]float64, len(parts))
+	for i, part := range parts {
+		w, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("weight %v must not be negative", w)
+		}
+		weights[i] = w
+	}
+	return weights, nil
+}
+
+// hashToUnitInterval maps a key to a value in [0,1) that is stable across executor replicas and restarts.
+func hashToUnitInterval(key string) float64 {
+	sum := sha256.Sum256([]byte(key))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / float64(1<<53)
+}
+
+// simpleRouter routes to the child given by the request header or, failing that, the meta tag of the
+// request named by the node parameters. Requests without either are sent to the first child.
+func (p *PredictorProcess) simpleRouter(node *v1.PredictiveUnit, msg payload.SeldonPayload) (int, error) {
+	header := DefaultSimpleRouterHeader
+	tag := DefaultSimpleRouterTag
+	for _, param := range node.Parameters {
+		switch param.Name {
+		case SimpleRouterHeaderParameter:
+			header = param.Value
+		case SimpleRouterTagParameter:
+			tag = param.Value
+		}
+	}
+
+	route := 0
+	if value, ok := p.getHeader(header); ok {
+		var err error
+		route, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid route %q in header %s for %s", value, header, node.Name)
+		}
+	} else if value, ok := getMetaTag(msg, tag); ok {
+		route = int(value)
+	}
+
+	if route < 0 || route >= len(node.Children) {
+		return 0, fmt.Errorf("route %d out of range for %s with %d children", route, node.Name, len(node.Children))
+	}
+	return route, nil
+}
+
+// REST headers are canonicalised while gRPC metadata keys are lower case so match either.
+func (p *PredictorProcess) getHeader(name string) (string, bool) {
+	for k, v := range p.Meta.Meta {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return v[0], true
+		}
+	}
+	return "", false
+}
+
+func getMetaTag(msg payload.SeldonPayload, tag string) (float64, bool) {
+	switch value := getMetaTagValue(msg, tag).(type) {
+	case float64:
+		return value, true
+	case string:
+		route, err := strconv.Atoi(value)
+		return float64(route), err == nil
+	}
+	return 0, false
+}
+
+// getMetaTagValue returns the value of a SeldonMessage meta tag or nil if the tag is not present.
+func getMetaTagValue(msg payload.SeldonPayload, tag string) interface{} {
+	if msg.GetContentType() == payload.APPLICATION_TYPE_PROTOBUF {
+		sm, ok := msg.GetPayload().(*proto.SeldonMessage)
+		if !ok {
+			return nil
+		}
+		value, ok := sm.GetMeta().GetTags()[tag]
+		if !ok {
+			return nil
+		}
+		return value.AsInterface()
+	}
+	if msg.GetContentEncoding() != "" {
+		return nil
+	}
+	b, err := msg.GetBytes()
+	if err != nil {
+		return nil
+	}
+	var sm struct {
+		Meta struct {
+			Tags map[string]interface{} `json:"tags"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(b, &sm); err != nil {
+		return nil
 	}
+	return sm.Meta.Tags[tag]
 }
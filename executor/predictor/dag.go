@@ -0,0 +1,122 @@
+package predictor
+
+import (
+	"fmt"
+
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// dagNodes returns the nodes of a DAG graph, ending with the graph node whose output is returned, in an order
+// where every node comes after its inputs.
+func dagNodes(spec *v1.PredictorSpec) ([]*v1.PredictiveUnit, error) {
+	byName := map[string]*v1.PredictiveUnit{spec.Graph.Name: &spec.Graph}
+	for i := range spec.Nodes {
+		byName[spec.Nodes[i].Name] = &spec.Nodes[i]
+	}
+
+	var order []*v1.PredictiveUnit
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(node *v1.PredictiveUnit) error
+	visit = func(node *v1.PredictiveUnit) error {
+		switch state[node.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("DAG graph has a cycle through %s", node.Name)
+		}
+		state[node.Name] = visiting
+		for _, input := range node.Inputs {
+			if input == v1.GraphRequestInput {
+				continue
+			}
+			inputNode, ok := byName[input]
+			if !ok {
+				return fmt.Errorf("unknown input %s of DAG node %s", input, node.Name)
+			}
+			if err := visit(inputNode); err != nil {
+				return err
+			}
+		}
+		state[node.Name] = visited
+		order = append(order, node)
+		return nil
+	}
+	if err := visit(&spec.Graph); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+type dagResult struct {
+	done chan struct{}
+	msg  payload.SeldonPayload
+	err  error
+}
+
+// predictDAG runs a prediction through a DAG graph. Each node is called as soon as all of its inputs are
+// ready, so independent branches run concurrently.
+func (p *PredictorProcess) predictDAG(spec *v1.PredictorSpec, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	puid, err := p.getPUIDHeader()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := dagNodes(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*dagResult, len(nodes))
+	for _, node := range nodes {
+		results[node.Name] = &dagResult{done: make(chan struct{})}
+	}
+	for _, node := range nodes {
+		go func(node *v1.PredictiveUnit, res *dagResult) {
+			defer close(res.done)
+			inputs := make([]payload.SeldonPayload, 0, len(node.Inputs))
+			for _, input := range node.Inputs {
+				if input == v1.GraphRequestInput {
+					inputs = append(inputs, msg)
+					continue
+				}
+				in := results[input]
+				<-in.done
+				if in.err != nil {
+					res.msg, res.err = in.msg, in.err
+					return
+				}
+				inputs = append(inputs, in.msg)
+			}
+			if len(inputs) == 0 {
+				inputs = append(inputs, msg)
+			}
+			res.msg, res.err = p.predictDAGNode(node, inputs, puid)
+		}(node, results[node.Name])
+	}
+
+	res := results[spec.Graph.Name]
+	<-res.done
+	return res.msg, res.err
+}
+
+// predictDAGNode calls a DAG node with the outputs of its inputs. Combiners get every input while other
+// nodes have a single input which they transform.
+func (p *PredictorProcess) predictDAGNode(node *v1.PredictiveUnit, inputs []payload.SeldonPayload, puid string) (payload.SeldonPayload, error) {
+	var msg payload.SeldonPayload
+	var err error
+	if v1.IsAggregator(node) {
+		msg, err = p.aggregate(node, inputs, inputs[0], puid)
+	} else if len(inputs) == 1 {
+		msg, err = p.transformInput(node, inputs[0], puid)
+	} else {
+		err = fmt.Errorf("DAG node %s has %d inputs but isn't a combiner", node.Name, len(inputs))
+	}
+	if err != nil {
+		return msg, err
+	}
+	return p.transformOutput(node, msg, puid)
+}
@@ -0,0 +1,185 @@
+package predictor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// dagTestClient appends the name of each model called to the string payload. Models named in barrier wait
+// for each other so a test only passes if they are called concurrently.
+type dagTestClient struct {
+	test.SeldonMessageTestClient
+	barrier  map[string]chan struct{}
+	fail     string
+	feedback []string
+}
+
+func (c *dagTestClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if modelName == c.fail {
+		return nil, errors.New("failed")
+	}
+	if ch, ok := c.barrier[modelName]; ok {
+		close(ch)
+		for name, other := range c.barrier {
+			select {
+			case <-other:
+			case <-time.After(5 * time.Second):
+				return nil, errors.New(name + " was not called concurrently with " + modelName)
+			}
+		}
+	}
+	return &payload.BytesPayload{Msg: []byte(string(msg.GetPayload().([]byte)) + ">" + modelName)}, nil
+}
+
+func (c *dagTestClient) Combine(ctx context.Context, modelName string, host string, port int32, msgs []payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	parts := make([]string, len(msgs))
+	for i, msg := range msgs {
+		parts[i] = string(msg.GetPayload().([]byte))
+	}
+	return &payload.BytesPayload{Msg: []byte("(" + strings.Join(parts, ",") + ")" + modelName)}, nil
+}
+
+func (c *dagTestClient) Feedback(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	c.feedback = append(c.feedback, modelName)
+	return msg, nil
+}
+
+func createDAGPredictorProcess(client *dagTestClient) *PredictorProcess {
+	url, _ := url.Parse(testSourceUrl)
+	ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, testSeldonPuid)
+	pp := NewPredictorProcess(ctx, client, logf.Log.WithName("DAGTest"), url, "default", map[string][]string{}, "")
+	return &pp
+}
+
+func createDAGSpec() *v1.PredictorSpec {
+	model := v1.MODEL
+	combiner := v1.COMBINER
+	endpoint := &v1.Endpoint{ServiceHost: "foo", ServicePort: 9000, Type: v1.REST}
+	return &v1.PredictorSpec{
+		Name: "dag",
+		Graph: v1.PredictiveUnit{
+			Name:     "explainer",
+			Type:     &combiner,
+			Endpoint: endpoint,
+			Inputs:   []string{v1.GraphRequestInput, "model1", "model2"},
+		},
+		Nodes: []v1.PredictiveUnit{
+			{Name: "model2", Type: &model, Endpoint: endpoint, Inputs: []string{"features"}},
+			{Name: "model1", Type: &model, Endpoint: endpoint, Inputs: []string{"features"}},
+			{Name: "features", Type: &model, Endpoint: endpoint},
+		},
+	}
+}
+
+func TestPredictDAG(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := &dagTestClient{barrier: map[string]chan struct{}{
+		"model1": make(chan struct{}),
+		"model2": make(chan struct{}),
+	}}
+	pp := createDAGPredictorProcess(client)
+	res, err := pp.PredictGraph(createDAGSpec(), &payload.BytesPayload{Msg: []byte("req")})
+	g.Expect(err).Should(BeNil())
+	g.Expect(string(res.GetPayload().([]byte))).To(Equal("(req,req>features>model1,req>features>model2)explainer"))
+}
+
+func TestPredictDAGError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pp := createDAGPredictorProcess(&dagTestClient{fail: "features"})
+	_, err := pp.PredictGraph(createDAGSpec(), &payload.BytesPayload{Msg: []byte("req")})
+	g.Expect(err).ShouldNot(BeNil())
+
+	spec := createDAGSpec()
+	spec.Nodes[2].Inputs = []string{"model1"}
+	_, err = createDAGPredictorProcess(&dagTestClient{}).PredictGraph(spec, &payload.BytesPayload{Msg: []byte("req")})
+	g.Expect(err).ShouldNot(BeNil())
+
+	spec = createDAGSpec()
+	spec.Nodes[2].Inputs = []string{"missing"}
+	_, err = createDAGPredictorProcess(&dagTestClient{}).PredictGraph(spec, &payload.BytesPayload{Msg: []byte("req")})
+	g.Expect(err).ShouldNot(BeNil())
+}
+
+func TestDAGNodesOrder(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nodes, err := dagNodes(createDAGSpec())
+	g.Expect(err).Should(BeNil())
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+	}
+	g.Expect(names).To(Equal([]string{"features", "model1", "model2", "explainer"}))
+}
+
+func TestGraphMetadataDAG(t *testing.T) {
+	g := NewGomegaWithT(t)
+	model := v1.MODEL
+	combiner := v1.COMBINER
+	average := v1.AVERAGE_COMBINER
+	endpoint := &v1.Endpoint{ServiceHost: "foo", ServicePort: 9000, Type: v1.REST}
+
+	spec := &v1.PredictorSpec{
+		Name: "predictor-name",
+		Graph: v1.PredictiveUnit{
+			Name:     "model-combiner",
+			Type:     &combiner,
+			Endpoint: endpoint,
+			Inputs:   []string{v1.GraphRequestInput, "model-2"},
+		},
+		Nodes: []v1.PredictiveUnit{
+			{Name: "model-1", Type: &model, Endpoint: endpoint},
+			{Name: "model-2", Type: &model, Endpoint: endpoint, Inputs: []string{"model-1"}},
+		},
+	}
+
+	graphMetadata, err := createPredictorProcessWithMetadata(t, nil, metadataMap).GraphMetadata(spec)
+	g.Expect(err).Should(BeNil())
+	g.Expect(graphMetadata.Models).To(HaveLen(3))
+	expected, err := json.Marshal(map[string]interface{}{
+		"graphinputs":  metadataMap["model-1"].Inputs,
+		"graphoutputs": metadataMap["model-combiner"].Outputs,
+	})
+	g.Expect(err).Should(BeNil())
+	actual, err := json.Marshal(map[string]interface{}{
+		"graphinputs":  graphMetadata.GraphInputs,
+		"graphoutputs": graphMetadata.GraphOutputs,
+	})
+	g.Expect(err).Should(BeNil())
+	g.Expect(actual).To(MatchJSON(expected))
+
+	// Built-in combiners return the outputs of their inputs
+	spec.Graph = v1.PredictiveUnit{
+		Name:           "average",
+		Implementation: &average,
+		Inputs:         []string{"model-2", "model-1"},
+	}
+	graphMetadata, err = createPredictorProcessWithMetadata(t, nil, metadataMap).GraphMetadata(spec)
+	g.Expect(err).Should(BeNil())
+	expected, err = json.Marshal(metadataMap["model-2"].Outputs)
+	g.Expect(err).Should(BeNil())
+	actual, err = json.Marshal(graphMetadata.GraphOutputs)
+	g.Expect(err).Should(BeNil())
+	g.Expect(actual).To(MatchJSON(expected))
+}
+
+func TestFeedbackDAG(t *testing.T) {
+	g := NewGomegaWithT(t)
+	client := &dagTestClient{}
+	_, err := createDAGPredictorProcess(client).FeedbackGraph(createDAGSpec(), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(client.feedback).To(Equal([]string{"model2", "model1", "features"}))
+}
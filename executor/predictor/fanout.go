@@ -0,0 +1,117 @@
+package predictor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/util"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const DroppedChildrenTag = "dropped_children"
+
+type childResult struct {
+	index int
+	msg   payload.SeldonPayload
+	err   error
+}
+
+func childName(node *v1.PredictiveUnit, index int) string {
+	if node.Children[index].Name != "" {
+		return node.Children[index].Name
+	}
+	return strconv.Itoa(index)
+}
+
+// predictChildrenWithQuorum calls all children concurrently and returns the successful responses in child order
+// along with the names of the children that failed or did not answer before the fan out deadline. It waits for
+// every child until the deadline, stopping early only once so many children have failed that the quorum can no
+// longer be met.
+func (p *PredictorProcess) predictChildrenWithQuorum(node *v1.PredictiveUnit, msg payload.SeldonPayload) ([]payload.SeldonPayload, []string, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if node.FanOut.DeadlineMs > 0 {
+		ctx, cancel = context.WithTimeout(p.Ctx, time.Duration(node.FanOut.DeadlineMs)*time.Millisecond)
+	} else {
+		ctx, cancel = context.WithCancel(p.Ctx)
+	}
+	// Cancels calls to children still running at the deadline or once the quorum can no longer be met
+	defer cancel()
+
+	childProcess := *p
+	childProcess.Ctx = ctx
+
+	results := make(chan childResult, len(node.Children))
+	for i, nodeChild := range node.Children {
+		go func(i int, nodeChild v1.PredictiveUnit) {
+			cmsg, err := childProcess.Predict(&nodeChild, msg)
+			results <- childResult{index: i, msg: cmsg, err: err}
+		}(i, nodeChild)
+	}
+
+	quorum := int(node.FanOut.Quorum)
+	cmsgs := make([]payload.SeldonPayload, len(node.Children))
+	errs := make([]error, len(node.Children))
+	received := 0
+	failed := 0
+	for received < len(node.Children) && len(node.Children)-failed >= quorum {
+		select {
+		case r := <-results:
+			received++
+			if r.err != nil {
+				errs[r.index] = r.err
+				failed++
+			} else {
+				cmsgs[r.index] = r.msg
+			}
+		case <-ctx.Done():
+			if p.Ctx.Err() != nil {
+				return nil, nil, p.Ctx.Err()
+			}
+			received = len(node.Children)
+		}
+	}
+
+	var successes []payload.SeldonPayload
+	var dropped []string
+	var firstErr error
+	for i := range node.Children {
+		if cmsgs[i] != nil {
+			successes = append(successes, cmsgs[i])
+		} else {
+			dropped = append(dropped, childName(node, i))
+			if errs[i] != nil {
+				p.Log.Info("Dropping failed child from fan out", "node", node.Name, "child", childName(node, i), "error", errs[i].Error())
+				if firstErr == nil {
+					firstErr = errs[i]
+				}
+			} else {
+				p.Log.Info("Dropping child that missed fan out deadline", "node", node.Name, "child", childName(node, i))
+			}
+		}
+	}
+
+	if len(successes) < quorum {
+		if firstErr == nil {
+			firstErr = context.DeadlineExceeded
+		}
+		return nil, dropped, fmt.Errorf("fan out for %s received %d of %d responses with quorum %d: %w", node.Name, len(successes), len(node.Children), quorum, firstErr)
+	}
+	return successes, dropped, nil
+}
+
+func (p *PredictorProcess) tagDroppedChildren(msg payload.SeldonPayload, dropped []string) payload.SeldonPayload {
+	values := make([]interface{}, len(dropped))
+	for i, name := range dropped {
+		values[i] = name
+	}
+	tagged, err := util.InsertTagToSeldonPredictPayload(msg, DroppedChildrenTag, values)
+	if err != nil {
+		p.Log.Error(err, "Failed to add dropped children to response meta")
+		return msg
+	}
+	return tagged
+}
@@ -0,0 +1,128 @@
+package predictor
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type degradedEnsembleTestClient struct {
+	test.SeldonMessageTestClient
+	failingHosts map[string]bool
+	slowHosts    map[string]bool
+	combined     *int32
+}
+
+func (d degradedEnsembleTestClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if d.failingHosts[host] {
+		return nil, errors.New("model failed")
+	}
+	if d.slowHosts[host] {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return msg, nil
+}
+
+func (d degradedEnsembleTestClient) Combine(ctx context.Context, modelName string, host string, port int32, msgs []payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	atomic.StoreInt32(d.combined, int32(len(msgs)))
+	return msgs[0], nil
+}
+
+func createEnsemble(quorum int32, deadlineMs int32) *v1.PredictiveUnit {
+	model := v1.MODEL
+	combiner := v1.COMBINER
+	graph := &v1.PredictiveUnit{
+		Name:   "ensemble",
+		Type:   &combiner,
+		FanOut: &v1.FanOutPolicy{Quorum: quorum, DeadlineMs: deadlineMs},
+		Endpoint: &v1.Endpoint{
+			ServiceHost: "combiner",
+			ServicePort: 9000,
+			Type:        v1.REST,
+		},
+	}
+	for _, name := range []string{"m1", "m2", "m3"} {
+		graph.Children = append(graph.Children, v1.PredictiveUnit{
+			Name: name,
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: name,
+				ServicePort: 9001,
+				Type:        v1.REST,
+			},
+		})
+	}
+	return graph
+}
+
+func createPredictorProcessWithEnsembleClient(client degradedEnsembleTestClient) *PredictorProcess {
+	url, _ := url.Parse(testSourceUrl)
+	ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, testSeldonPuid)
+	pp := NewPredictorProcess(ctx, client, logf.Log.WithName("SeldonMessageRestClient"), url, "default", map[string][]string{}, "")
+	return &pp
+}
+
+func TestFanOutDropsFailedChild(t *testing.T) {
+	g := NewGomegaWithT(t)
+	combined := int32(0)
+	client := degradedEnsembleTestClient{failingHosts: map[string]bool{"m2": true}, combined: &combined}
+
+	pResp, err := createPredictorProcessWithEnsembleClient(client).Predict(createEnsemble(2, 0), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(combined).To(Equal(int32(2)))
+	smRes := pResp.GetPayload().(*proto.SeldonMessage)
+	dropped := smRes.GetMeta().GetTags()[DroppedChildrenTag].GetListValue().GetValues()
+	g.Expect(len(dropped)).To(Equal(1))
+	g.Expect(dropped[0].GetStringValue()).To(Equal("m2"))
+}
+
+func TestFanOutDeadline(t *testing.T) {
+	g := NewGomegaWithT(t)
+	combined := int32(0)
+	client := degradedEnsembleTestClient{slowHosts: map[string]bool{"m3": true}, combined: &combined}
+
+	start := time.Now()
+	pResp, err := createPredictorProcessWithEnsembleClient(client).Predict(createEnsemble(2, 50), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	g.Expect(combined).To(Equal(int32(2)))
+	smRes := pResp.GetPayload().(*proto.SeldonMessage)
+	dropped := smRes.GetMeta().GetTags()[DroppedChildrenTag].GetListValue().GetValues()
+	g.Expect(dropped[0].GetStringValue()).To(Equal("m3"))
+}
+
+func TestFanOutQuorumNotMet(t *testing.T) {
+	g := NewGomegaWithT(t)
+	combined := int32(0)
+	client := degradedEnsembleTestClient{failingHosts: map[string]bool{"m1": true, "m2": true}, combined: &combined}
+
+	_, err := createPredictorProcessWithEnsembleClient(client).Predict(createEnsemble(2, 0), createPredictPayload(g))
+	g.Expect(err).ShouldNot(BeNil())
+	g.Expect(combined).To(Equal(int32(0)))
+}
+
+func TestFanOutQuorumUnreachableCancelsSlowChild(t *testing.T) {
+	g := NewGomegaWithT(t)
+	combined := int32(0)
+	client := degradedEnsembleTestClient{failingHosts: map[string]bool{"m1": true, "m2": true}, slowHosts: map[string]bool{"m3": true}, combined: &combined}
+
+	start := time.Now()
+	_, err := createPredictorProcessWithEnsembleClient(client).Predict(createEnsemble(2, 0), createPredictPayload(g))
+	g.Expect(err).ShouldNot(BeNil())
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	g.Expect(combined).To(Equal(int32(0)))
+}
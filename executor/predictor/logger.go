@@ -0,0 +1,308 @@
+package predictor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	payloadLogger "github.com/seldonio/seldon-core/executor/logger"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// logSampled decides whether the payloads of a request are logged. The decision is a hash of the request
+// puid so the request and response of a sampled request are logged at every node.
+func logSampled(logger *v1.Logger, puid string) bool {
+	if logger.SampleRate == nil {
+		return true
+	}
+	rate, err := strconv.ParseFloat(*logger.SampleRate, 64)
+	if err != nil || rate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(puid))
+	return float64(h.Sum64()) < rate*math.MaxUint64
+}
+
+// logHeaders returns the request headers the logger allows to be logged.
+func (p *PredictorProcess) logHeaders(logger *v1.Logger) map[string][]string {
+	if len(logger.Headers) == 0 || p.Meta == nil {
+		return nil
+	}
+	headers := map[string][]string{}
+	for name, values := range p.Meta.Meta {
+		for _, allowed := range logger.Headers {
+			if strings.EqualFold(name, allowed) {
+				headers[name] = values
+				break
+			}
+		}
+	}
+	return headers
+}
+
+// Parsed redaction rules keyed by logger
+var loggerRedactions sync.Map
+
+// redaction is a parsed redaction rule masking either the values selected by the segments of its path or a
+// data column by name.
+type redaction struct {
+	segments []string
+	name     string
+	mask     string
+}
+
+// getRedactions returns the logger's redaction rules, parsing their paths the first time the logger is used.
+func getRedactions(logger *v1.Logger) ([]redaction, error) {
+	if redactions, ok := loggerRedactions.Load(logger); ok {
+		return redactions.([]redaction), nil
+	}
+	redactions := make([]redaction, 0, len(logger.Redact))
+	for _, rule := range logger.Redact {
+		r := redaction{name: rule.Name, mask: rule.Mask}
+		if r.mask == "" {
+			r.mask = v1.DefaultRedactionMask
+		}
+		if rule.JSONPath != "" {
+			segments, err := v1.ParseRedactionPath(rule.JSONPath)
+			if err != nil {
+				return nil, err
+			}
+			r.segments = segments
+		}
+		redactions = append(redactions, r)
+	}
+	stored, _ := loggerRedactions.LoadOrStore(logger, redactions)
+	return stored.([]redaction), nil
+}
+
+// redactPayload returns the payload to log with the logger's redaction rules applied. Redacted payloads are
+// logged as uncompressed JSON, so gRPC payloads are converted to their REST form first.
+func redactPayload(logger *v1.Logger, msg payload.SeldonPayload) ([]byte, string, string, error) {
+	if len(logger.Redact) == 0 {
+		data, err := msg.GetBytes()
+		return data, msg.GetContentType(), msg.GetContentEncoding(), err
+	}
+	redactions, err := getRedactions(logger)
+	if err != nil {
+		return nil, "", "", err
+	}
+	jsonMsg, err := payload.ProtoToJSON(msg)
+	if err != nil {
+		return nil, "", "", err
+	}
+	data, err := payload.DecompressSeldonPayload(jsonMsg)
+	if err != nil {
+		return nil, "", "", err
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, "", "", fmt.Errorf("unable to redact payload that isn't JSON: %w", err)
+	}
+	for _, r := range redactions {
+		if r.segments != nil {
+			doc = redactPath(doc, r.segments, r.mask)
+		} else {
+			redactName(doc, r.name, r.mask)
+		}
+	}
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return data, "application/json", "", nil
+}
+
+// redactPath replaces the values selected by the path segments with mask and returns the updated value.
+func redactPath(value interface{}, segments []string, mask string) interface{} {
+	if len(segments) == 0 {
+		return mask
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if segments[0] == "*" {
+			for key, elem := range v {
+				v[key] = redactPath(elem, segments[1:], mask)
+			}
+		} else if elem, ok := v[segments[0]]; ok {
+			v[segments[0]] = redactPath(elem, segments[1:], mask)
+		}
+	case []interface{}:
+		if segments[0] == "*" {
+			for i, elem := range v {
+				v[i] = redactPath(elem, segments[1:], mask)
+			}
+		} else if i, err := strconv.Atoi(segments[0]); err == nil && i >= 0 && i < len(v) {
+			v[i] = redactPath(v[i], segments[1:], mask)
+		}
+	}
+	return value
+}
+
+// redactName masks the column of SeldonMessage data whose entry in data.names is name. Values in ndarray
+// rows are replaced with mask, while tensor values, which must be numbers, are set to zero.
+func redactName(doc interface{}, name string, mask string) {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return
+	}
+	data, ok := root["data"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	names, _ := data["names"].([]interface{})
+	column := -1
+	for i, n := range names {
+		if n == name {
+			column = i
+			break
+		}
+	}
+	if column < 0 {
+		return
+	}
+	if ndarray, ok := data["ndarray"].([]interface{}); ok {
+		rows := ndarray
+		if len(ndarray) > 0 {
+			if _, nested := ndarray[0].([]interface{}); !nested {
+				rows = []interface{}{ndarray}
+			}
+		}
+		for _, row := range rows {
+			if values, ok := row.([]interface{}); ok && column < len(values) {
+				values[column] = mask
+			}
+		}
+	}
+	if tensor, ok := data["tensor"].(map[string]interface{}); ok {
+		values, _ := tensor["values"].([]interface{})
+		columns := int64(len(names))
+		if shape, ok := tensor["shape"].([]interface{}); ok && len(shape) > 0 {
+			if n, ok := shape[len(shape)-1].(json.Number); ok {
+				if c, err := n.Int64(); err == nil && c > 0 {
+					columns = c
+				}
+			}
+		}
+		for i := int64(column); i < int64(len(values)); i += columns {
+			values[i] = json.Number("0")
+		}
+	}
+}
+
+// pairEvent is the data of a logged node call, holding its request and response. Payloads that aren't JSON are
+// base64 encoded.
+type pairEvent struct {
+	Request        json.RawMessage  `json:"request,omitempty"`
+	RequestBase64  []byte           `json:"requestBase64,omitempty"`
+	Response       json.RawMessage  `json:"response,omitempty"`
+	ResponseBase64 []byte           `json:"responseBase64,omitempty"`
+	LatencyMs      float64          `json:"latencyMs"`
+	Status         pairStatus       `json:"status"`
+	Routing        map[string]int32 `json:"routing,omitempty"`
+}
+
+// pairStatus is the outcome of a node call in the terms of the transport it was made over.
+type pairStatus struct {
+	Transport  string `json:"transport"`
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+	GrpcStatus string `json:"grpcStatus,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// logPair logs a node call as a single event holding its request, response, latency, status and the routing
+// decisions taken so far, so consumers don't need to join request and response events.
+func (p *PredictorProcess) logPair(node *v1.PredictiveUnit, request payload.SeldonPayload, response payload.SeldonPayload, start time.Time, callErr error, puid string) error {
+	logger := node.Logger
+	if !logSampled(logger, puid) {
+		return nil
+	}
+	e := pairEvent{
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Status:    p.pairStatus(node, callErr),
+	}
+	var err error
+	if e.Request, e.RequestBase64, err = pairPayload(logger, request); err == nil {
+		e.Response, e.ResponseBase64, err = pairPayload(logger, response)
+	}
+	if err != nil {
+		p.Log.Error(err, "failed to redact payload, not logging it", "node", node.Name)
+		return nil
+	}
+	p.RoutingMutex.RLock()
+	if len(p.Routing) > 0 {
+		e.Routing = make(map[string]int32, len(p.Routing))
+		for name, route := range p.Routing {
+			e.Routing[name] = route
+		}
+	}
+	p.RoutingMutex.RUnlock()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return p.queueLog(node.Name, logger, payloadLogger.InferencePair, data, "application/json", "", puid)
+}
+
+// pairPayload returns a payload to embed in a pair event, either as JSON or as raw bytes to base64 encode.
+func pairPayload(logger *v1.Logger, msg payload.SeldonPayload) (json.RawMessage, []byte, error) {
+	if msg == nil || msg.GetPayload() == nil {
+		return nil, nil, nil
+	}
+	if jsonMsg, err := payload.ProtoToJSON(msg); err == nil {
+		msg = jsonMsg
+	}
+	data, contentType, contentEncoding, err := redactPayload(logger, msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentEncoding != "" {
+		if decompressed, err := payload.DecompressBytes(data, contentEncoding); err == nil {
+			data = decompressed
+			contentEncoding = ""
+		}
+	}
+	if contentEncoding == "" && strings.Contains(contentType, "json") && json.Valid(data) {
+		return data, nil, nil
+	}
+	return nil, data, nil
+}
+
+func (p *PredictorProcess) pairStatus(node *v1.PredictiveUnit, err error) pairStatus {
+	s := pairStatus{}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	if p.isGrpcNode(node) {
+		s.Transport = "grpc"
+		s.GrpcStatus = status.Code(err).String()
+		if _, ok := err.(*CircuitOpenError); ok {
+			s.GrpcStatus = codes.Unavailable.String()
+		}
+		return s
+	}
+	s.Transport = "rest"
+	s.HTTPStatus = http.StatusOK
+	var statusErr interface{ HTTPStatus() int }
+	if errors.As(err, &statusErr) {
+		s.HTTPStatus = statusErr.HTTPStatus()
+	} else if _, ok := err.(*CircuitOpenError); ok {
+		s.HTTPStatus = http.StatusServiceUnavailable
+	} else if err != nil {
+		s.HTTPStatus = http.StatusInternalServerError
+	}
+	return s
+}
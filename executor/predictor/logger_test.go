@@ -0,0 +1,270 @@
+package predictor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	"github.com/seldonio/seldon-core/executor/logger"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestLogSampled(t *testing.T) {
+	g := NewGomegaWithT(t)
+	rate := func(s string) *v1.Logger { return &v1.Logger{Mode: v1.LogAll, SampleRate: &s} }
+
+	g.Expect(logSampled(&v1.Logger{Mode: v1.LogAll}, "a")).To(BeTrue())
+	g.Expect(logSampled(rate("1"), "a")).To(BeTrue())
+	g.Expect(logSampled(rate("0"), "a")).To(BeFalse())
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		puid := fmt.Sprintf("puid-%d", i)
+		s := logSampled(rate("0.2"), puid)
+		// The same request is always sampled the same way
+		g.Expect(logSampled(rate("0.2"), puid)).To(Equal(s))
+		if s {
+			sampled++
+		}
+	}
+	g.Expect(sampled).To(BeNumerically("~", 2000, 200))
+}
+
+func TestLogHeaders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pp := createPredictorProcess(t)
+	pp.Meta.Meta["x-user-id"] = []string{"user1"}
+	pp.Meta.Meta["Authorization"] = []string{"secret"}
+	g.Expect(pp.logHeaders(&v1.Logger{Mode: v1.LogAll})).To(BeNil())
+	g.Expect(pp.logHeaders(&v1.Logger{Mode: v1.LogAll, Headers: []string{"X-User-Id"}})).To(Equal(map[string][]string{"x-user-id": {"user1"}}))
+}
+
+func TestRedactPayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name     string
+		rules    []v1.RedactionRule
+		msg      string
+		expected string
+	}{
+		{
+			name:     "json path",
+			rules:    []v1.RedactionRule{{JSONPath: "$.jsonData.customer.email"}, {JSONPath: "$.jsonData.missing.field"}},
+			msg:      `{"jsonData":{"customer":{"email":"a@b.com","age":40}}}`,
+			expected: `{"jsonData":{"customer":{"email":"****","age":40}}}`,
+		},
+		{
+			name:     "json path wildcard",
+			rules:    []v1.RedactionRule{{JSONPath: "$.data.ndarray[*][1]", Mask: "x"}},
+			msg:      `{"data":{"ndarray":[[1,2],[3,4]]}}`,
+			expected: `{"data":{"ndarray":[[1,"x"],[3,"x"]]}}`,
+		},
+		{
+			name:     "names ndarray",
+			rules:    []v1.RedactionRule{{Name: "ssn"}},
+			msg:      `{"data":{"names":["age","ssn"],"ndarray":[[40,"123-45"],[50,"678-90"]]}}`,
+			expected: `{"data":{"names":["age","ssn"],"ndarray":[[40,"****"],[50,"****"]]}}`,
+		},
+		{
+			name:     "names ndarray single row",
+			rules:    []v1.RedactionRule{{Name: "age"}},
+			msg:      `{"data":{"names":["age","ssn"],"ndarray":[40,"123-45"]}}`,
+			expected: `{"data":{"names":["age","ssn"],"ndarray":["****","123-45"]}}`,
+		},
+		{
+			name:     "names tensor",
+			rules:    []v1.RedactionRule{{Name: "income"}},
+			msg:      `{"data":{"names":["age","income"],"tensor":{"shape":[2,2],"values":[40,30000,50,60000]}}}`,
+			expected: `{"data":{"names":["age","income"],"tensor":{"shape":[2,2],"values":[40,0,50,0]}}}`,
+		},
+		{
+			name:     "unknown name",
+			rules:    []v1.RedactionRule{{Name: "ssn"}},
+			msg:      `{"data":{"names":["age"],"ndarray":[[40]]}}`,
+			expected: `{"data":{"names":["age"],"ndarray":[[40]]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, contentType, contentEncoding, err := redactPayload(&v1.Logger{Mode: v1.LogAll, Redact: test.rules}, jsonPayload(test.msg))
+			g.Expect(err).Should(BeNil())
+			g.Expect(string(data)).To(MatchJSON(test.expected))
+			g.Expect(contentType).To(Equal("application/json"))
+			g.Expect(contentEncoding).To(Equal(""))
+		})
+	}
+}
+
+func TestRedactPayloadProto(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var sm proto.SeldonMessage
+	err := jsonpb.UnmarshalString(`{"data":{"names":["a","b"],"ndarray":[[1,2]]}}`, &sm)
+	g.Expect(err).Should(BeNil())
+	data, contentType, _, err := redactPayload(&v1.Logger{Mode: v1.LogAll, Redact: []v1.RedactionRule{{Name: "b"}}}, &payload.ProtoPayload{Msg: &sm})
+	g.Expect(err).Should(BeNil())
+	g.Expect(string(data)).To(MatchJSON(`{"data":{"names":["a","b"],"ndarray":[[1,"****"]]}}`))
+	g.Expect(contentType).To(Equal("application/json"))
+
+	_, _, _, err = redactPayload(&v1.Logger{Mode: v1.LogAll, Redact: []v1.RedactionRule{{Name: "b"}}}, &payload.BytesPayload{Msg: []byte("raw"), ContentType: "text/plain"})
+	g.Expect(err).ShouldNot(BeNil())
+}
+
+func TestRedactionPathsAreParsedOnce(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	logger := &v1.Logger{Mode: v1.LogAll, Redact: []v1.RedactionRule{{JSONPath: "$.data.ndarray[*][1]"}, {Name: "a"}}}
+	for i := 0; i < 2; i++ {
+		data, _, _, err := redactPayload(logger, jsonPayload(`{"data":{"names":["a","b"],"ndarray":[[1,2]]}}`))
+		g.Expect(err).Should(BeNil())
+		g.Expect(string(data)).To(MatchJSON(`{"data":{"names":["a","b"],"ndarray":[["****","****"]]}}`))
+	}
+	first, err := getRedactions(logger)
+	g.Expect(err).Should(BeNil())
+	second, err := getRedactions(logger)
+	g.Expect(err).Should(BeNil())
+	g.Expect(second[0].segments).To(Equal([]string{"data", "ndarray", "*", "1"}))
+	g.Expect(&second[0]).To(BeIdenticalTo(&first[0]))
+
+	_, _, _, err = redactPayload(&v1.Logger{Mode: v1.LogAll, Redact: []v1.RedactionRule{{JSONPath: "data"}}}, jsonPayload(`{}`))
+	g.Expect(err).ShouldNot(BeNil())
+}
+
+func TestModelWithLogRedaction(t *testing.T) {
+	g := NewGomegaWithT(t)
+	modelName := "foo"
+	logged := make(chan string, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Header.Get("X-User-Id")).To(Equal("user1"))
+		g.Expect(r.Header.Get("Authorization")).To(Equal(""))
+		g.Expect(r.Header.Get(contentTypeHeaderName)).To(Equal("application/json"))
+		body, err := ioutil.ReadAll(r.Body)
+		g.Expect(err).Should(BeNil())
+		w.Write([]byte(""))
+		logged <- string(body)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	logf.SetLogger(zap.New())
+	log := logf.Log.WithName("entrypoint")
+	logger.StartDispatcher(1, logger.DefaultWorkQueueSize, logger.DefaultWriteTimeoutMilliseconds, log, "", "", "", "", "")
+
+	model := v1.MODEL
+	graph := &v1.PredictiveUnit{
+		Name: modelName,
+		Type: &model,
+		Endpoint: &v1.Endpoint{
+			ServiceHost: "foo",
+			ServicePort: 9000,
+			Type:        v1.REST,
+		},
+		Logger: &v1.Logger{
+			Mode:    v1.LogRequest,
+			Url:     &server.URL,
+			Headers: []string{"X-User-Id"},
+			Redact:  []v1.RedactionRule{{JSONPath: "$.data.ndarray[0]"}},
+		},
+	}
+
+	pp := createPredictorProcess(t)
+	pp.Meta.Meta["X-User-Id"] = []string{"user1"}
+	pp.Meta.Meta["Authorization"] = []string{"secret"}
+	_, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Eventually(logged).Should(Receive(MatchJSON(`{"data":{"ndarray":["****",2]}}`)))
+}
+
+func TestModelWithLogPair(t *testing.T) {
+	g := NewGomegaWithT(t)
+	modelName := "foo"
+	logged := make(chan map[string]interface{}, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Header.Get("ce-type")).To(Equal(logger.CEInferencePair))
+		g.Expect(r.Header.Get(contentTypeHeaderName)).To(Equal("application/json"))
+		var body map[string]interface{}
+		g.Expect(json.NewDecoder(r.Body).Decode(&body)).Should(BeNil())
+		w.Write([]byte(""))
+		logged <- body
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	logf.SetLogger(zap.New())
+	log := logf.Log.WithName("entrypoint")
+	logger.StartDispatcher(1, logger.DefaultWorkQueueSize, logger.DefaultWriteTimeoutMilliseconds, log, "", "", "", "", "")
+
+	model := v1.MODEL
+	graph := &v1.PredictiveUnit{
+		Name: modelName,
+		Type: &model,
+		Endpoint: &v1.Endpoint{
+			ServiceHost: "foo",
+			ServicePort: 9000,
+			Type:        v1.GRPC,
+		},
+		Logger: &v1.Logger{
+			Mode: v1.LogPair,
+			Url:  &server.URL,
+		},
+	}
+
+	pp := createPredictorProcess(t)
+	_, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	var body map[string]interface{}
+	g.Eventually(logged).Should(Receive(&body))
+	g.Expect(body["request"]).To(Equal(map[string]interface{}{"data": map[string]interface{}{"ndarray": []interface{}{1.1, 2.0}}}))
+	g.Expect(body["response"]).To(Equal(body["request"]))
+	g.Expect(body["status"]).To(Equal(map[string]interface{}{"transport": "grpc", "grpcStatus": "OK"}))
+	g.Expect(body).To(HaveKey("latencyMs"))
+}
+
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("status %d", int(e))
+}
+
+func (e statusError) HTTPStatus() int {
+	return int(e)
+}
+
+type restTestClient struct {
+	test.SeldonMessageTestClient
+}
+
+func (c restTestClient) IsGrpc() bool {
+	return false
+}
+
+func TestPairStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+	node := &v1.PredictiveUnit{Name: "foo"}
+
+	pp := createPredictorProcess(t)
+	g.Expect(pp.pairStatus(node, nil)).To(Equal(pairStatus{Transport: "grpc", GrpcStatus: "OK"}))
+	err := status.Error(codes.InvalidArgument, "bad")
+	g.Expect(pp.pairStatus(node, err)).To(Equal(pairStatus{Transport: "grpc", GrpcStatus: "InvalidArgument", Error: err.Error()}))
+
+	pp.Client = restTestClient{}
+	g.Expect(pp.pairStatus(node, nil)).To(Equal(pairStatus{Transport: "rest", HTTPStatus: http.StatusOK}))
+	g.Expect(pp.pairStatus(node, statusError(400)).HTTPStatus).To(Equal(400))
+	g.Expect(pp.pairStatus(node, &CircuitOpenError{}).HTTPStatus).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(pp.pairStatus(node, fmt.Errorf("failed")).HTTPStatus).To(Equal(http.StatusInternalServerError))
+}
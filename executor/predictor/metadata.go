@@ -14,6 +14,25 @@ type GraphMetadata struct {
 	GraphOutputs interface{}                      `json:"graphoutputs"`
 }
 
+// ServerMetadata is the V2 protocol server metadata of the executor. The name and version are those of the
+// predictor whose graph is served.
+type ServerMetadata struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	Extensions []string `json:"extensions"`
+}
+
+// ServerExtensions are the V2 protocol extensions served by the executor.
+var ServerExtensions = []string{"model_configuration", "model_repository", "statistics"}
+
+func (p *PredictorProcess) ServerMetadata(spec *v1.PredictorSpec) *ServerMetadata {
+	return &ServerMetadata{
+		Name:       spec.Name,
+		Version:    spec.Annotations["version"],
+		Extensions: ServerExtensions,
+	}
+}
+
 type MetadataTensor struct {
 	DataType string `json:"datatype,omitempty"`
 	Name     string `json:"name,omitempty"`
@@ -32,7 +51,11 @@ func (gm *GraphMetadata) getEdgeNodes(node *v1.PredictiveUnit) (
 	}
 
 	// Multi nodes graphs
-	if *node.Type == v1.MODEL || *node.Type == v1.TRANSFORMER {
+	if v1.IsExecutorImplementation(node) {
+		// Built-in routers return one child's output and the average combiner keeps the shape
+		// of its children's outputs.
+		return gm.getEdgeNodes(&node.Children[0])
+	} else if *node.Type == v1.MODEL || *node.Type == v1.TRANSFORMER {
 		// Ignore all children except first one for Models and Transformers
 		_, childOutput := gm.getEdgeNodes(&node.Children[0])
 		return &nodeMeta, childOutput
@@ -59,3 +82,54 @@ func (gm *GraphMetadata) getEdgeNodes(node *v1.PredictiveUnit) (
 	logger.Info("Unimplemented case: Couldn't derive graph-level inputs and outputs.")
 	return nil, nil
 }
+
+// getDAGEdgeNodes returns the metadata of the first node of a DAG graph given the request and of the node
+// whose output is returned. nodes must be in the order returned by dagNodes.
+func (gm *GraphMetadata) getDAGEdgeNodes(nodes []*v1.PredictiveUnit) (
+	input *payload.ModelMetadata, output *payload.ModelMetadata,
+) {
+	byName := map[string]*v1.PredictiveUnit{}
+	var inputNode *v1.PredictiveUnit
+	for _, node := range nodes {
+		byName[node.Name] = node
+		if inputNode != nil && !v1.IsExecutorImplementation(inputNode) {
+			continue
+		}
+		if len(node.Inputs) == 0 || hasInput(node, v1.GraphRequestInput) {
+			inputNode = node
+		}
+	}
+
+	// Built-in combiners keep the shape of their inputs' outputs so follow them back to a model
+	outputNode := nodes[len(nodes)-1]
+	for v1.IsExecutorImplementation(outputNode) {
+		next, ok := byName[firstNodeInput(outputNode)]
+		if !ok {
+			break
+		}
+		outputNode = next
+	}
+
+	inputMeta := gm.Models[inputNode.Name]
+	outputMeta := gm.Models[outputNode.Name]
+	return &inputMeta, &outputMeta
+}
+
+func hasInput(node *v1.PredictiveUnit, name string) bool {
+	for _, input := range node.Inputs {
+		if input == name {
+			return true
+		}
+	}
+	return false
+}
+
+// firstNodeInput returns the first input of a DAG node which is another node rather than the request.
+func firstNodeInput(node *v1.PredictiveUnit) string {
+	for _, input := range node.Inputs {
+		if input != v1.GraphRequestInput {
+			return input
+		}
+	}
+	return ""
+}
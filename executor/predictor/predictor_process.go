@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	guuid "github.com/google/uuid"
@@ -66,8 +67,16 @@ func hasMethod(method v1.PredictiveUnitMethod, methods *[]v1.PredictiveUnitMetho
 	return false
 }
 
+// isGrpcNode returns whether the node is called over gRPC.
+func (p *PredictorProcess) isGrpcNode(node *v1.PredictiveUnit) bool {
+	if nc, ok := p.Client.(client.SeldonNodeTransportClient); ok {
+		return nc.IsGrpcNode(node)
+	}
+	return p.Client.IsGrpc()
+}
+
 func (p *PredictorProcess) getPort(node *v1.PredictiveUnit) int32 {
-	if p.Client.IsGrpc() {
+	if p.isGrpcNode(node) {
 		return node.Endpoint.GrpcPort
 	} else {
 		return node.Endpoint.HttpPort
@@ -99,7 +108,20 @@ func (p *PredictorProcess) transformInput(node *v1.PredictiveUnit, msg payload.S
 
 	modelName := p.getModelName(node)
 
+	if node.Implementation != nil && *node.Implementation == v1.SIMPLE_MODEL {
+		_, step := p.startStep(node, StepTransformInput)
+		defer func() { step.end(err) }()
+
+		p.RoutingMutex.Lock()
+		p.Routing[node.Name] = -1
+		p.RoutingMutex.Unlock()
+		return simpleModel(msg)
+	}
+
 	if callModel || callTransformInput {
+		pp, step := p.startStep(node, StepTransformInput)
+		defer func() { step.end(err) }()
+
 		//Log Request
 		if node.Logger != nil && (node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll) {
 			err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceRequest, msg, puid)
@@ -108,7 +130,7 @@ func (p *PredictorProcess) transformInput(node *v1.PredictiveUnit, msg payload.S
 			}
 		}
 
-		msg, err := p.Client.Chain(p.Ctx, modelName, msg)
+		msg, err := p.Client.Chain(pp.Ctx, modelName, msg)
 		if err != nil {
 			return nil, err
 		}
@@ -116,10 +138,34 @@ func (p *PredictorProcess) transformInput(node *v1.PredictiveUnit, msg payload.S
 		p.Routing[node.Name] = -1
 		p.RoutingMutex.Unlock()
 
-		if callTransformInput {
-			tmsg, err = p.Client.TransformInput(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
-		} else {
-			tmsg, err = p.Client.Predict(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		method := client.SeldonTransformInputPath
+		if !callTransformInput {
+			method = client.SeldonPredictPath
+		}
+		start := time.Now()
+		tmsg, err = pp.cached(node.Name, method, node.Cache, false, msg, func() (payload.SeldonPayload, error) {
+			if !callTransformInput && node.Batching != nil {
+				return pp.batchedPredict(node, modelName, msg)
+			}
+			if !callTransformInput {
+				return pp.predictNode(pp.Ctx, node, modelName, msg, p.Meta.Meta, 1)
+			}
+			var tmsg payload.SeldonPayload
+			err := pp.callNode(node, func(ctx context.Context) error {
+				var err error
+				tmsg, err = p.Client.TransformInput(ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+				return err
+			})
+			return tmsg, err
+		})
+		step.observePayload(PayloadRequest, msg)
+		if err == nil {
+			step.observePayload(PayloadResponse, tmsg)
+		}
+		if node.Logger != nil && node.Logger.Mode == v1.LogPair {
+			if err := p.logPair(node, msg, tmsg, start, err, puid); err != nil {
+				return nil, err
+			}
 		}
 		if tmsg != nil && err == nil {
 			// Log Response
@@ -136,7 +182,7 @@ func (p *PredictorProcess) transformInput(node *v1.PredictiveUnit, msg payload.S
 	}
 }
 
-func (p *PredictorProcess) transformOutput(node *v1.PredictiveUnit, msg payload.SeldonPayload, puid string) (payload.SeldonPayload, error) {
+func (p *PredictorProcess) transformOutput(node *v1.PredictiveUnit, msg payload.SeldonPayload, puid string) (tmsg payload.SeldonPayload, err error) {
 	callClient := false
 	if (*node).Type != nil {
 		switch *node.Type {
@@ -151,6 +197,9 @@ func (p *PredictorProcess) transformOutput(node *v1.PredictiveUnit, msg payload.
 	modelName := p.getModelName(node)
 
 	if callClient {
+		pp, step := p.startStep(node, StepTransformOutput)
+		defer func() { step.end(err) }()
+
 		//Log Request
 		if node.Logger != nil && (node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll) {
 			err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceRequest, msg, puid)
@@ -159,11 +208,29 @@ func (p *PredictorProcess) transformOutput(node *v1.PredictiveUnit, msg payload.
 			}
 		}
 
-		msg, err := p.Client.Chain(p.Ctx, modelName, msg)
+		msg, err := p.Client.Chain(pp.Ctx, modelName, msg)
 		if err != nil {
 			return nil, err
 		}
-		tmsg, err := p.Client.TransformOutput(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		start := time.Now()
+		tmsg, err := pp.cached(node.Name, client.SeldonTransformOutputPath, node.Cache, false, msg, func() (payload.SeldonPayload, error) {
+			var tmsg payload.SeldonPayload
+			err := pp.callNode(node, func(ctx context.Context) error {
+				var err error
+				tmsg, err = p.Client.TransformOutput(ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+				return err
+			})
+			return tmsg, err
+		})
+		step.observePayload(PayloadRequest, msg)
+		if err == nil {
+			step.observePayload(PayloadResponse, tmsg)
+		}
+		if node.Logger != nil && node.Logger.Mode == v1.LogPair {
+			if err := p.logPair(node, msg, tmsg, start, err, puid); err != nil {
+				return nil, err
+			}
+		}
 		if tmsg != nil && err == nil {
 			// Log Response
 			if node.Logger != nil && (node.Logger.Mode == v1.LogResponse || node.Logger.Mode == v1.LogAll) {
@@ -181,6 +248,12 @@ func (p *PredictorProcess) transformOutput(node *v1.PredictiveUnit, msg payload.
 }
 
 func (p *PredictorProcess) feedback(node *v1.PredictiveUnit, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	if v1.IsBanditImplementation(node) {
+		return p.banditFeedback(node, msg)
+	} else if v1.IsExecutorImplementation(node) {
+		return msg, nil
+	}
+
 	callClient := false
 	if (*node).Type != nil {
 		switch *node.Type {
@@ -195,7 +268,7 @@ func (p *PredictorProcess) feedback(node *v1.PredictiveUnit, msg payload.SeldonP
 	modelName := p.getModelName(node)
 
 	if callClient {
-		return p.Client.Feedback(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		return p.Client.Feedback(client.WithNodeName(p.Ctx, node.Name), modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
 	} else {
 		return msg, nil
 	}
@@ -211,6 +284,19 @@ func (p *PredictorProcess) routeFeedback(node *v1.PredictiveUnit, msg payload.Se
 }
 
 func (p *PredictorProcess) route(node *v1.PredictiveUnit, msg payload.SeldonPayload) (int, error) {
+	if node.Implementation != nil {
+		switch *node.Implementation {
+		case v1.RANDOM_ABTEST:
+			return p.abTestRouter(node, msg)
+		case v1.SIMPLE_ROUTER:
+			return p.simpleRouter(node, msg)
+		case v1.RULES_ROUTER:
+			return p.rulesRouter(node, msg)
+		case v1.EPSILON_GREEDY, v1.THOMPSON_SAMPLING, v1.UCB:
+			return p.banditRouter(node)
+		}
+	}
+
 	callClient := false
 	if (*node).Type != nil {
 		switch *node.Type {
@@ -225,15 +311,29 @@ func (p *PredictorProcess) route(node *v1.PredictiveUnit, msg payload.SeldonPayl
 	modelName := p.getModelName(node)
 
 	if callClient {
-		return p.Client.Route(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
-	} else if node.Implementation != nil && *node.Implementation == v1.RANDOM_ABTEST {
-		return p.abTestRouter(node)
+		var route int
+		err := p.callNode(node, func(ctx context.Context) error {
+			var err error
+			route, err = p.Client.Route(ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+			return err
+		})
+		return route, err
 	} else {
 		return -1, nil
 	}
 }
 
-func (p *PredictorProcess) aggregate(node *v1.PredictiveUnit, cmsg []payload.SeldonPayload, msg payload.SeldonPayload, puid string) (payload.SeldonPayload, error) {
+func (p *PredictorProcess) aggregate(node *v1.PredictiveUnit, cmsg []payload.SeldonPayload, msg payload.SeldonPayload, puid string) (tmsg payload.SeldonPayload, err error) {
+	if node.Implementation != nil && *node.Implementation == v1.AVERAGE_COMBINER {
+		_, step := p.startStep(node, StepCombine)
+		defer func() { step.end(err) }()
+
+		p.RoutingMutex.Lock()
+		p.Routing[node.Name] = -1
+		p.RoutingMutex.Unlock()
+		return averagePayloads(cmsg)
+	}
+
 	callClient := false
 	if (*node).Type != nil {
 		switch *node.Type {
@@ -248,6 +348,9 @@ func (p *PredictorProcess) aggregate(node *v1.PredictiveUnit, cmsg []payload.Sel
 	modelName := p.getModelName(node)
 
 	if callClient {
+		pp, step := p.startStep(node, StepCombine)
+		defer func() { step.end(err) }()
+
 		//Log Request
 		if node.Logger != nil && (node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll) {
 			err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceRequest, msg, puid)
@@ -258,7 +361,22 @@ func (p *PredictorProcess) aggregate(node *v1.PredictiveUnit, cmsg []payload.Sel
 		p.RoutingMutex.Lock()
 		p.Routing[node.Name] = -1
 		p.RoutingMutex.Unlock()
-		tmsg, err := p.Client.Combine(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), cmsg, p.Meta.Meta)
+		var tmsg payload.SeldonPayload
+		start := time.Now()
+		err := pp.callNode(node, func(ctx context.Context) error {
+			var err error
+			tmsg, err = p.Client.Combine(ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), cmsg, p.Meta.Meta)
+			return err
+		})
+		step.observePayload(PayloadRequest, cmsg...)
+		if err == nil {
+			step.observePayload(PayloadResponse, tmsg)
+		}
+		if node.Logger != nil && node.Logger.Mode == v1.LogPair {
+			if err := p.logPair(node, msg, tmsg, start, err, puid); err != nil {
+				return nil, err
+			}
+		}
 		if tmsg != nil && err == nil {
 			// Log Response
 			if node.Logger != nil && (node.Logger.Mode == v1.LogResponse || node.Logger.Mode == v1.LogAll) {
@@ -276,12 +394,32 @@ func (p *PredictorProcess) aggregate(node *v1.PredictiveUnit, cmsg []payload.Sel
 
 func (p *PredictorProcess) predictChildren(node *v1.PredictiveUnit, msg payload.SeldonPayload, puid string) (payload.SeldonPayload, error) {
 	if node.Children != nil && len(node.Children) > 0 {
-		route, err := p.route(node, msg)
+		var route int
+		var err error
+		if v1.IsRouter(node) {
+			pp, step := p.startStep(node, StepRoute)
+			route, err = pp.route(node, msg)
+			if err == nil {
+				step.setRoute(route)
+			}
+			step.end(err)
+		} else {
+			route, err = p.route(node, msg)
+		}
 		if err != nil {
 			return nil, err
 		}
 		var cmsgs []payload.SeldonPayload
-		if route == -1 {
+		var dropped []string
+		if route == -1 && node.FanOut != nil {
+			cmsgs, dropped, err = p.predictChildrenWithQuorum(node, msg)
+			p.RoutingMutex.Lock()
+			p.Routing[node.Name] = -1
+			p.RoutingMutex.Unlock()
+			if err != nil {
+				return nil, err
+			}
+		} else if route == -1 {
 
 			cmsgs = make([]payload.SeldonPayload, len(node.Children))
 			var errs = make([]error, len(node.Children))
@@ -318,7 +456,11 @@ func (p *PredictorProcess) predictChildren(node *v1.PredictiveUnit, msg payload.
 				return cmsgs[0], err
 			}
 		}
-		return p.aggregate(node, cmsgs, msg, puid)
+		tmsg, err := p.aggregate(node, cmsgs, msg, puid)
+		if err == nil && len(dropped) > 0 {
+			return p.tagDroppedChildren(tmsg, dropped), nil
+		}
+		return tmsg, err
 	} else {
 		// Don't add routing for leaf nodes
 		return msg, nil
@@ -373,25 +515,36 @@ func (p *PredictorProcess) getLogUrl(logger *v1.Logger) (*url.URL, error) {
 }
 
 func (p *PredictorProcess) logPayload(nodeName string, logger *v1.Logger, reqType payloadLogger.LogRequestType, msg payload.SeldonPayload, puid string) error {
-	data, err := msg.GetBytes()
+	if !logSampled(logger, puid) {
+		return nil
+	}
+	data, contentType, contentEncoding, err := redactPayload(logger, msg)
 	if err != nil {
-		return err
+		// Never log a payload whose redaction failed, but don't fail the request either
+		p.Log.Error(err, "failed to redact payload, not logging it", "node", nodeName)
+		return nil
 	}
+	return p.queueLog(nodeName, logger, reqType, data, contentType, contentEncoding, puid)
+}
+
+func (p *PredictorProcess) queueLog(nodeName string, logger *v1.Logger, reqType payloadLogger.LogRequestType, data []byte, contentType string, contentEncoding string, puid string) error {
 	logUrl, err := p.getLogUrl(logger)
 	if err != nil {
 		return err
 	}
+	headers := p.logHeaders(logger)
 	go func() {
 		err := payloadLogger.QueueLogRequest(payloadLogger.LogRequest{
 			Url:             logUrl,
 			Bytes:           &data,
-			ContentType:     msg.GetContentType(),
-			ContentEncoding: msg.GetContentEncoding(),
+			ContentType:     contentType,
+			ContentEncoding: contentEncoding,
 			ReqType:         reqType,
 			Id:              guuid.New().String(),
 			SourceUri:       p.ServerUrl,
 			ModelId:         nodeName,
 			RequestId:       puid,
+			Headers:         headers,
 		})
 		if err != nil {
 			p.Log.Error(err, "failed to log request")
@@ -433,19 +586,19 @@ func (p *PredictorProcess) Predict(node *v1.PredictiveUnit, msg payload.SeldonPa
 	return response, err
 }
 
-func (p *PredictorProcess) Status(node *v1.PredictiveUnit, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
-	if nodeModel := v1.GetPredictiveUnit(node, modelName); nodeModel == nil {
+func (p *PredictorProcess) Status(spec *v1.PredictorSpec, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	if nodeModel := v1.GetPredictiveUnitForPredictor(spec, modelName); nodeModel == nil {
 		return nil, fmt.Errorf("Failed to find model %s", modelName)
 	} else {
-		return p.Client.Status(p.Ctx, modelName, nodeModel.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		return p.Client.Status(client.WithNodeName(p.Ctx, nodeModel.Name), modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
 	}
 }
 
-func (p *PredictorProcess) Metadata(node *v1.PredictiveUnit, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
-	if nodeModel := v1.GetPredictiveUnit(node, modelName); nodeModel == nil {
+func (p *PredictorProcess) Metadata(spec *v1.PredictorSpec, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	if nodeModel := v1.GetPredictiveUnitForPredictor(spec, modelName); nodeModel == nil {
 		return nil, fmt.Errorf("Failed to find model %s", modelName)
 	} else {
-		return p.Client.Metadata(p.Ctx, modelName, nodeModel.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		return p.Client.Metadata(client.WithNodeName(p.Ctx, nodeModel.Name), modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
 	}
 }
 
@@ -454,13 +607,31 @@ func (p *PredictorProcess) GraphMetadata(spec *v1.PredictorSpec) (*GraphMetadata
 	if err != nil {
 		return nil, err
 	}
+	for i := range spec.Nodes {
+		nodeMeta, err := p.ModelMetadataMap(&spec.Nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range nodeMeta {
+			metadataMap[k] = v
+		}
+	}
 
 	output := &GraphMetadata{
 		Name:   spec.Name,
 		Models: metadataMap,
 	}
 
-	inputNodeMeta, outputNodeMeta := output.getEdgeNodes(&spec.Graph)
+	var inputNodeMeta, outputNodeMeta *payload.ModelMetadata
+	if spec.IsDAG() {
+		nodes, err := dagNodes(spec)
+		if err != nil {
+			return nil, err
+		}
+		inputNodeMeta, outputNodeMeta = output.getDAGEdgeNodes(nodes)
+	} else {
+		inputNodeMeta, outputNodeMeta = output.getEdgeNodes(&spec.Graph)
+	}
 	output.GraphInputs = inputNodeMeta.Inputs
 	output.GraphOutputs = outputNodeMeta.Outputs
 
@@ -488,10 +659,26 @@ func (p *PredictorProcess) Feedback(node *v1.PredictiveUnit, msg payload.SeldonP
 	return p.feedback(node, msg)
 }
 
+// FeedbackGraph sends feedback through the predictor's graph. Every node of a DAG receives it.
+func (p *PredictorProcess) FeedbackGraph(spec *v1.PredictorSpec, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	for i := range spec.Nodes {
+		if tmsg, err := p.Feedback(&spec.Nodes[i], msg); err != nil {
+			return tmsg, err
+		}
+	}
+	return p.Feedback(&spec.Graph, msg)
+}
+
 func (p *PredictorProcess) ModelMetadataMap(node *v1.PredictiveUnit) (map[string]payload.ModelMetadata, error) {
-	resPayload, err := p.Client.ModelMetadata(p.Ctx, node.Name, node.Endpoint.ServiceHost, p.getPort(node), nil, p.Meta.Meta)
-	if err != nil {
-		return nil, err
+	var resPayload payload.ModelMetadata
+	if v1.IsExecutorImplementation(node) {
+		resPayload = payload.ModelMetadata{Name: node.Name}
+	} else {
+		var err error
+		resPayload, err = p.Client.ModelMetadata(client.WithNodeName(p.Ctx, node.Name), node.Name, node.Endpoint.ServiceHost, p.getPort(node), nil, p.Meta.Meta)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var output = map[string]payload.ModelMetadata{
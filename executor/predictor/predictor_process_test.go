@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"testing"
 
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -153,7 +154,7 @@ func TestStatus(t *testing.T) {
 		},
 	}
 
-	pResp, err := createPredictorProcess(t).Status(graph, modelName, nil)
+	pResp, err := createPredictorProcess(t).Status(&v1.PredictorSpec{Graph: *graph}, modelName, nil)
 	g.Expect(err).Should(BeNil())
 	smRes := string(pResp.GetPayload().([]byte))
 	g.Expect(smRes).To(Equal(test.TestClientStatusResponse))
@@ -178,7 +179,7 @@ func TestMetadata(t *testing.T) {
 	data := `{"metadata":{"name":"mymodel"}}`
 	metadataResponse := payload.BytesPayload{Msg: []byte(data)}
 
-	pResp, err := createPredictorProcessWithMetadata(t, &metadataResponse, nil).Metadata(graph, modelName, createMetadataPayload(g))
+	pResp, err := createPredictorProcessWithMetadata(t, &metadataResponse, nil).Metadata(&v1.PredictorSpec{Graph: *graph}, modelName, createMetadataPayload(g))
 	g.Expect(err).Should(BeNil())
 	smRes := string(pResp.GetPayload().([]byte))
 	g.Expect(smRes).To(Equal(data))
@@ -573,3 +574,203 @@ func TestPredictNilPUIDError(t *testing.T) {
 	g.Expect(err).NotTo(BeNil())
 	g.Expect(err.Error()).Should(Equal(NilPUIDError))
 }
+
+func TestAverageCombiner(t *testing.T) {
+	g := NewGomegaWithT(t)
+	model := v1.MODEL
+	combiner := v1.COMBINER
+	average := v1.AVERAGE_COMBINER
+	graph := &v1.PredictiveUnit{
+		Name:           "combiner",
+		Type:           &combiner,
+		Implementation: &average,
+		Children: []v1.PredictiveUnit{
+			{
+				Type: &model,
+				Endpoint: &v1.Endpoint{
+					ServiceHost: "foo2",
+					ServicePort: 9001,
+					Type:        v1.REST,
+				},
+			},
+			{
+				Type: &model,
+				Endpoint: &v1.Endpoint{
+					ServiceHost: "foo3",
+					ServicePort: 9002,
+					Type:        v1.REST,
+				},
+			},
+		},
+	}
+
+	pResp, err := createPredictorProcess(t).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	smRes := pResp.GetPayload().(*proto.SeldonMessage)
+	g.Expect(smRes.GetData().GetNdarray().Values[0].GetNumberValue()).Should(Equal(1.1))
+	g.Expect(smRes.GetData().GetNdarray().Values[1].GetNumberValue()).Should(Equal(2.0))
+}
+
+func createSimpleRouterGraph() *v1.PredictiveUnit {
+	model := v1.MODEL
+	router := v1.ROUTER
+	simpleRouter := v1.SIMPLE_ROUTER
+	return &v1.PredictiveUnit{
+		Name:           "router",
+		Type:           &router,
+		Implementation: &simpleRouter,
+		Children: []v1.PredictiveUnit{
+			{
+				Name: "model1",
+				Type: &model,
+				Endpoint: &v1.Endpoint{
+					ServiceHost: "foo2",
+					ServicePort: 9001,
+					Type:        v1.REST,
+				},
+			},
+			{
+				Name: "model2",
+				Type: &model,
+				Endpoint: &v1.Endpoint{
+					ServiceHost: "foo3",
+					ServicePort: 9002,
+					Type:        v1.REST,
+				},
+			},
+		},
+	}
+}
+
+func TestSimpleRouterDefault(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pp := createPredictorProcess(t)
+	_, err := pp.Predict(createSimpleRouterGraph(), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(pp.Routing["router"]).Should(Equal(int32(0)))
+}
+
+func TestSimpleRouterHeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pp := createPredictorProcess(t)
+	pp.Meta.Meta["seldon-route"] = []string{"1"}
+	_, err := pp.Predict(createSimpleRouterGraph(), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(pp.Routing["router"]).Should(Equal(int32(1)))
+
+	pp = createPredictorProcess(t)
+	pp.Meta.Meta["Seldon-Route"] = []string{"2"}
+	_, err = pp.Predict(createSimpleRouterGraph(), createPredictPayload(g))
+	g.Expect(err).ShouldNot(BeNil())
+}
+
+func TestSimpleRouterMetaTag(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createSimpleRouterGraph()
+	graph.Parameters = []v1.Parameter{{Name: SimpleRouterTagParameter, Value: "variant", Type: v1.STRING}}
+	var sm proto.SeldonMessage
+	err := jsonpb.UnmarshalString(`{"meta":{"tags":{"variant":1}},"data":{"ndarray":[1.1,2.0]}}`, &sm)
+	g.Expect(err).Should(BeNil())
+
+	pp := createPredictorProcess(t)
+	_, err = pp.Predict(graph, &payload.ProtoPayload{Msg: &sm})
+	g.Expect(err).Should(BeNil())
+	g.Expect(pp.Routing["router"]).Should(Equal(int32(1)))
+
+	pp = createPredictorProcess(t)
+	msg := &payload.BytesPayload{Msg: []byte(`{"meta":{"tags":{"variant":1}},"data":{"ndarray":[1.1,2.0]}}`), ContentType: "application/json"}
+	route, err := pp.route(graph, msg)
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(1))
+}
+
+func createABTestGraph(params []v1.Parameter) *v1.PredictiveUnit {
+	model := v1.MODEL
+	abtest := v1.RANDOM_ABTEST
+	graph := &v1.PredictiveUnit{
+		Name:           "abtest",
+		Implementation: &abtest,
+		Parameters:     params,
+	}
+	for i := 0; i < 3; i++ {
+		graph.Children = append(graph.Children, v1.PredictiveUnit{
+			Name: fmt.Sprintf("model%d", i),
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: int32(9001 + i),
+				Type:        v1.REST,
+			},
+		})
+	}
+	return graph
+}
+
+func TestABTestWeights(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createABTestGraph([]v1.Parameter{{Name: v1.ABTestWeightsParameter, Value: "0,0,1", Type: v1.STRING}})
+	for i := 0; i < 10; i++ {
+		pp := createPredictorProcess(t)
+		_, err := pp.Predict(graph, createPredictPayload(g))
+		g.Expect(err).Should(BeNil())
+		g.Expect(pp.Routing["abtest"]).Should(Equal(int32(2)))
+	}
+}
+
+func TestABTestStickyHeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createABTestGraph([]v1.Parameter{
+		{Name: v1.ABTestWeightsParameter, Value: "1,1,1", Type: v1.STRING},
+		{Name: ABTestStickyHeaderParameter, Value: "X-User-Id", Type: v1.STRING},
+	})
+	routes := map[int]bool{}
+	for user := 0; user < 50; user++ {
+		first := -1
+		for i := 0; i < 5; i++ {
+			pp := createPredictorProcess(t)
+			pp.Meta.Meta["X-User-Id"] = []string{strconv.Itoa(user)}
+			route, err := pp.route(graph, createPredictPayload(g))
+			g.Expect(err).Should(BeNil())
+			if first == -1 {
+				first = route
+			}
+			g.Expect(route).Should(Equal(first))
+		}
+		routes[first] = true
+	}
+	g.Expect(routes).Should(HaveLen(3))
+}
+
+func TestABTestStickyTag(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createABTestGraph([]v1.Parameter{
+		{Name: v1.ABTestWeightsParameter, Value: "1,1,1", Type: v1.STRING},
+		{Name: ABTestStickyTagParameter, Value: "user", Type: v1.STRING},
+	})
+	msg := &payload.BytesPayload{Msg: []byte(`{"meta":{"tags":{"user":"alice"}},"data":{"ndarray":[1.1,2.0]}}`), ContentType: "application/json"}
+	first, err := createPredictorProcess(t).route(graph, msg)
+	g.Expect(err).Should(BeNil())
+	for i := 0; i < 5; i++ {
+		route, err := createPredictorProcess(t).route(graph, msg)
+		g.Expect(err).Should(BeNil())
+		g.Expect(route).Should(Equal(first))
+	}
+}
+
+func TestSimpleModel(t *testing.T) {
+	g := NewGomegaWithT(t)
+	simpleModel := v1.SIMPLE_MODEL
+	graph := &v1.PredictiveUnit{
+		Name:           "model",
+		Implementation: &simpleModel,
+	}
+
+	pp := createPredictorProcess(t)
+	pResp, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	smRes := pResp.GetPayload().(*proto.SeldonMessage)
+	g.Expect(smRes.GetData().GetNames()).Should(Equal(SimpleModelNames))
+	g.Expect(smRes.GetData().GetTensor().GetShape()).Should(Equal([]int32{1, 3}))
+	g.Expect(smRes.GetData().GetTensor().GetValues()).Should(Equal(SimpleModelValues))
+	g.Expect(pp.Routing["model"]).Should(Equal(int32(-1)))
+}
@@ -1,9 +1,9 @@
 package predictor
 
 import (
-	"fmt"
 	"github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	"net"
+	"strconv"
 )
 
 func Ready(node *v1.PredictiveUnit) error {
@@ -14,7 +14,7 @@ func Ready(node *v1.PredictiveUnit) error {
 		}
 	}
 	if node.Endpoint != nil && node.Endpoint.ServiceHost != "" && node.Endpoint.ServicePort > 0 {
-		c, err := net.Dial("tcp", fmt.Sprintf("%s:%d", node.Endpoint.ServiceHost, node.Endpoint.ServicePort))
+		c, err := net.Dial("tcp", net.JoinHostPort(node.Endpoint.ServiceHost, strconv.Itoa(int(node.Endpoint.ServicePort))))
 		if err != nil {
 			return err
 		} else {
@@ -25,3 +25,16 @@ func Ready(node *v1.PredictiveUnit) error {
 		return nil
 	}
 }
+
+// ReadyPredictor checks every node of the predictor's graph, including the nodes of a DAG graph, is ready.
+func ReadyPredictor(spec *v1.PredictorSpec) error {
+	if err := Ready(&spec.Graph); err != nil {
+		return err
+	}
+	for i := range spec.Nodes {
+		if err := Ready(&spec.Nodes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package predictor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func (p *PredictorProcess) repositoryClient() (client.SeldonRepositoryApiClient, error) {
+	rc, ok := p.Client.(client.SeldonRepositoryApiClient)
+	if !ok {
+		return nil, fmt.Errorf("model repository calls are not supported by the %T client", p.Client)
+	}
+	return rc, nil
+}
+
+func (p *PredictorProcess) findModelNode(spec *v1.PredictorSpec, modelName string) (*v1.PredictiveUnit, error) {
+	nodeModel := v1.GetPredictiveUnitForPredictor(spec, modelName)
+	if nodeModel == nil || nodeModel.Endpoint == nil || v1.IsExecutorImplementation(nodeModel) {
+		return nil, &ModelNotFoundError{ModelName: modelName}
+	}
+	return nodeModel, nil
+}
+
+// ModelConfig returns the configuration of a model as reported by its graph node.
+func (p *PredictorProcess) ModelConfig(spec *v1.PredictorSpec, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	rc, err := p.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	nodeModel, err := p.findModelNode(spec, modelName)
+	if err != nil {
+		return nil, err
+	}
+	return rc.ModelConfig(client.WithNodeName(p.Ctx, nodeModel.Name), modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
+}
+
+// RepositoryModelLoad asks the graph node of a model to load it.
+func (p *PredictorProcess) RepositoryModelLoad(spec *v1.PredictorSpec, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	rc, err := p.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	nodeModel, err := p.findModelNode(spec, modelName)
+	if err != nil {
+		return nil, err
+	}
+	return rc.RepositoryModelLoad(client.WithNodeName(p.Ctx, nodeModel.Name), modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
+}
+
+// RepositoryModelUnload asks the graph node of a model to unload it.
+func (p *PredictorProcess) RepositoryModelUnload(spec *v1.PredictorSpec, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	rc, err := p.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	nodeModel, err := p.findModelNode(spec, modelName)
+	if err != nil {
+		return nil, err
+	}
+	return rc.RepositoryModelUnload(client.WithNodeName(p.Ctx, nodeModel.Name), modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
+}
+
+// RepositoryIndex asks each distinct endpoint in the graph for its model repository index and merges them.
+func (p *PredictorProcess) RepositoryIndex(spec *v1.PredictorSpec, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	rc, err := p.repositoryClient()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var indexes []payload.SeldonPayload
+	for _, pu := range v1.GetPredictiveUnitListForPredictor(spec) {
+		if pu.Endpoint == nil || v1.IsExecutorImplementation(pu) {
+			continue
+		}
+		address := net.JoinHostPort(pu.Endpoint.ServiceHost, strconv.Itoa(int(p.getPort(pu))))
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		index, err := rc.RepositoryIndex(client.WithNodeName(p.Ctx, pu.Name), pu.Name, pu.Endpoint.ServiceHost, p.getPort(pu), msg, p.Meta.Meta)
+		if err != nil {
+			return index, err
+		}
+		indexes = append(indexes, index)
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no graph nodes with a model repository")
+	}
+	return mergeRepositoryIndexes(indexes)
+}
+
+// mergeRepositoryIndexes joins V2 repository indexes in gRPC or REST form, keeping the first entry for each
+// model name and version.
+func mergeRepositoryIndexes(indexes []payload.SeldonPayload) (payload.SeldonPayload, error) {
+	if len(indexes) == 1 {
+		return indexes[0], nil
+	}
+	seen := make(map[string]bool)
+	if _, ok := indexes[0].GetPayload().(*inference.RepositoryIndexResponse); ok {
+		merged := &inference.RepositoryIndexResponse{}
+		for _, index := range indexes {
+			resp, ok := index.GetPayload().(*inference.RepositoryIndexResponse)
+			if !ok {
+				return nil, fmt.Errorf("unable to merge repository index of type %T", index.GetPayload())
+			}
+			for _, model := range resp.GetModels() {
+				key := model.GetName() + "/" + model.GetVersion()
+				if !seen[key] {
+					seen[key] = true
+					merged.Models = append(merged.Models, model)
+				}
+			}
+		}
+		return &payload.ProtoPayload{Msg: merged}, nil
+	}
+
+	merged := []map[string]interface{}{}
+	for _, index := range indexes {
+		b, err := index.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		var models []map[string]interface{}
+		if err := json.Unmarshal(b, &models); err != nil {
+			return nil, err
+		}
+		for _, model := range models {
+			key := fmt.Sprintf("%v/%v", model["name"], model["version"])
+			if !seen[key] {
+				seen[key] = true
+				merged = append(merged, model)
+			}
+		}
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	return &payload.BytesPayload{Msg: b, ContentType: indexes[0].GetContentType()}, nil
+}
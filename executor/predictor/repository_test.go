@@ -0,0 +1,53 @@
+package predictor
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestMergeRepositoryIndexesJson(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	indexes := []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`[{"name":"a","version":"1","state":"READY"},{"name":"b","version":"1","state":"READY"}]`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`[{"name":"b","version":"1","state":"UNAVAILABLE"},{"name":"c","version":"2","state":"READY"}]`), ContentType: "application/json"},
+	}
+	merged, err := mergeRepositoryIndexes(indexes)
+	g.Expect(err).Should(BeNil())
+	g.Expect(merged.GetContentType()).Should(Equal("application/json"))
+	var models []map[string]interface{}
+	g.Expect(json.Unmarshal(merged.GetPayload().([]byte), &models)).Should(BeNil())
+	g.Expect(models).Should(HaveLen(3))
+	g.Expect(models[1]["state"]).Should(Equal("READY"))
+	g.Expect(models[2]["name"]).Should(Equal("c"))
+}
+
+func TestMergeRepositoryIndexesProto(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	indexes := []payload.SeldonPayload{
+		&payload.ProtoPayload{Msg: &inference.RepositoryIndexResponse{Models: []*inference.RepositoryIndexResponse_ModelIndex{{Name: "a", Version: "1"}}}},
+		&payload.ProtoPayload{Msg: &inference.RepositoryIndexResponse{Models: []*inference.RepositoryIndexResponse_ModelIndex{{Name: "a", Version: "1"}, {Name: "a", Version: "2"}}}},
+	}
+	merged, err := mergeRepositoryIndexes(indexes)
+	g.Expect(err).Should(BeNil())
+	models := merged.GetPayload().(*inference.RepositoryIndexResponse).GetModels()
+	g.Expect(models).Should(HaveLen(2))
+	g.Expect(models[1].GetVersion()).Should(Equal("2"))
+}
+
+func TestRepositoryCallsUnsupportedClient(t *testing.T) {
+	g := NewGomegaWithT(t)
+	calls := int32(0)
+	pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls})
+
+	_, err := pp.ModelConfig(&v1.PredictorSpec{Graph: *createResilientModel("repo-model")}, "repo-model", nil)
+	g.Expect(err).ShouldNot(BeNil())
+	_, err = pp.RepositoryIndex(&v1.PredictorSpec{Graph: *createResilientModel("repo-model")}, nil)
+	g.Expect(err).ShouldNot(BeNil())
+}
@@ -0,0 +1,219 @@
+package predictor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultRetryBackoffMs        = 100
+	DefaultMaxRetryBackoffMs     = 10000
+	DefaultCircuitOpenDurationMs = 30000
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type CircuitOpenError struct {
+	NodeName string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for graph node %s", e.NodeName)
+}
+
+type circuitBreaker struct {
+	mu           sync.Mutex
+	nodeName     string
+	threshold    int32
+	openDuration time.Duration
+	state        circuitState
+	failures     int32
+	openedAt     time.Time
+	now          func() time.Time
+}
+
+var (
+	circuitBreakers      = make(map[string]*circuitBreaker)
+	circuitBreakersMutex = &sync.Mutex{}
+
+	resilienceMetrics     *metric.ResilienceMetrics
+	resilienceMetricsOnce sync.Once
+)
+
+func getResilienceMetrics() *metric.ResilienceMetrics {
+	resilienceMetricsOnce.Do(func() {
+		resilienceMetrics = metric.NewResilienceMetrics()
+	})
+	return resilienceMetrics
+}
+
+// Circuit breaker state is kept per node name for the lifetime of the executor as a new
+// PredictorProcess is created for each request.
+func getCircuitBreaker(node *v1.PredictiveUnit) *circuitBreaker {
+	if node.CircuitBreaker == nil {
+		return nil
+	}
+	circuitBreakersMutex.Lock()
+	defer circuitBreakersMutex.Unlock()
+	cb, ok := circuitBreakers[node.Name]
+	if !ok {
+		openDurationMs := node.CircuitBreaker.OpenDurationMs
+		if openDurationMs <= 0 {
+			openDurationMs = DefaultCircuitOpenDurationMs
+		}
+		cb = &circuitBreaker{
+			nodeName:     node.Name,
+			threshold:    node.CircuitBreaker.FailureThreshold,
+			openDuration: time.Duration(openDurationMs) * time.Millisecond,
+			now:          time.Now,
+		}
+		circuitBreakers[node.Name] = cb
+	}
+	return cb
+}
+
+// allow returns whether a call may proceed. Once the open duration has passed a single trial
+// call is let through; its result decides whether the circuit closes or opens again.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case circuitOpen:
+		if cb.now().Sub(cb.openedAt) >= cb.openDuration {
+			cb.state = circuitHalfOpen
+			return true
+		}
+		return false
+	case circuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) onSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures = 0
+	if cb.state != circuitClosed {
+		cb.state = circuitClosed
+		getResilienceMetrics().CircuitOpenGauge.WithLabelValues(cb.nodeName).Set(0)
+	}
+}
+
+func (cb *circuitBreaker) onFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	if cb.state == circuitHalfOpen || cb.failures >= cb.threshold {
+		cb.state = circuitOpen
+		cb.openedAt = cb.now()
+		getResilienceMetrics().CircuitOpenGauge.WithLabelValues(cb.nodeName).Set(1)
+	}
+}
+
+func retryBackoff(retries *v1.RetryPolicy, attempt int) time.Duration {
+	backoffMs := int64(retries.InitialBackoffMs)
+	if backoffMs <= 0 {
+		backoffMs = DefaultRetryBackoffMs
+	}
+	maxBackoffMs := int64(retries.MaxBackoffMs)
+	if maxBackoffMs <= 0 {
+		maxBackoffMs = DefaultMaxRetryBackoffMs
+	}
+	for i := 0; i < attempt && backoffMs < maxBackoffMs; i++ {
+		backoffMs *= 2
+	}
+	if backoffMs > maxBackoffMs {
+		backoffMs = maxBackoffMs
+	}
+	return time.Duration(backoffMs) * time.Millisecond
+}
+
+// isTransient returns whether a failed call may succeed if made again: the node could not be reached, timed out or
+// failed with a server error. Errors such as invalid requests are the caller's, so are neither retried nor counted
+// against the node's circuit breaker.
+func isTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var statusErr interface{ HTTPStatus() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatus() >= http.StatusInternalServerError
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// callNode runs a client call for a node applying the node's timeout, retry policy and circuit breaker.
+func (p *PredictorProcess) callNode(node *v1.PredictiveUnit, call func(ctx context.Context) error) (err error) {
+	cb := getCircuitBreaker(node)
+	maxRetries := 0
+	if node.Retries != nil {
+		maxRetries = int(node.Retries.MaxRetries)
+	}
+	for attempt := 0; ; attempt++ {
+		if cb != nil && !cb.allow() {
+			getResilienceMetrics().CircuitRejectedCounter.WithLabelValues(node.Name).Inc()
+			if err == nil {
+				err = &CircuitOpenError{NodeName: node.Name}
+			}
+			return err
+		}
+
+		ctx := client.WithNodeName(p.Ctx, node.Name)
+		var cancel context.CancelFunc
+		if node.TimeoutMs > 0 {
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(node.TimeoutMs)*time.Millisecond)
+		}
+		err = call(ctx)
+		if cancel != nil {
+			cancel()
+		}
+
+		if err == nil || !isTransient(err) {
+			// A node rejecting the request is still serving
+			if cb != nil {
+				cb.onSuccess()
+			}
+			return err
+		}
+		if cb != nil {
+			cb.onFailure()
+		}
+		if attempt >= maxRetries || p.Ctx.Err() != nil {
+			return err
+		}
+
+		p.Log.Info("Retrying call to graph node", "node", node.Name, "attempt", attempt+1, "error", err.Error())
+		getResilienceMetrics().RetriesCounter.WithLabelValues(node.Name).Inc()
+		select {
+		case <-time.After(retryBackoff(node.Retries, attempt)):
+		case <-p.Ctx.Done():
+			return err
+		}
+	}
+}
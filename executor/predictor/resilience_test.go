@@ -0,0 +1,173 @@
+package predictor
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type flakyTestClient struct {
+	test.SeldonMessageTestClient
+	failures int32
+	calls    *int32
+	delay    time.Duration
+	err      error
+}
+
+func (f flakyTestClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	call := atomic.AddInt32(f.calls, 1)
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if call <= f.failures {
+		if f.err != nil {
+			return nil, f.err
+		}
+		return nil, errors.New("unavailable")
+	}
+	return msg, nil
+}
+
+func createPredictorProcessWithClient(t *testing.T, client flakyTestClient) *PredictorProcess {
+	url, _ := url.Parse(testSourceUrl)
+	ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, testSeldonPuid)
+	pp := NewPredictorProcess(ctx, client, logf.Log.WithName("SeldonMessageRestClient"), url, "default", map[string][]string{}, "")
+	return &pp
+}
+
+func createResilientModel(name string) *v1.PredictiveUnit {
+	model := v1.MODEL
+	return &v1.PredictiveUnit{
+		Name: name,
+		Type: &model,
+		Endpoint: &v1.Endpoint{
+			ServiceHost: "foo",
+			ServicePort: 9000,
+			Type:        v1.REST,
+		},
+	}
+}
+
+func TestRetriesUntilSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createResilientModel("retry-model")
+	graph.Retries = &v1.RetryPolicy{MaxRetries: 2, InitialBackoffMs: 1}
+
+	calls := int32(0)
+	pp := createPredictorProcessWithClient(t, flakyTestClient{failures: 2, calls: &calls})
+	_, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(calls).To(Equal(int32(3)))
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createResilientModel("retry-exhausted-model")
+	graph.Retries = &v1.RetryPolicy{MaxRetries: 1, InitialBackoffMs: 1}
+
+	calls := int32(0)
+	pp := createPredictorProcessWithClient(t, flakyTestClient{failures: 5, calls: &calls})
+	_, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).ShouldNot(BeNil())
+	g.Expect(calls).To(Equal(int32(2)))
+}
+
+func TestNodeTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createResilientModel("timeout-model")
+	graph.TimeoutMs = 10
+
+	calls := int32(0)
+	pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls, delay: time.Second})
+	_, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(Equal(context.DeadlineExceeded))
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createResilientModel("circuit-model")
+	graph.CircuitBreaker = &v1.CircuitBreaker{FailureThreshold: 2, OpenDurationMs: 60000}
+
+	calls := int32(0)
+	pp := createPredictorProcessWithClient(t, flakyTestClient{failures: 100, calls: &calls})
+	for i := 0; i < 2; i++ {
+		_, err := pp.Predict(graph, createPredictPayload(g))
+		g.Expect(err).ShouldNot(BeNil())
+	}
+	resp, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).To(BeAssignableToTypeOf(&CircuitOpenError{}))
+	g.Expect(resp).To(BeNil())
+	g.Expect(calls).To(Equal(int32(2)))
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createResilientModel("circuit-half-open-model")
+	graph.CircuitBreaker = &v1.CircuitBreaker{FailureThreshold: 1, OpenDurationMs: 1000}
+
+	now := time.Now()
+	cb := getCircuitBreaker(graph)
+	cb.now = func() time.Time { return now }
+
+	g.Expect(cb.allow()).To(BeTrue())
+	cb.onFailure()
+	g.Expect(cb.allow()).To(BeFalse())
+
+	now = now.Add(2 * time.Second)
+	g.Expect(cb.allow()).To(BeTrue())
+	// Only a single trial call while half open
+	g.Expect(cb.allow()).To(BeFalse())
+	cb.onSuccess()
+	g.Expect(cb.allow()).To(BeTrue())
+}
+
+func TestClientErrorsNotRetried(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createResilientModel("client-error-model")
+	graph.Retries = &v1.RetryPolicy{MaxRetries: 2, InitialBackoffMs: 1}
+	graph.CircuitBreaker = &v1.CircuitBreaker{FailureThreshold: 1, OpenDurationMs: 60000}
+
+	calls := int32(0)
+	pp := createPredictorProcessWithClient(t, flakyTestClient{failures: 100, calls: &calls, err: status.Error(codes.InvalidArgument, "bad request")})
+	for i := 0; i < 2; i++ {
+		_, err := pp.Predict(graph, createPredictPayload(g))
+		g.Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	}
+	// Neither retried nor opening the circuit
+	g.Expect(calls).To(Equal(int32(2)))
+}
+
+func TestIsTransient(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(isTransient(errors.New("connection refused"))).To(BeTrue())
+	g.Expect(isTransient(context.DeadlineExceeded)).To(BeTrue())
+	g.Expect(isTransient(statusError(503))).To(BeTrue())
+	g.Expect(isTransient(statusError(400))).To(BeFalse())
+	g.Expect(isTransient(status.Error(codes.Unavailable, "down"))).To(BeTrue())
+	g.Expect(isTransient(status.Error(codes.NotFound, "no model"))).To(BeFalse())
+}
+
+func TestRetryBackoff(t *testing.T) {
+	g := NewGomegaWithT(t)
+	retries := &v1.RetryPolicy{InitialBackoffMs: 10, MaxBackoffMs: 50}
+	g.Expect(retryBackoff(retries, 0)).To(Equal(10 * time.Millisecond))
+	g.Expect(retryBackoff(retries, 2)).To(Equal(40 * time.Millisecond))
+	g.Expect(retryBackoff(retries, 3)).To(Equal(50 * time.Millisecond))
+	// Without a maximum the backoff is capped by default rather than overflowing
+	g.Expect(retryBackoff(&v1.RetryPolicy{}, 100)).To(Equal(DefaultMaxRetryBackoffMs * time.Millisecond))
+}
@@ -0,0 +1,289 @@
+package predictor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Compiled regular expressions of routing rules keyed by pattern
+var ruleRegexps sync.Map
+
+// Parsed JSONPaths of routing rules keyed by path
+var ruleJSONPaths sync.Map
+
+// ruleJSONPath holds parsed copies of a rule's JSONPath, as a JSONPath can only find results in one request at a
+// time. Paths with range blocks change as they are applied so are parsed for each request.
+type ruleJSONPath struct {
+	path     string
+	reusable bool
+	pool     sync.Pool
+}
+
+func getRuleJSONPath(path string) (*ruleJSONPath, error) {
+	if rj, ok := ruleJSONPaths.Load(path); ok {
+		return rj.(*ruleJSONPath), nil
+	}
+	parser, err := jsonpath.Parse("rule", path)
+	if err != nil {
+		return nil, err
+	}
+	rj := &ruleJSONPath{path: path, reusable: !hasRange(parser.Root)}
+	rj.pool.New = func() interface{} {
+		j := jsonpath.New("rule").AllowMissingKeys(true)
+		// The path was parsed when it was cached so can't fail
+		_ = j.Parse(rj.path)
+		return j
+	}
+	cached, _ := ruleJSONPaths.LoadOrStore(path, rj)
+	return cached.(*ruleJSONPath), nil
+}
+
+func hasRange(node *jsonpath.ListNode) bool {
+	for _, n := range node.Nodes {
+		switch n := n.(type) {
+		case *jsonpath.ListNode:
+			if hasRange(n) {
+				return true
+			}
+		case *jsonpath.IdentifierNode:
+			if n.Name == "range" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findResults applies the JSONPath to a request.
+func (rj *ruleJSONPath) findResults(doc interface{}) ([][]reflect.Value, error) {
+	j := rj.pool.Get().(*jsonpath.JSONPath)
+	results, err := j.FindResults(doc)
+	if rj.reusable {
+		rj.pool.Put(j)
+	}
+	return results, err
+}
+
+// rulesRouter routes to the child of the first rule whose conditions all match the request. Requests matching
+// no rule are sent to the first child, as for SIMPLE_ROUTER.
+func (p *PredictorProcess) rulesRouter(node *v1.PredictiveUnit, msg payload.SeldonPayload) (int, error) {
+	req := &ruleRequest{msg: msg}
+	for i := range node.Rules {
+		rule := &node.Rules[i]
+		matched, err := p.matchRule(rule, req)
+		if err != nil {
+			return 0, fmt.Errorf("rule %d of %s: %w", i, node.Name, err)
+		}
+		if matched {
+			if rule.Route != -2 && (rule.Route < 0 || int(rule.Route) >= len(node.Children)) {
+				return 0, fmt.Errorf("route %d out of range for %s with %d children", rule.Route, node.Name, len(node.Children))
+			}
+			return int(rule.Route), nil
+		}
+	}
+	return 0, nil
+}
+
+func (p *PredictorProcess) matchRule(rule *v1.RoutingRule, req *ruleRequest) (bool, error) {
+	if rule.Header != nil {
+		value, ok := p.getHeader(rule.Header.Name)
+		if !ok {
+			return false, nil
+		}
+		if matched, err := matchValue(value, rule.Header.Equals, rule.Header.Regex); !matched || err != nil {
+			return false, err
+		}
+	}
+	if rule.MetaTag != nil {
+		value := getMetaTagValue(req.msg, rule.MetaTag.Name)
+		if value == nil {
+			return false, nil
+		}
+		if matched, err := matchValue(ruleValueString(value), rule.MetaTag.Equals, rule.MetaTag.Regex); !matched || err != nil {
+			return false, err
+		}
+	}
+	if rule.JSONPath != nil {
+		value, ok, err := req.jsonPathValue(rule.JSONPath.Path)
+		if !ok || err != nil {
+			return false, err
+		}
+		if matched, err := matchValue(value, rule.JSONPath.Equals, rule.JSONPath.Regex); !matched || err != nil {
+			return false, err
+		}
+	}
+	if rule.Feature != nil {
+		if matched, err := req.matchFeature(rule.Feature); !matched || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchValue(value string, equals string, regex string) (bool, error) {
+	if regex == "" {
+		return value == equals, nil
+	}
+	re, ok := ruleRegexps.Load(regex)
+	if !ok {
+		compiled, err := regexp.Compile(regex)
+		if err != nil {
+			return false, err
+		}
+		re, _ = ruleRegexps.LoadOrStore(regex, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(value), nil
+}
+
+// ruleValueString formats a JSON value for matching, so strings are compared without quotes and numbers
+// as they would be written in JSON.
+func ruleValueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// ruleRequest lazily decodes the request payload for the rules which need it.
+type ruleRequest struct {
+	msg  payload.SeldonPayload
+	data []byte
+	doc  map[string]interface{}
+}
+
+func (r *ruleRequest) json() (map[string]interface{}, error) {
+	if r.doc != nil {
+		return r.doc, nil
+	}
+	msg, err := payload.ProtoToJSON(r.msg)
+	if err != nil {
+		return nil, err
+	}
+	if r.data, err = payload.DecompressSeldonPayload(msg); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(r.data, &r.doc); err != nil {
+		return nil, fmt.Errorf("unable to apply rules to a request that isn't a JSON object: %w", err)
+	}
+	return r.doc, nil
+}
+
+func (r *ruleRequest) jsonPathValue(path string) (string, bool, error) {
+	doc, err := r.json()
+	if err != nil {
+		return "", false, err
+	}
+	rj, err := getRuleJSONPath(path)
+	if err != nil {
+		return "", false, err
+	}
+	results, err := rj.findResults(doc)
+	if err != nil {
+		return "", false, err
+	}
+	if len(results) == 0 || len(results[0]) == 0 || !results[0][0].IsValid() || !results[0][0].CanInterface() {
+		return "", false, nil
+	}
+	return ruleValueString(results[0][0].Interface()), true, nil
+}
+
+func (r *ruleRequest) matchFeature(cond *v1.FeatureCondition) (bool, error) {
+	threshold, err := strconv.ParseFloat(cond.Value, 64)
+	if err != nil {
+		return false, err
+	}
+	value, ok, err := r.feature(cond)
+	if !ok || err != nil {
+		return false, err
+	}
+	switch cond.Operator {
+	case v1.FeatureGreaterThan:
+		return value > threshold, nil
+	case v1.FeatureGreaterThanOrEqual:
+		return value >= threshold, nil
+	case v1.FeatureLessThan:
+		return value < threshold, nil
+	case v1.FeatureLessThanOrEqual:
+		return value <= threshold, nil
+	case v1.FeatureEqual:
+		return value == threshold, nil
+	}
+	return false, fmt.Errorf("unknown feature operator %s", cond.Operator)
+}
+
+// feature returns the feature of the first row of the request data selected by the condition, or false if
+// the request has no such feature.
+func (r *ruleRequest) feature(cond *v1.FeatureCondition) (float64, bool, error) {
+	doc, err := r.json()
+	if err != nil {
+		return 0, false, err
+	}
+	protocol := api.ProtocolSeldon
+	if _, ok := doc["inputs"]; ok {
+		protocol = api.ProtocolKFServing
+	} else if _, ok := doc["instances"]; ok {
+		protocol = api.ProtocolTensorflow
+	}
+	tensors, err := payload.DecodeTensors(protocol, r.data, false)
+	if err != nil || len(tensors) == 0 {
+		return 0, false, err
+	}
+	tensor := &tensors[0]
+	index := int(cond.Index)
+	if cond.Name != "" {
+		if protocol == api.ProtocolSeldon {
+			if index = seldonFeatureIndex(doc, cond.Name); index < 0 {
+				return 0, false, nil
+			}
+		} else if tensor = findTensor(tensors, cond.Name); tensor == nil {
+			return 0, false, nil
+		}
+	}
+	rowSize := len(tensor.Data)
+	if len(tensor.Shape) > 1 && tensor.Shape[0] > 0 {
+		rowSize /= int(tensor.Shape[0])
+	}
+	if index >= rowSize {
+		return 0, false, nil
+	}
+	switch v := tensor.Data[index].(type) {
+	case float64:
+		return v, true, nil
+	case int64:
+		return float64(v), true, nil
+	}
+	return 0, false, nil
+}
+
+func seldonFeatureIndex(doc map[string]interface{}, name string) int {
+	data, _ := doc["data"].(map[string]interface{})
+	names, _ := data["names"].([]interface{})
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func findTensor(tensors []payload.Tensor, name string) *payload.Tensor {
+	for i := range tensors {
+		if tensors[i].Name == name {
+			return &tensors[i]
+		}
+	}
+	return nil
+}
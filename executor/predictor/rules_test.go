@@ -0,0 +1,206 @@
+package predictor
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func createRulesRouterGraph(rules ...v1.RoutingRule) *v1.PredictiveUnit {
+	graph := createSimpleRouterGraph()
+	rulesRouter := v1.RULES_ROUTER
+	graph.Implementation = &rulesRouter
+	graph.Rules = rules
+	return graph
+}
+
+func jsonPayload(msg string) payload.SeldonPayload {
+	return &payload.BytesPayload{Msg: []byte(msg), ContentType: "application/json"}
+}
+
+func TestRulesRouterHeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createRulesRouterGraph(
+		v1.RoutingRule{Route: 1, Header: &v1.MatchCondition{Name: "X-Segment", Regex: "^beta-"}},
+		v1.RoutingRule{Route: -2, Header: &v1.MatchCondition{Name: "X-Segment", Equals: "blocked"}},
+	)
+	msg := jsonPayload(`{"data":{"ndarray":[[1,2]]}}`)
+
+	pp := createPredictorProcess(t)
+	route, err := pp.route(graph, msg)
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(0))
+
+	pp.Meta.Meta["x-segment"] = []string{"beta-users"}
+	route, err = pp.route(graph, msg)
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(1))
+
+	pp.Meta.Meta["x-segment"] = []string{"blocked"}
+	res, err := pp.Predict(graph, msg)
+	g.Expect(err).Should(BeNil())
+	g.Expect(res).Should(Equal(msg))
+	g.Expect(pp.Routing["router"]).Should(Equal(int32(-2)))
+}
+
+func TestRulesRouterMetaTag(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createRulesRouterGraph(
+		v1.RoutingRule{Route: 1, MetaTag: &v1.MatchCondition{Name: "variant", Equals: "2"}},
+	)
+	var sm proto.SeldonMessage
+	err := jsonpb.UnmarshalString(`{"meta":{"tags":{"variant":2}},"data":{"ndarray":[1.1,2.0]}}`, &sm)
+	g.Expect(err).Should(BeNil())
+
+	pp := createPredictorProcess(t)
+	_, err = pp.Predict(graph, &payload.ProtoPayload{Msg: &sm})
+	g.Expect(err).Should(BeNil())
+	g.Expect(pp.Routing["router"]).Should(Equal(int32(1)))
+
+	route, err := pp.route(graph, jsonPayload(`{"meta":{"tags":{"variant":2}},"data":{"ndarray":[1.1,2.0]}}`))
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(1))
+}
+
+func TestRulesRouterJSONPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createRulesRouterGraph(
+		v1.RoutingRule{Route: 1, JSONPath: &v1.JSONPathCondition{Path: "{.meta.tags.country}", Regex: "^(uk|ie)$"}},
+		v1.RoutingRule{Route: 0, JSONPath: &v1.JSONPathCondition{Path: "{.data.ndarray[0][1]}", Equals: "2"}},
+		v1.RoutingRule{Route: -2},
+	)
+
+	pp := createPredictorProcess(t)
+	route, err := pp.route(graph, jsonPayload(`{"meta":{"tags":{"country":"uk"}},"data":{"ndarray":[[1,5]]}}`))
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(1))
+
+	route, err = pp.route(graph, jsonPayload(`{"data":{"ndarray":[[1,2]]}}`))
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(0))
+
+	route, err = pp.route(graph, jsonPayload(`{"data":{"ndarray":[[1,3]]}}`))
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(-2))
+}
+
+func TestRuleJSONPathsAreParsedOnce(t *testing.T) {
+	g := NewGomegaWithT(t)
+	pp := createPredictorProcess(t)
+	for _, path := range []string{"{.meta.tags.cached}", "{range .data.ndarray[*]}{[1]}{end}"} {
+		graph := createRulesRouterGraph(v1.RoutingRule{Route: 1, JSONPath: &v1.JSONPathCondition{Path: path, Equals: "2"}})
+		for i := 0; i < 3; i++ {
+			route, err := pp.route(graph, jsonPayload(`{"meta":{"tags":{"cached":2}},"data":{"ndarray":[[1,2]]}}`))
+			g.Expect(err).Should(BeNil())
+			g.Expect(route).Should(Equal(1))
+		}
+		first, err := getRuleJSONPath(path)
+		g.Expect(err).Should(BeNil())
+		second, err := getRuleJSONPath(path)
+		g.Expect(err).Should(BeNil())
+		g.Expect(second).Should(BeIdenticalTo(first))
+	}
+	rj, _ := getRuleJSONPath("{.meta.tags.cached}")
+	g.Expect(rj.reusable).Should(BeTrue())
+	rj, _ = getRuleJSONPath("{range .data.ndarray[*]}{[1]}{end}")
+	g.Expect(rj.reusable).Should(BeFalse())
+}
+
+func TestRulesRouterFeature(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name     string
+		cond     v1.FeatureCondition
+		msg      string
+		expected int
+	}{
+		{
+			name:     "seldon index",
+			cond:     v1.FeatureCondition{Index: 1, Operator: v1.FeatureGreaterThan, Value: "0.5"},
+			msg:      `{"data":{"ndarray":[[0.1,0.9],[0.1,0.1]]}}`,
+			expected: 1,
+		},
+		{
+			name:     "seldon index below threshold",
+			cond:     v1.FeatureCondition{Index: 1, Operator: v1.FeatureGreaterThan, Value: "0.5"},
+			msg:      `{"data":{"ndarray":[[0.1,0.2]]}}`,
+			expected: 0,
+		},
+		{
+			name:     "seldon name",
+			cond:     v1.FeatureCondition{Name: "age", Operator: v1.FeatureGreaterThanOrEqual, Value: "65"},
+			msg:      `{"data":{"names":["income","age"],"ndarray":[[30000,70]]}}`,
+			expected: 1,
+		},
+		{
+			name:     "seldon index out of range",
+			cond:     v1.FeatureCondition{Index: 2, Operator: v1.FeatureLessThan, Value: "1"},
+			msg:      `{"data":{"ndarray":[[0,0]]}}`,
+			expected: 0,
+		},
+		{
+			name:     "v2 tensor name",
+			cond:     v1.FeatureCondition{Name: "b", Operator: v1.FeatureEqual, Value: "3"},
+			msg:      `{"inputs":[{"name":"a","datatype":"INT64","shape":[1],"data":[1]},{"name":"b","datatype":"INT64","shape":[1],"data":[3]}]}`,
+			expected: 1,
+		},
+		{
+			name:     "tensorflow instances",
+			cond:     v1.FeatureCondition{Index: 0, Operator: v1.FeatureLessThanOrEqual, Value: "-1"},
+			msg:      `{"instances":[[-1.5,2.0]]}`,
+			expected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cond := test.cond
+			graph := createRulesRouterGraph(v1.RoutingRule{Route: 1, Feature: &cond})
+			pp := createPredictorProcess(t)
+			route, err := pp.route(graph, jsonPayload(test.msg))
+			g.Expect(err).Should(BeNil())
+			g.Expect(route).Should(Equal(test.expected))
+		})
+	}
+}
+
+func TestRulesRouterConditionsCombined(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createRulesRouterGraph(
+		v1.RoutingRule{
+			Route:   1,
+			Header:  &v1.MatchCondition{Name: "X-Segment", Equals: "beta"},
+			Feature: &v1.FeatureCondition{Index: 0, Operator: v1.FeatureGreaterThan, Value: "10"},
+		},
+	)
+	msg := jsonPayload(`{"data":{"ndarray":[[20]]}}`)
+
+	pp := createPredictorProcess(t)
+	route, err := pp.route(graph, msg)
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(0))
+
+	pp.Meta.Meta["X-Segment"] = []string{"beta"}
+	route, err = pp.route(graph, msg)
+	g.Expect(err).Should(BeNil())
+	g.Expect(route).Should(Equal(1))
+}
+
+func TestRulesRouterErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pp := createPredictorProcess(t)
+	_, err := pp.route(createRulesRouterGraph(v1.RoutingRule{Route: 2}), jsonPayload(`{}`))
+	g.Expect(err).ShouldNot(BeNil())
+
+	_, err = pp.route(createRulesRouterGraph(v1.RoutingRule{Route: 1, JSONPath: &v1.JSONPathCondition{Path: "{.data", Equals: "a"}}), jsonPayload(`{}`))
+	g.Expect(err).ShouldNot(BeNil())
+
+	_, err = pp.route(createRulesRouterGraph(v1.RoutingRule{Route: 1, JSONPath: &v1.JSONPathCondition{Path: "{.data}", Equals: "a"}}), jsonPayload(`[1]`))
+	g.Expect(err).ShouldNot(BeNil())
+}
@@ -0,0 +1,102 @@
+package predictor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// StatisticDuration is a count of calls and their cumulative duration in nanoseconds.
+type StatisticDuration struct {
+	Count uint64 `json:"count"`
+	Ns    uint64 `json:"ns"`
+}
+
+type InferStatistics struct {
+	Success StatisticDuration `json:"success"`
+	Fail    StatisticDuration `json:"fail"`
+}
+
+// ModelStatistics are the executor's statistics for predictions by one graph node, in the form of the V2 protocol
+// statistics extension. Each request predicted counts as one inference and each call to the node, including its
+// retries, as one execution, so a batch of requests is many inferences but one execution.
+type ModelStatistics struct {
+	Name           string          `json:"name"`
+	Version        string          `json:"version"`
+	LastInference  uint64          `json:"last_inference"`
+	InferenceCount uint64          `json:"inference_count"`
+	ExecutionCount uint64          `json:"execution_count"`
+	InferenceStats InferStatistics `json:"inference_stats"`
+}
+
+// ModelNotFoundError is returned for a model which is not a node of the graph.
+type ModelNotFoundError struct {
+	ModelName string
+}
+
+func (e *ModelNotFoundError) Error() string {
+	return fmt.Sprintf("Failed to find model %s", e.ModelName)
+}
+
+var (
+	modelStatistics      = map[string]*ModelStatistics{}
+	modelStatisticsMutex = &sync.Mutex{}
+)
+
+// recordPrediction records a call predicting the given number of requests.
+func recordPrediction(node *v1.PredictiveUnit, start time.Time, inferences int, err error) {
+	ns := uint64(time.Since(start).Nanoseconds())
+	n := uint64(inferences)
+	modelStatisticsMutex.Lock()
+	defer modelStatisticsMutex.Unlock()
+	stats, ok := modelStatistics[node.Name]
+	if !ok {
+		stats = &ModelStatistics{Name: node.Name}
+		modelStatistics[node.Name] = stats
+	}
+	if err != nil {
+		stats.InferenceStats.Fail.Count += n
+		stats.InferenceStats.Fail.Ns += ns * n
+		return
+	}
+	stats.InferenceStats.Success.Count += n
+	stats.InferenceStats.Success.Ns += ns * n
+	stats.InferenceCount += n
+	stats.ExecutionCount++
+	stats.LastInference = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+}
+
+// ModelStatistics returns the statistics of the named node or, if modelName is empty, of all nodes of the graph
+// which are called by the executor, sorted by name.
+func (p *PredictorProcess) ModelStatistics(spec *v1.PredictorSpec, modelName string) ([]ModelStatistics, error) {
+	var nodes []*v1.PredictiveUnit
+	if modelName != "" {
+		nodeModel := v1.GetPredictiveUnitForPredictor(spec, modelName)
+		if nodeModel == nil {
+			return nil, &ModelNotFoundError{ModelName: modelName}
+		}
+		nodes = append(nodes, nodeModel)
+	} else {
+		for _, pu := range v1.GetPredictiveUnitListForPredictor(spec) {
+			if !v1.IsExecutorImplementation(pu) {
+				nodes = append(nodes, pu)
+			}
+		}
+	}
+
+	modelStatisticsMutex.Lock()
+	defer modelStatisticsMutex.Unlock()
+	stats := make([]ModelStatistics, len(nodes))
+	for i, pu := range nodes {
+		if s, ok := modelStatistics[pu.Name]; ok {
+			stats[i] = *s
+		} else {
+			stats[i] = ModelStatistics{Name: pu.Name}
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats, nil
+}
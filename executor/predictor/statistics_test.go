@@ -0,0 +1,91 @@
+package predictor
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestModelStatistics(t *testing.T) {
+	g := NewGomegaWithT(t)
+	calls := int32(0)
+	pp := createPredictorProcessWithClient(t, flakyTestClient{calls: &calls, failures: 1})
+
+	model := createResilientModel("stats-model")
+	router := v1.RANDOM_ABTEST
+	spec := &v1.PredictorSpec{
+		Graph: v1.PredictiveUnit{
+			Name:           "stats-router",
+			Implementation: &router,
+			Children:       []v1.PredictiveUnit{*model, *createResilientModel("stats-other")},
+		},
+	}
+
+	_, err := pp.Predict(model, createPredictPayload(g))
+	g.Expect(err).ShouldNot(BeNil())
+	_, err = pp.Predict(model, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	_, err = pp.Predict(model, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+
+	stats, err := pp.ModelStatistics(spec, "stats-model")
+	g.Expect(err).Should(BeNil())
+	g.Expect(stats).Should(HaveLen(1))
+	g.Expect(stats[0].Name).Should(Equal("stats-model"))
+	g.Expect(stats[0].InferenceCount).Should(Equal(uint64(2)))
+	g.Expect(stats[0].ExecutionCount).Should(Equal(uint64(2)))
+	g.Expect(stats[0].InferenceStats.Success.Count).Should(Equal(uint64(2)))
+	g.Expect(stats[0].InferenceStats.Fail.Count).Should(Equal(uint64(1)))
+	g.Expect(stats[0].LastInference).ShouldNot(BeZero())
+
+	// Executor implementations are not listed and nodes not yet called have empty statistics
+	stats, err = pp.ModelStatistics(spec, "")
+	g.Expect(err).Should(BeNil())
+	g.Expect(stats).Should(HaveLen(2))
+	g.Expect(stats[0].Name).Should(Equal("stats-model"))
+	g.Expect(stats[1]).Should(Equal(ModelStatistics{Name: "stats-other"}))
+
+	_, err = pp.ModelStatistics(spec, "unknown")
+	g.Expect(err).ShouldNot(BeNil())
+
+	// The nodes of a DAG are found as well as its graph node
+	dag := &v1.PredictorSpec{
+		Nodes: []v1.PredictiveUnit{*model},
+		Graph: *createResilientModel("stats-other"),
+	}
+	stats, err = pp.ModelStatistics(dag, "stats-model")
+	g.Expect(err).Should(BeNil())
+	g.Expect(stats[0].InferenceCount).Should(Equal(uint64(2)))
+	stats, err = pp.ModelStatistics(dag, "")
+	g.Expect(err).Should(BeNil())
+	g.Expect(stats).Should(HaveLen(2))
+}
+
+func TestModelStatisticsCountsPredictions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls []payload.SeldonPayload
+	client := batchRecordingTestClient{mu: &sync.Mutex{}, calls: &calls}
+	node := createBatchingModel("stats-batch", 2, 10000)
+
+	_, errs := predictConcurrently(t, client, node, []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[1.0]]}}`), ContentType: "application/json"},
+		&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[2.0]]}}`), ContentType: "application/json"},
+	})
+	g.Expect(errs).Should(Equal([]error{nil, nil}))
+
+	transformer := v1.TRANSFORMER
+	node.Type = &transformer
+	_, errs = predictConcurrently(t, client, node, []payload.SeldonPayload{
+		&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[[3.0]]}}`), ContentType: "application/json"},
+	})
+	g.Expect(errs).Should(Equal([]error{nil}))
+
+	stats, err := createPredictorProcessWithClient(t, flakyTestClient{}).ModelStatistics(&v1.PredictorSpec{Graph: *node}, "stats-batch")
+	g.Expect(err).Should(BeNil())
+	g.Expect(stats[0].InferenceCount).Should(Equal(uint64(2)))
+	g.Expect(stats[0].ExecutionCount).Should(Equal(uint64(1)))
+	g.Expect(stats[0].InferenceStats.Success.Count).Should(Equal(uint64(2)))
+}
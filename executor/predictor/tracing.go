@@ -0,0 +1,137 @@
+package predictor
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Steps of a graph node, each timed and, when OpenTelemetry is enabled, traced in a span of its own.
+const (
+	StepTransformInput  = "transform-input"
+	StepRoute           = "route"
+	StepCombine         = "combine"
+	StepTransformOutput = "transform-output"
+)
+
+// Attributes of the spans of graph node steps and batched calls.
+const (
+	AttributeNodeName           = "seldon.node.name"
+	AttributeNodeType           = "seldon.node.type"
+	AttributeNodeImplementation = "seldon.node.implementation"
+	AttributeRoute              = "seldon.route"
+	AttributeRouteChild         = "seldon.route.child"
+	AttributeBatchSize          = "seldon.batch.size"
+)
+
+// Directions of the payloads whose size is recorded.
+const (
+	PayloadRequest  = "request"
+	PayloadResponse = "response"
+)
+
+var (
+	graphMetrics      *metric.GraphMetrics
+	graphMetricsMutex = &sync.Mutex{}
+)
+
+// SetGraphMetrics labels the metrics of graph node steps with the deployment and predictor the executor serves.
+func SetGraphMetrics(spec *v1.PredictorSpec, deploymentName string) {
+	graphMetricsMutex.Lock()
+	defer graphMetricsMutex.Unlock()
+	graphMetrics = metric.NewGraphMetrics(spec, deploymentName)
+}
+
+func getGraphMetrics() *metric.GraphMetrics {
+	graphMetricsMutex.Lock()
+	defer graphMetricsMutex.Unlock()
+	if graphMetrics == nil {
+		graphMetrics = metric.NewGraphMetrics(&v1.PredictorSpec{}, "")
+	}
+	return graphMetrics
+}
+
+// nodeStep is a step of a graph node being processed.
+type nodeStep struct {
+	node  *v1.PredictiveUnit
+	name  string
+	start time.Time
+	span  trace.Span
+}
+
+// startStep starts a node's step, returning a copy of the process whose calls are made within the step's span.
+func (p *PredictorProcess) startStep(node *v1.PredictiveUnit, name string) (*PredictorProcess, *nodeStep) {
+	attrs := []attribute.KeyValue{attribute.String(AttributeNodeName, node.Name)}
+	if node.Type != nil {
+		attrs = append(attrs, attribute.String(AttributeNodeType, string(*node.Type)))
+	}
+	if node.Implementation != nil {
+		attrs = append(attrs, attribute.String(AttributeNodeImplementation, string(*node.Implementation)))
+	}
+	ctx, span := otel.Tracer(tracing.TracerName).Start(p.Ctx, name, trace.WithAttributes(attrs...))
+	np := *p
+	np.Ctx = ctx
+	return &np, &nodeStep{node: node, name: name, start: time.Now(), span: span}
+}
+
+// end records the step's duration and ends its span, marking it failed if the step returned an error.
+func (s *nodeStep) end(err error) {
+	m := getGraphMetrics()
+	m.StepHistogram.WithLabelValues(m.LabelValues(s.node.Name, s.name)...).Observe(time.Since(s.start).Seconds())
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// setRoute records a router's decision: the child it chose, -1 for all children or -2 to return the request
+// unchanged.
+func (s *nodeStep) setRoute(route int) {
+	child := ""
+	if route >= 0 && route < len(s.node.Children) {
+		child = s.node.Children[route].Name
+		s.span.SetAttributes(attribute.String(AttributeRouteChild, child))
+	}
+	s.span.SetAttributes(attribute.Int(AttributeRoute, route))
+	m := getGraphMetrics()
+	m.RouteCounter.WithLabelValues(m.LabelValues(s.node.Name, strconv.Itoa(route), child)...).Inc()
+	if route == -2 {
+		m.AbortCounter.WithLabelValues(m.LabelValues(s.node.Name)...).Inc()
+	}
+}
+
+// observePayload records the total size of the payloads sent to, or received from, the node.
+func (s *nodeStep) observePayload(direction string, msgs ...payload.SeldonPayload) {
+	size := 0
+	for _, msg := range msgs {
+		size += payloadSize(msg)
+	}
+	m := getGraphMetrics()
+	m.PayloadSizeHistogram.WithLabelValues(m.LabelValues(s.node.Name, direction)...).Observe(float64(size))
+}
+
+// payloadSize returns the size of a payload on the wire, without marshalling protos.
+func payloadSize(msg payload.SeldonPayload) int {
+	switch m := msg.(type) {
+	case nil:
+		return 0
+	case *payload.ProtoPayload:
+		return proto.Size(m.Msg)
+	default:
+		if b, err := msg.GetBytes(); err == nil {
+			return len(b)
+		}
+		return 0
+	}
+}
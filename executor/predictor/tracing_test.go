@@ -0,0 +1,147 @@
+package predictor
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	return recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func histogramSamples(g *GomegaWithT, observer prometheus.Observer) (uint64, float64) {
+	m := &dto.Metric{}
+	g.Expect(observer.(prometheus.Metric).Write(m)).To(Succeed())
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func createStepGraph(nodeType v1.PredictiveUnitType) *v1.PredictiveUnit {
+	return createNamedStepGraph("parent", nodeType)
+}
+
+func createNamedStepGraph(name string, nodeType v1.PredictiveUnitType) *v1.PredictiveUnit {
+	model := v1.MODEL
+	return &v1.PredictiveUnit{
+		Name:     name,
+		Type:     &nodeType,
+		Endpoint: &v1.Endpoint{ServiceHost: "foo", ServicePort: 9000, Type: v1.REST},
+		Children: []v1.PredictiveUnit{
+			{
+				Name:     name + "-a",
+				Type:     &model,
+				Endpoint: &v1.Endpoint{ServiceHost: "foo2", ServicePort: 9001, Type: v1.REST},
+			},
+			{
+				Name:     name + "-b",
+				Type:     &model,
+				Endpoint: &v1.Endpoint{ServiceHost: "foo3", ServicePort: 9002, Type: v1.REST},
+			},
+		},
+	}
+}
+
+func TestRouteStepSpans(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := recordSpans(t)
+
+	_, err := createPredictorProcessWithRoute(t, 1).Predict(createStepGraph(v1.ROUTER), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(2))
+	g.Expect(spans[0].Name()).To(Equal(StepRoute))
+	attrs := spanAttributes(spans[0])
+	g.Expect(attrs[AttributeNodeName].AsString()).To(Equal("parent"))
+	g.Expect(attrs[AttributeNodeType].AsString()).To(Equal(string(v1.ROUTER)))
+	g.Expect(attrs[AttributeRoute].AsInt64()).To(Equal(int64(1)))
+	g.Expect(attrs[AttributeRouteChild].AsString()).To(Equal("parent-b"))
+	g.Expect(spans[1].Name()).To(Equal(StepTransformInput))
+	g.Expect(spanAttributes(spans[1])[AttributeNodeName].AsString()).To(Equal("parent-b"))
+}
+
+func TestCombineStepSpans(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := recordSpans(t)
+
+	_, err := createPredictorProcess(t).Predict(createStepGraph(v1.COMBINER), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+
+	names := make(map[string][]string)
+	for _, span := range recorder.Ended() {
+		name := spanAttributes(span)[AttributeNodeName].AsString()
+		names[span.Name()] = append(names[span.Name()], name)
+	}
+	g.Expect(names[StepTransformInput]).To(ConsistOf("parent-a", "parent-b"))
+	g.Expect(names[StepCombine]).To(Equal([]string{"parent"}))
+	// Only routers have a route step
+	g.Expect(names).ToNot(HaveKey(StepRoute))
+	g.Expect(names).ToNot(HaveKey(StepTransformOutput))
+}
+
+func TestRouteMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createNamedStepGraph("route-metrics", v1.ROUTER)
+	SetGraphMetrics(&v1.PredictorSpec{Name: "p1", Annotations: map[string]string{"version": "v1"}}, "dep")
+	metrics := getGraphMetrics()
+
+	_, err := createPredictorProcessWithRoute(t, 1).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	_, err = createPredictorProcessWithRoute(t, -2).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	_, err = createPredictorProcessWithRoute(t, 1).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+
+	g.Expect(testutil.ToFloat64(metrics.RouteCounter.WithLabelValues(metrics.LabelValues("route-metrics", "1", "route-metrics-b")...))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(metrics.RouteCounter.WithLabelValues(metrics.LabelValues("route-metrics", "-2", "")...))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.AbortCounter.WithLabelValues(metrics.LabelValues("route-metrics")...))).To(Equal(1.0))
+	count, _ := histogramSamples(g, metrics.StepHistogram.WithLabelValues(metrics.LabelValues("route-metrics", StepRoute)...))
+	g.Expect(count).To(Equal(uint64(3)))
+	count, _ = histogramSamples(g, metrics.StepHistogram.WithLabelValues(metrics.LabelValues("route-metrics-b", StepTransformInput)...))
+	g.Expect(count).To(Equal(uint64(2)))
+	g.Expect(testutil.ToFloat64(metrics.AbortCounter.WithLabelValues("dep", "p1", "v1", "route-metrics"))).To(Equal(1.0))
+}
+
+func TestPayloadSizeMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+	graph := createNamedStepGraph("size-metrics", v1.COMBINER)
+	metrics := getGraphMetrics()
+	msg := createPredictPayload(g)
+
+	res, err := createPredictorProcess(t).Predict(graph, msg)
+	g.Expect(err).Should(BeNil())
+
+	count, sum := histogramSamples(g, metrics.PayloadSizeHistogram.WithLabelValues(metrics.LabelValues("size-metrics-a", PayloadRequest)...))
+	g.Expect(count).To(Equal(uint64(1)))
+	g.Expect(sum).To(Equal(float64(payloadSize(msg))))
+	count, _ = histogramSamples(g, metrics.PayloadSizeHistogram.WithLabelValues(metrics.LabelValues("size-metrics-a", PayloadResponse)...))
+	g.Expect(count).To(Equal(uint64(1)))
+	// The combiner is sent the responses of both children
+	count, sum = histogramSamples(g, metrics.PayloadSizeHistogram.WithLabelValues(metrics.LabelValues("size-metrics", PayloadRequest)...))
+	g.Expect(count).To(Equal(uint64(1)))
+	g.Expect(sum).To(BeNumerically(">", 0))
+	_, sum = histogramSamples(g, metrics.PayloadSizeHistogram.WithLabelValues(metrics.LabelValues("size-metrics", PayloadResponse)...))
+	g.Expect(sum).To(Equal(float64(payloadSize(res))))
+}
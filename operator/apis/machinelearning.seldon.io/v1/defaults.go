@@ -145,6 +145,29 @@ func (r *SeldonDeploymentSpec) setContainerPredictiveUnitDefaults(compSpecIdx in
 	pu.Endpoint.GrpcPort = portNumGrpc
 }
 
+// removeExecutorContainers drops the containers of predictive units run by the executor so no Deployment or Service is
+// created for them, along with any componentSpec left without containers.
+func removeExecutorContainers(p *PredictorSpec) {
+	var cSpecs []*SeldonPodSpec
+	for _, cSpec := range p.ComponentSpecs {
+		if len(cSpec.Spec.Containers) == 0 {
+			cSpecs = append(cSpecs, cSpec)
+			continue
+		}
+		var containers []corev1.Container
+		for _, con := range cSpec.Spec.Containers {
+			if !IsExecutorContainer(p, con.Name) {
+				containers = append(containers, con)
+			}
+		}
+		if len(containers) > 0 {
+			cSpec.Spec.Containers = containers
+			cSpecs = append(cSpecs, cSpec)
+		}
+	}
+	p.ComponentSpecs = cSpecs
+}
+
 func (r *SeldonDeployment) Default() {
 	seldondeploymentlog.Info("Defaulting Seldon Deployment called", "name", r.Name)
 
@@ -204,6 +227,10 @@ func (r *SeldonDeploymentSpec) DefaultSeldonDeployment(mldepName string, namespa
 		}
 
 		addDefaultsToGraph(&p.Graph)
+		for j := range p.Nodes {
+			addDefaultsToGraph(&p.Nodes[j])
+		}
+		removeExecutorContainers(&p)
 
 		for j := 0; j < len(p.ComponentSpecs); j++ {
 			cSpec := r.Predictors[i].ComponentSpecs[j]
@@ -218,7 +245,7 @@ func (r *SeldonDeploymentSpec) DefaultSeldonDeployment(mldepName string, namespa
 				getUpdatePortNumMap(con.Name, &nextGrpcPortNum, portMapGrpc)
 				grpcPortNum := portMapGrpc[con.Name]
 
-				pu := GetPredictiveUnit(&p.Graph, con.Name)
+				pu := GetPredictiveUnitForPredictor(&p, con.Name)
 
 				if pu != nil {
 					r.setContainerPredictiveUnitDefaults(j, httpPortNum, grpcPortNum, &nextMetricsPortNum, mldepName, namespace, &p, pu, con)
@@ -226,7 +253,7 @@ func (r *SeldonDeploymentSpec) DefaultSeldonDeployment(mldepName string, namespa
 			}
 		}
 
-		pus := GetPredictiveUnitList(&p.Graph)
+		pus := GetPredictiveUnitListForPredictor(&p)
 
 		//some pus might not have a container spec so pick those up
 		for l := 0; l < len(pus); l++ {
@@ -118,10 +118,78 @@ func init() {
 }
 
 func IsPrepack(pu *PredictiveUnit) bool {
-	isPrepack := len(*pu.Implementation) > 0 && *pu.Implementation != SIMPLE_MODEL && *pu.Implementation != SIMPLE_ROUTER && *pu.Implementation != RANDOM_ABTEST && *pu.Implementation != AVERAGE_COMBINER && *pu.Implementation != UNKNOWN_IMPLEMENTATION
+	isPrepack := len(*pu.Implementation) > 0 && *pu.Implementation != SIMPLE_MODEL && *pu.Implementation != SIMPLE_ROUTER && *pu.Implementation != RANDOM_ABTEST && *pu.Implementation != AVERAGE_COMBINER && *pu.Implementation != RULES_ROUTER && !IsBanditImplementation(pu) && *pu.Implementation != UNKNOWN_IMPLEMENTATION
 	return isPrepack
 }
 
+// IsExecutorImplementation returns whether the predictive unit is run in-process by the executor
+// and so has no container or service of its own.
+func IsExecutorImplementation(pu *PredictiveUnit) bool {
+	if pu.Implementation == nil {
+		return false
+	}
+	switch *pu.Implementation {
+	case SIMPLE_MODEL, RANDOM_ABTEST, SIMPLE_ROUTER, AVERAGE_COMBINER, RULES_ROUTER:
+		return true
+	}
+	return IsBanditImplementation(pu)
+}
+
+// IsExecutorContainer returns whether the container is for a predictive unit run in-process by the executor, which
+// deployments from before these implementations ran in the executor may still define.
+func IsExecutorContainer(p *PredictorSpec, name string) bool {
+	pu := GetPredictiveUnitForPredictor(p, name)
+	return pu != nil && IsExecutorImplementation(pu)
+}
+
+// IsBanditImplementation returns whether the predictive unit is a multi-armed bandit router run by the executor.
+func IsBanditImplementation(pu *PredictiveUnit) bool {
+	if pu.Implementation == nil {
+		return false
+	}
+	switch *pu.Implementation {
+	case EPSILON_GREEDY, THOMPSON_SAMPLING, UCB:
+		return true
+	}
+	return false
+}
+
+// IsRouter returns whether the predictive unit routes requests to one of its children.
+func IsRouter(pu *PredictiveUnit) bool {
+	if pu.Type != nil && *pu.Type == ROUTER {
+		return true
+	}
+	if pu.Implementation != nil {
+		switch *pu.Implementation {
+		case RANDOM_ABTEST, SIMPLE_ROUTER, RULES_ROUTER:
+			return true
+		}
+	}
+	return hasMethod(pu, ROUTE) || IsBanditImplementation(pu)
+}
+
+// IsAggregator returns whether the predictive unit combines the outputs of its children.
+func IsAggregator(pu *PredictiveUnit) bool {
+	if pu.Type != nil && *pu.Type == COMBINER {
+		return true
+	}
+	if pu.Implementation != nil && *pu.Implementation == AVERAGE_COMBINER {
+		return true
+	}
+	return hasMethod(pu, AGGREGATE)
+}
+
+func hasMethod(pu *PredictiveUnit, method PredictiveUnitMethod) bool {
+	if pu.Methods != nil {
+		for _, m := range *pu.Methods {
+			if m == method {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func getPredictorServerConfigs() (map[string]PredictorServerConfig, error) {
 	configMap := &corev1.ConfigMap{}
 
@@ -0,0 +1,151 @@
+package v1
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	. "github.com/onsi/gomega"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	crdV1Path            = "../../../config/crd_v1/bases/machinelearning.seldon.io_seldondeployments.yaml"
+	crdV1GraphPatchPath  = "../../../config/crd_v1/patches/graph_children.yaml"
+	crdV1GraphSchemaPath = "/spec/versions/0/schema/openAPIV3Schema/properties/spec/properties/predictors/items/properties/graph"
+)
+
+type graphSchemaPatch struct {
+	Op    string                          `json:"op"`
+	Path  string                          `json:"path"`
+	Value apiextensionsv1.JSONSchemaProps `json:"value"`
+}
+
+// loadStructuralSchema returns the schema of the v1 version of the generated CRD with its graph patched to allow
+// nested children, as the API server prunes with it.
+func loadStructuralSchema(g *GomegaWithT) *structuralschema.Structural {
+	data, err := ioutil.ReadFile(crdV1Path)
+	g.Expect(err).To(BeNil())
+	crd := apiextensionsv1.CustomResourceDefinition{}
+	g.Expect(yaml.Unmarshal(data, &crd)).To(BeNil())
+	g.Expect(crd.Spec.Versions[0].Name).To(Equal(GroupVersion.Version))
+	schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+
+	data, err = ioutil.ReadFile(crdV1GraphPatchPath)
+	g.Expect(err).To(BeNil())
+	var patches []graphSchemaPatch
+	g.Expect(yaml.Unmarshal(data, &patches)).To(BeNil())
+	g.Expect(patches[0].Op).To(Equal("replace"))
+	g.Expect(patches[0].Path).To(Equal(crdV1GraphSchemaPath))
+	schema.Properties["spec"].Properties["predictors"].Items.Schema.Properties["graph"] = patches[0].Value
+
+	internal := &apiextensions.JSONSchemaProps{}
+	g.Expect(apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(schema, internal, nil)).To(BeNil())
+	structural, err := structuralschema.NewStructural(internal)
+	g.Expect(err).To(BeNil())
+	return structural
+}
+
+// expectPreserved checks the API server keeps every field of the deployment.
+func expectPreserved(g *GomegaWithT, mlDep *SeldonDeployment) {
+	structural := loadStructuralSchema(g)
+	data, err := json.Marshal(mlDep)
+	g.Expect(err).To(BeNil())
+	obj := map[string]interface{}{}
+	g.Expect(json.Unmarshal(data, &obj)).To(BeNil())
+	pruned := runtime.DeepCopyJSON(obj)
+	pruning.Prune(pruned, structural, true)
+	g.Expect(pruned).To(Equal(obj))
+}
+
+func TestCRDPreservesGraphFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+	sampleRate := "0.5"
+	unit := func(name string, children ...PredictiveUnit) PredictiveUnit {
+		return PredictiveUnit{
+			Name:      name,
+			Children:  children,
+			TimeoutMs: 500,
+			Retries: &RetryPolicy{
+				MaxRetries:       2,
+				InitialBackoffMs: 10,
+				MaxBackoffMs:     100,
+			},
+			CircuitBreaker: &CircuitBreaker{
+				FailureThreshold: 5,
+				OpenDurationMs:   1000,
+			},
+			FanOut: &FanOutPolicy{
+				Quorum:     1,
+				DeadlineMs: 200,
+			},
+			Cache: &CachePolicy{
+				TtlMs:      60000,
+				MaxEntries: 100,
+				Headers:    []string{"X-User-Id"},
+			},
+			Batching: &BatchingPolicy{
+				MaxBatchSize: 8,
+				MaxWaitMs:    5,
+			},
+			Protocol: ProtocolKfserving,
+			Rules: []RoutingRule{
+				{
+					Route:    1,
+					Header:   &MatchCondition{Name: "X-Segment", Equals: "beta"},
+					MetaTag:  &MatchCondition{Name: "tier", Regex: "^gold"},
+					JSONPath: &JSONPathCondition{Path: "{.meta.tags.segment}", Equals: "a"},
+					Feature:  &FeatureCondition{Index: 2, Operator: FeatureGreaterThan, Value: "0.5"},
+				},
+				{Route: 0},
+			},
+			Inputs: []string{GraphRequestInput},
+			Logger: &Logger{
+				Mode:       LogAll,
+				SampleRate: &sampleRate,
+				Headers:    []string{"X-User-Id"},
+				Redact: []RedactionRule{
+					{JSONPath: "$.jsonData.email"},
+					{Name: "ssn", Mask: "xxx"},
+				},
+			},
+		}
+	}
+	// The logger mode is a plain string so the pair mode needs no schema change
+	output := unit("combiner")
+	output.Logger = &Logger{Mode: LogPair}
+	mlDep := &SeldonDeployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupVersion.String(),
+			Kind:       "SeldonDeployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dep",
+			Namespace: "default",
+		},
+		Spec: SeldonDeploymentSpec{
+			Predictors: []PredictorSpec{
+				{
+					Name: "p1",
+					Cache: &CachePolicy{
+						TtlMs:   1000,
+						Headers: []string{"X-User-Id"},
+					},
+					Graph: unit("combiner", unit("model1"), unit("model2", unit("model3"))),
+				},
+				{
+					Name:  "p2",
+					Nodes: []PredictiveUnit{unit("features"), unit("model1")},
+					Graph: output,
+				},
+			},
+		},
+	}
+	expectPreserved(g, mlDep)
+}
@@ -74,6 +74,9 @@ const (
 	ANNOTATION_CUSTOM_SVC_NAME         = "seldon.io/svc-name"
 	ANNOTATION_LOGGER_WORK_QUEUE_SIZE  = "seldon.io/executor-logger-queue-size"
 	ANNOTATION_LOGGER_WRITE_TIMEOUT_MS = "seldon.io/executor-logger-write-timeout-ms"
+	ANNOTATION_LOGGER_SPOOL_DIR        = "seldon.io/executor-logger-spool-dir"
+	ANNOTATION_LOGGER_SPOOL_MAX_BYTES  = "seldon.io/executor-logger-spool-max-bytes"
+	ANNOTATION_LOGGER_SPOOL_SEGMENT    = "seldon.io/executor-logger-spool-segment-bytes"
 
 	DeploymentNamePrefix = "seldon"
 )
@@ -203,6 +206,38 @@ func GetPredictiveUnitList(p *PredictiveUnit) (list []*PredictiveUnit) {
 	return list
 }
 
+// IsDAG returns whether the predictor's graph is in DAG form.
+func (p *PredictorSpec) IsDAG() bool {
+	return len(p.Nodes) > 0
+}
+
+// GetPredictiveUnitListForPredictor returns every unit of the predictor's graph including the nodes of a DAG.
+func GetPredictiveUnitListForPredictor(p *PredictorSpec) []*PredictiveUnit {
+	list := GetPredictiveUnitList(&p.Graph)
+	for i := range p.Nodes {
+		list = append(list, GetPredictiveUnitList(&p.Nodes[i])...)
+	}
+	return list
+}
+
+func GetPredictiveUnitForPredictor(p *PredictorSpec, name string) *PredictiveUnit {
+	for _, pu := range GetPredictiveUnitListForPredictor(p) {
+		if pu.Name == name {
+			return pu
+		}
+	}
+	return nil
+}
+
+func GetEnginePredictiveUnitForPredictor(p *PredictorSpec) *PredictiveUnit {
+	for _, pu := range GetPredictiveUnitListForPredictor(p) {
+		if pu.Endpoint != nil && pu.Endpoint.ServiceHost == "localhost" {
+			return pu
+		}
+	}
+	return nil
+}
+
 func GetContainerServiceName(mlDepName string, predictorSpec PredictorSpec, c *v1.Container) string {
 	svcName := mlDepName + "-" + predictorSpec.Name + "-" + c.Name
 	if len(svcName) > 63 {
@@ -246,8 +281,16 @@ type PredictorSpec struct {
 	Explainer       *Explainer              `json:"explainer,omitempty" protobuf:"bytes,10,opt,name=explainer"`
 	Shadow          bool                    `json:"shadow,omitempty" protobuf:"bytes,11,opt,name=shadow"`
 	SSL             *SSL                    `json:"ssl,omitempty" protobuf:"bytes,12,opt,name=ssl"`
+	Cache           *CachePolicy            `json:"cache,omitempty" protobuf:"bytes,13,opt,name=cache"`
+	// Nodes of a DAG graph, which name their inputs instead of being children of another node.
+	// The graph is then the node whose output is returned and must name its inputs too.
+	// +optional
+	Nodes []PredictiveUnit `json:"nodes,omitempty" protobuf:"bytes,14,opt,name=nodes"`
 }
 
+// GraphRequestInput is the input name DAG nodes use for the request sent to the graph
+const GraphRequestInput = "$request"
+
 type Protocol string
 
 const (
@@ -268,6 +311,8 @@ type ServerType string
 const (
 	ServerRPC   ServerType = "rpc"
 	ServerKafka ServerType = "kafka"
+	// ServerStream consumes from NATS JetStream, Redis Streams or AMQP, chosen by the scheme of STREAM_URL
+	ServerStream ServerType = "stream"
 )
 
 type SvcOrchSpec struct {
@@ -539,6 +584,17 @@ const (
 	SIMPLE_ROUTER          PredictiveUnitImplementation = "SIMPLE_ROUTER"
 	RANDOM_ABTEST          PredictiveUnitImplementation = "RANDOM_ABTEST"
 	AVERAGE_COMBINER       PredictiveUnitImplementation = "AVERAGE_COMBINER"
+	EPSILON_GREEDY         PredictiveUnitImplementation = "EPSILON_GREEDY"
+	THOMPSON_SAMPLING      PredictiveUnitImplementation = "THOMPSON_SAMPLING"
+	UCB                    PredictiveUnitImplementation = "UCB"
+	RULES_ROUTER           PredictiveUnitImplementation = "RULES_ROUTER"
+)
+
+// Parameters of the implementations run by the executor that are checked by the webhook.
+const (
+	ABTestRatioAParameter  = "ratioA"
+	ABTestWeightsParameter = "weights"
+	BanditEpsilonParameter = "epsilon"
 )
 
 type PredictiveUnitMethod string
@@ -595,6 +651,130 @@ type PredictiveUnit struct {
 	EnvSecretRefName        string                        `json:"envSecretRefName,omitempty" protobuf:"bytes,10,opt,name=envSecretRefName"`
 	StorageInitializerImage string                        `json:"storageInitializerImage,omitempty" protobuf:"bytes,11,opt,name=storageInitializerImage"`
 	Logger                  *Logger                       `json:"logger,omitempty" protobuf:"bytes,12,opt,name=logger"`
+	TimeoutMs               int32                         `json:"timeoutMs,omitempty" protobuf:"int32,13,opt,name=timeoutMs"`
+	Retries                 *RetryPolicy                  `json:"retries,omitempty" protobuf:"bytes,14,opt,name=retries"`
+	CircuitBreaker          *CircuitBreaker               `json:"circuitBreaker,omitempty" protobuf:"bytes,15,opt,name=circuitBreaker"`
+	FanOut                  *FanOutPolicy                 `json:"fanOut,omitempty" protobuf:"bytes,16,opt,name=fanOut"`
+	Cache                   *CachePolicy                  `json:"cache,omitempty" protobuf:"bytes,17,opt,name=cache"`
+	Batching                *BatchingPolicy               `json:"batching,omitempty" protobuf:"bytes,18,opt,name=batching"`
+	Protocol                Protocol                      `json:"protocol,omitempty" protobuf:"bytes,19,opt,name=protocol"`
+	Rules                   []RoutingRule                 `json:"rules,omitempty" protobuf:"bytes,20,opt,name=rules"`
+	Inputs                  []string                      `json:"inputs,omitempty" protobuf:"bytes,21,rep,name=inputs"`
+}
+
+// RoutingRule sends requests matching all of its conditions to a child of a RULES_ROUTER. A rule without
+// conditions matches every request.
+type RoutingRule struct {
+	// Index of the child matching requests are sent to, or -2 to return the request without calling a child
+	Route int32 `json:"route" protobuf:"int32,1,opt,name=route"`
+	// +optional
+	Header *MatchCondition `json:"header,omitempty" protobuf:"bytes,2,opt,name=header"`
+	// +optional
+	MetaTag *MatchCondition `json:"metaTag,omitempty" protobuf:"bytes,3,opt,name=metaTag"`
+	// +optional
+	JSONPath *JSONPathCondition `json:"jsonPath,omitempty" protobuf:"bytes,4,opt,name=jsonPath"`
+	// +optional
+	Feature *FeatureCondition `json:"feature,omitempty" protobuf:"bytes,5,opt,name=feature"`
+}
+
+// MatchCondition matches the value of a request header or meta tag
+type MatchCondition struct {
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Value the header or tag must equal
+	// +optional
+	Equals string `json:"equals,omitempty" protobuf:"bytes,2,opt,name=equals"`
+	// Regular expression the header or tag must match
+	// +optional
+	Regex string `json:"regex,omitempty" protobuf:"bytes,3,opt,name=regex"`
+}
+
+// JSONPathCondition matches the value selected from the JSON request by a Kubernetes JSONPath expression
+type JSONPathCondition struct {
+	// Expression such as {.meta.tags.segment}
+	Path string `json:"path" protobuf:"bytes,1,opt,name=path"`
+	// +optional
+	Equals string `json:"equals,omitempty" protobuf:"bytes,2,opt,name=equals"`
+	// +optional
+	Regex string `json:"regex,omitempty" protobuf:"bytes,3,opt,name=regex"`
+}
+
+type FeatureOperator string
+
+const (
+	FeatureGreaterThan        FeatureOperator = "GreaterThan"
+	FeatureGreaterThanOrEqual FeatureOperator = "GreaterThanOrEqual"
+	FeatureLessThan           FeatureOperator = "LessThan"
+	FeatureLessThanOrEqual    FeatureOperator = "LessThanOrEqual"
+	FeatureEqual              FeatureOperator = "Equal"
+)
+
+// FeatureCondition compares a feature in the first row of the request data with a threshold
+type FeatureCondition struct {
+	// Position of the feature in the row
+	// +optional
+	Index int32 `json:"index,omitempty" protobuf:"int32,1,opt,name=index"`
+	// Name of a Seldon protocol feature in the data names, or of a V2 or Tensorflow input tensor.
+	// The feature is found by name rather than index when set.
+	// +optional
+	Name     string          `json:"name,omitempty" protobuf:"bytes,2,opt,name=name"`
+	Operator FeatureOperator `json:"operator" protobuf:"bytes,3,opt,name=operator"`
+	// Threshold the feature is compared with
+	Value string `json:"value" protobuf:"bytes,4,opt,name=value"`
+}
+
+// BatchingPolicy lets the executor group concurrent requests to a model into a single call
+type BatchingPolicy struct {
+	// Maximum number of rows sent to the model in one call
+	MaxBatchSize int32 `json:"maxBatchSize" protobuf:"int32,1,opt,name=maxBatchSize"`
+	// How long to wait for more requests before sending a partial batch
+	// +optional
+	MaxWaitMs int32 `json:"maxWaitMs,omitempty" protobuf:"int32,2,opt,name=maxWaitMs"`
+}
+
+// CachePolicy enables the executor to return cached responses for identical requests
+type CachePolicy struct {
+	// How long responses are cached. Zero keeps them until evicted.
+	// +optional
+	TtlMs int32 `json:"ttlMs,omitempty" protobuf:"int32,1,opt,name=ttlMs"`
+	// Maximum number of cached responses. Zero uses the executor default.
+	// +optional
+	MaxEntries int32 `json:"maxEntries,omitempty" protobuf:"int32,2,opt,name=maxEntries"`
+	// Request headers that are part of the cache key in addition to the payload
+	// +optional
+	Headers []string `json:"headers,omitempty" protobuf:"bytes,3,rep,name=headers"`
+}
+
+// FanOutPolicy allows a node calling all its children to continue when some of them fail
+type FanOutPolicy struct {
+	// Minimum number of children that must respond successfully
+	Quorum int32 `json:"quorum" protobuf:"int32,1,opt,name=quorum"`
+	// How long to wait for children before continuing with the responses received so far
+	// +optional
+	DeadlineMs int32 `json:"deadlineMs,omitempty" protobuf:"int32,2,opt,name=deadlineMs"`
+}
+
+// RetryPolicy configures how the executor retries failed calls to a predictive unit
+type RetryPolicy struct {
+	// Number of retries after the initial call fails
+	MaxRetries int32 `json:"maxRetries,omitempty" protobuf:"int32,1,opt,name=maxRetries"`
+	// Backoff before the first retry, doubled for each subsequent retry
+	// +optional
+	InitialBackoffMs int32 `json:"initialBackoffMs,omitempty" protobuf:"int32,2,opt,name=initialBackoffMs"`
+	// Upper bound on the backoff between retries
+	// +optional
+	MaxBackoffMs int32 `json:"maxBackoffMs,omitempty" protobuf:"int32,3,opt,name=maxBackoffMs"`
+}
+
+// RetryPolicyMaxRetries bounds the retries of a retry policy, as each retry adds a backoff to the latency of the request
+const RetryPolicyMaxRetries = 10
+
+// CircuitBreaker stops the executor calling a predictive unit after repeated failures
+type CircuitBreaker struct {
+	// Consecutive failures after which the circuit opens
+	FailureThreshold int32 `json:"failureThreshold" protobuf:"int32,1,opt,name=failureThreshold"`
+	// How long the circuit stays open before a trial call is let through
+	// +optional
+	OpenDurationMs int32 `json:"openDurationMs,omitempty" protobuf:"int32,2,opt,name=openDurationMs"`
 }
 
 type LoggerMode string
@@ -603,6 +783,8 @@ const (
 	LogAll      LoggerMode = "all"
 	LogRequest  LoggerMode = "request"
 	LogResponse LoggerMode = "response"
+	// LogPair logs each node call as a single event holding its request and response
+	LogPair LoggerMode = "pair"
 )
 
 // Logger provides optional payload logging for all endpoints
@@ -613,6 +795,30 @@ type Logger struct {
 	Url *string `json:"url,omitempty"`
 	// What payloads to log
 	Mode LoggerMode `json:"mode,omitempty"`
+	// Fraction of requests to log as a decimal between 0 and 1. Defaults to logging every request.
+	// +optional
+	SampleRate *string `json:"sampleRate,omitempty"`
+	// Request headers to send with logged payloads. Other headers are not logged.
+	// +optional
+	Headers []string `json:"headers,omitempty"`
+	// Rules masking fields of logged payloads
+	// +optional
+	Redact []RedactionRule `json:"redact,omitempty"`
+}
+
+const DefaultRedactionMask = "****"
+
+// RedactionRule masks a field of logged payloads. Exactly one of JSONPath or Name must be set.
+type RedactionRule struct {
+	// Path to the JSON fields to mask, such as $.jsonData.customer.email or $.data.ndarray[*][2]
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+	// Name of a column in SeldonMessage data names to mask
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Value masked fields are replaced with. Defaults to "****".
+	// +optional
+	Mask string `json:"mask,omitempty"`
 }
 
 // +genclient
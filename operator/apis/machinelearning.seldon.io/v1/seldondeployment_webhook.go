@@ -17,17 +17,23 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
 	"github.com/seldonio/seldon-core/operator/constants"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/util/jsonpath"
+	"net/url"
 	"os"
+	"regexp"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -114,6 +120,86 @@ func (r *SeldonDeploymentSpec) checkPredictiveUnits(pu *PredictiveUnit, p *Predi
 		if pu.Logger.Mode == "" {
 			allErrs = append(allErrs, field.Invalid(fldPath, pu.Logger.Mode, "No logger mode specified"))
 		}
+		allErrs = checkLogger(pu.Logger, fldPath.Child("logger"), allErrs)
+	}
+
+	if pu.TimeoutMs < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeoutMs"), pu.TimeoutMs, "Timeout must not be negative"))
+	}
+
+	if pu.Retries != nil {
+		if pu.Retries.MaxRetries < 0 || pu.Retries.InitialBackoffMs < 0 || pu.Retries.MaxBackoffMs < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("retries"), pu.Name, "Retry settings must not be negative"))
+		}
+		if pu.Retries.MaxRetries > RetryPolicyMaxRetries {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("retries"), pu.Retries.MaxRetries, "Retry maxRetries must be at most "+strconv.Itoa(RetryPolicyMaxRetries)))
+		}
+	}
+
+	if pu.CircuitBreaker != nil {
+		if pu.CircuitBreaker.FailureThreshold < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("circuitBreaker"), pu.CircuitBreaker.FailureThreshold, "Circuit breaker failureThreshold must be at least 1"))
+		}
+		if pu.CircuitBreaker.OpenDurationMs < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("circuitBreaker"), pu.CircuitBreaker.OpenDurationMs, "Circuit breaker openDurationMs must not be negative"))
+		}
+	}
+
+	if pu.FanOut != nil {
+		if pu.FanOut.Quorum < 1 || int(pu.FanOut.Quorum) > len(pu.Children) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("fanOut"), pu.FanOut.Quorum, "Fan out quorum must be between 1 and the number of children"))
+		}
+		if pu.FanOut.DeadlineMs < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("fanOut"), pu.FanOut.DeadlineMs, "Fan out deadlineMs must not be negative"))
+		}
+	}
+
+	if pu.Implementation != nil && *pu.Implementation == RANDOM_ABTEST {
+		allErrs = checkABTestParameters(pu, fldPath, allErrs)
+	}
+
+	if pu.Implementation != nil && *pu.Implementation == RULES_ROUTER {
+		if len(pu.Rules) == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, pu.Name, "RULES_ROUTER requires at least one rule"))
+		}
+	} else if len(pu.Rules) > 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("rules"), pu.Name, "Rules can only be set for the RULES_ROUTER implementation"))
+	}
+	for i := range pu.Rules {
+		allErrs = checkRoutingRule(&pu.Rules[i], len(pu.Children), fldPath.Child("rules").Index(i), allErrs)
+	}
+
+	allErrs = checkCachePolicy(pu.Cache, fldPath.Child("cache"), allErrs)
+
+	if pu.Batching != nil {
+		if pu.Batching.MaxBatchSize < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("batching"), pu.Batching.MaxBatchSize, "Batching maxBatchSize must be at least 1"))
+		}
+		if pu.Batching.MaxWaitMs < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("batching"), pu.Batching.MaxWaitMs, "Batching maxWaitMs must not be negative"))
+		}
+	}
+
+	if pu.Protocol != "" {
+		if !(pu.Protocol == ProtocolSeldon || pu.Protocol == ProtocolTensorflow || pu.Protocol == ProtocolKfserving) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("protocol"), pu.Protocol, "Invalid protocol"))
+		} else if pu.Protocol != r.graphProtocol() && r.isGrpcEndpoint(pu) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("protocol"), pu.Protocol, "Protocol translation is only supported for REST endpoints"))
+		}
+	}
+
+	if IsBanditImplementation(pu) {
+		if len(pu.Children) == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, pu.Name, "Bandit router "+string(*pu.Implementation)+" requires at least one child"))
+		}
+		for _, param := range pu.Parameters {
+			if param.Name == BanditEpsilonParameter {
+				epsilon, err := strconv.ParseFloat(param.Value, 64)
+				if err != nil || epsilon < 0 || epsilon > 1 {
+					allErrs = append(allErrs, field.Invalid(fldPath.Child("parameters"), param.Value, "Bandit router epsilon must be a number between 0 and 1"))
+				}
+			}
+		}
 	}
 
 	for i := 0; i < len(pu.Children); i++ {
@@ -123,6 +209,194 @@ func (r *SeldonDeploymentSpec) checkPredictiveUnits(pu *PredictiveUnit, p *Predi
 	return allErrs
 }
 
+// graphProtocol is the protocol of the deployment, which nodes use unless they set their own.
+func (r *SeldonDeploymentSpec) graphProtocol() Protocol {
+	if r.Protocol == "" {
+		return ProtocolSeldon
+	}
+	return r.Protocol
+}
+
+func (r *SeldonDeploymentSpec) isGrpcEndpoint(pu *PredictiveUnit) bool {
+	if pu.Endpoint != nil && pu.Endpoint.Type != "" {
+		return pu.Endpoint.Type == GRPC
+	}
+	return r.Transport == TransportGrpc
+}
+
+func checkCachePolicy(cache *CachePolicy, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if cache != nil {
+		if cache.TtlMs < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, cache.TtlMs, "Cache ttlMs must not be negative"))
+		}
+		if cache.MaxEntries < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, cache.MaxEntries, "Cache maxEntries must not be negative"))
+		}
+	}
+	return allErrs
+}
+
+func checkRoutingRule(rule *RoutingRule, children int, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if rule.Route != -2 && (rule.Route < 0 || int(rule.Route) >= children) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("route"), rule.Route, "Route must be the index of a child or -2"))
+	}
+	if rule.Header != nil {
+		allErrs = checkMatchCondition(rule.Header, fldPath.Child("header"), allErrs)
+	}
+	if rule.MetaTag != nil {
+		allErrs = checkMatchCondition(rule.MetaTag, fldPath.Child("metaTag"), allErrs)
+	}
+	if rule.JSONPath != nil {
+		if _, err := jsonpath.Parse("rule", rule.JSONPath.Path); err != nil || rule.JSONPath.Path == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("jsonPath", "path"), rule.JSONPath.Path, "Invalid JSONPath expression"))
+		}
+		allErrs = checkConditionValue(rule.JSONPath.Equals, rule.JSONPath.Regex, fldPath.Child("jsonPath"), allErrs)
+	}
+	if rule.Feature != nil {
+		switch rule.Feature.Operator {
+		case FeatureGreaterThan, FeatureGreaterThanOrEqual, FeatureLessThan, FeatureLessThanOrEqual, FeatureEqual:
+		default:
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("feature", "operator"), rule.Feature.Operator, "Invalid feature operator"))
+		}
+		if rule.Feature.Index < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("feature", "index"), rule.Feature.Index, "Feature index must not be negative"))
+		}
+		if _, err := strconv.ParseFloat(rule.Feature.Value, 64); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("feature", "value"), rule.Feature.Value, "Feature value must be a number"))
+		}
+	}
+	return allErrs
+}
+
+func checkMatchCondition(cond *MatchCondition, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if cond.Name == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), cond.Name, "Condition requires a name"))
+	}
+	return checkConditionValue(cond.Equals, cond.Regex, fldPath, allErrs)
+}
+
+// checkConditionValue checks a condition has exactly one of a value or a valid regular expression to match.
+func checkConditionValue(equals string, regex string, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if (equals == "") == (regex == "") {
+		allErrs = append(allErrs, field.Invalid(fldPath, equals, "Condition requires exactly one of equals or regex"))
+	} else if regex != "" {
+		if _, err := regexp.Compile(regex); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("regex"), regex, "Invalid regular expression"))
+		}
+	}
+	return allErrs
+}
+
+// checkLogger checks the sampling and redaction settings of a payload logger.
+func checkLogger(logger *Logger, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if logger.SampleRate != nil {
+		rate, err := strconv.ParseFloat(*logger.SampleRate, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sampleRate"), *logger.SampleRate, "Sample rate must be a number between 0 and 1"))
+		}
+	}
+	for i, rule := range logger.Redact {
+		rulePath := fldPath.Child("redact").Index(i)
+		if (rule.JSONPath == "") == (rule.Name == "") {
+			allErrs = append(allErrs, field.Invalid(rulePath, rule.Name, "Redaction rule requires exactly one of jsonPath or name"))
+		} else if rule.JSONPath != "" {
+			if _, err := ParseRedactionPath(rule.JSONPath); err != nil {
+				allErrs = append(allErrs, field.Invalid(rulePath.Child("jsonPath"), rule.JSONPath, "Invalid redaction path: "+err.Error()))
+			}
+		}
+	}
+	return allErrs
+}
+
+// ParseRedactionPath splits a redaction JSONPath into the keys and array indices it selects. The path starts
+// with $ followed by .key, ['key'], [index] or a * wildcard for every key or element, which is returned as *.
+func ParseRedactionPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path %s must start with $", path)
+	}
+	var segments []string
+	rest := path[1:]
+	for rest != "" {
+		var segment string
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			segment, rest = rest[1:end+1], rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated key in path %s", path)
+			}
+			segment, rest = rest[2:end], rest[end+2:]
+			if segment == "*" {
+				return nil, fmt.Errorf("key * in path %s can't be quoted", path)
+			}
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in path %s", path)
+			}
+			segment, rest = rest[1:end], rest[end+1:]
+			if _, err := strconv.Atoi(segment); err != nil && segment != "*" {
+				return nil, fmt.Errorf("invalid index %s in path %s", segment, path)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in path %s", rest[0], path)
+		}
+		if segment == "" {
+			return nil, fmt.Errorf("empty key in path %s", path)
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("path %s selects the whole payload", path)
+	}
+	return segments, nil
+}
+
+func checkABTestParameters(pu *PredictiveUnit, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	hasRatio := false
+	hasWeights := false
+	for _, param := range pu.Parameters {
+		switch param.Name {
+		case ABTestRatioAParameter:
+			hasRatio = true
+			ratioA, err := strconv.ParseFloat(param.Value, 64)
+			if err != nil || ratioA < 0 || ratioA > 1 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("parameters"), param.Value, "ratioA must be a number between 0 and 1"))
+			}
+		case ABTestWeightsParameter:
+			hasWeights = true
+			parts := strings.Split(param.Value, ",")
+			if len(parts) != len(pu.Children) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("parameters"), param.Value, "Number of weights must match the number of children"))
+			}
+			var sum float64
+			for _, part := range parts {
+				w, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+				if err != nil || w < 0 {
+					allErrs = append(allErrs, field.Invalid(fldPath.Child("parameters"), param.Value, "Weights must be a comma separated list of non negative numbers"))
+					return allErrs
+				}
+				sum += w
+			}
+			if sum <= 0 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("parameters"), param.Value, "Weights must not all be zero"))
+			}
+		}
+	}
+	if hasRatio && hasWeights {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("parameters"), pu.Name, "Only one of ratioA and weights may be set"))
+	}
+	if !hasWeights && len(pu.Children) != 2 {
+		allErrs = append(allErrs, field.Invalid(fldPath, pu.Name, "RANDOM_ABTEST without weights requires exactly two children"))
+	}
+	return allErrs
+}
+
 func checkTraffic(spec *SeldonDeploymentSpec, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
 	var trafficSum int32 = 0
 	var shadows int = 0
@@ -148,6 +422,86 @@ func checkTraffic(spec *SeldonDeploymentSpec, fldPath *field.Path, allErrs field
 	return allErrs
 }
 
+// checkDAG checks the nodes of a DAG graph name known inputs without cycles and are all used by the graph.
+func checkDAG(p *PredictorSpec, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if !p.IsDAG() {
+		for _, pu := range GetPredictiveUnitList(&p.Graph) {
+			if len(pu.Inputs) > 0 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("graph"), pu.Name, "Inputs can only be set on the nodes of a DAG graph"))
+			}
+		}
+		return allErrs
+	}
+
+	nodes := map[string]*PredictiveUnit{}
+	paths := map[string]*field.Path{}
+	units := []*PredictiveUnit{&p.Graph}
+	unitPaths := []*field.Path{fldPath.Child("graph")}
+	for j := range p.Nodes {
+		units = append(units, &p.Nodes[j])
+		unitPaths = append(unitPaths, fldPath.Child("nodes").Index(j))
+	}
+	for j, pu := range units {
+		if _, present := nodes[pu.Name]; present || pu.Name == GraphRequestInput {
+			allErrs = append(allErrs, field.Invalid(unitPaths[j].Child("name"), pu.Name, "DAG node names must be unique"))
+		}
+		nodes[pu.Name] = pu
+		paths[pu.Name] = unitPaths[j]
+		if len(pu.Children) > 0 {
+			allErrs = append(allErrs, field.Invalid(unitPaths[j].Child("children"), pu.Name, "DAG nodes name their inputs and can't have children"))
+		}
+		if IsRouter(pu) {
+			allErrs = append(allErrs, field.Invalid(unitPaths[j], pu.Name, "Routers can't be used in a DAG graph"))
+		}
+		if len(pu.Inputs) > 1 && !IsAggregator(pu) {
+			allErrs = append(allErrs, field.Invalid(unitPaths[j].Child("inputs"), pu.Name, "DAG nodes with several inputs must be combiners"))
+		}
+	}
+	for j, pu := range units {
+		for _, input := range pu.Inputs {
+			if _, present := nodes[input]; !present && input != GraphRequestInput {
+				allErrs = append(allErrs, field.Invalid(unitPaths[j].Child("inputs"), input, "Unknown DAG input"))
+			}
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	// Walk back from the graph through the inputs of each node to find cycles and unused nodes
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		if name == GraphRequestInput || state[name] == visited {
+			return true
+		}
+		if state[name] == visiting {
+			return false
+		}
+		state[name] = visiting
+		for _, input := range nodes[name].Inputs {
+			if !visit(input) {
+				return false
+			}
+		}
+		state[name] = visited
+		return true
+	}
+	if !visit(p.Graph.Name) {
+		return append(allErrs, field.Invalid(fldPath.Child("nodes"), p.Graph.Name, "DAG graph has a cycle"))
+	}
+	for j := range p.Nodes {
+		if state[p.Nodes[j].Name] != visited {
+			allErrs = append(allErrs, field.Invalid(unitPaths[j+1], p.Nodes[j].Name, "DAG node is not used by the graph"))
+		}
+	}
+	return allErrs
+}
+
 func sizeOfGraph(p *PredictiveUnit) int {
 	count := 0
 	for _, child := range p.Children {
@@ -169,6 +523,11 @@ const (
 	ENV_KAFKA_BROKER       = "KAFKA_BROKER"
 	ENV_KAFKA_INPUT_TOPIC  = "KAFKA_INPUT_TOPIC"
 	ENV_KAFKA_OUTPUT_TOPIC = "KAFKA_OUTPUT_TOPIC"
+	ENV_KAFKA_DELIVERY     = "KAFKA_DELIVERY"
+	ENV_KAFKA_DLQ_TOPIC    = "KAFKA_DLQ_TOPIC"
+	ENV_KAFKA_BATCH_SIZE   = "KAFKA_BATCH_SIZE"
+	ENV_KAFKA_BATCH_WAIT   = "KAFKA_BATCH_WAIT_MS"
+	ENV_KAFKA_KEY_ORDERING = "KAFKA_KEY_ORDERING"
 )
 
 func (r *SeldonDeploymentSpec) validateKafka(allErrs field.ErrorList) field.ErrorList {
@@ -179,16 +538,89 @@ func (r *SeldonDeploymentSpec) validateKafka(allErrs field.ErrorList) field.Erro
 				allErrs = append(allErrs, field.Invalid(fldPath, p.Name, "For kafka please supply svcOrchSpec envs KAFKA_BROKER, KAFKA_INPUT_TOPIC, KAFKA_OUTPUT_TOPIC"))
 			} else {
 				found := 0
+				delivery, dlqTopic := "", ""
 				for _, env := range p.SvcOrchSpec.Env {
 					switch env.Name {
 					case ENV_KAFKA_BROKER, ENV_KAFKA_INPUT_TOPIC, ENV_KAFKA_OUTPUT_TOPIC:
 						found = found + 1
+					case ENV_KAFKA_DLQ_TOPIC:
+						dlqTopic = env.Value
+					case ENV_KAFKA_DELIVERY:
+						delivery = env.Value
+						switch env.Value {
+						case "", "at-most-once", "at-least-once", "exactly-once":
+						default:
+							fldPath := field.NewPath("spec").Child("predictors").Index(i).Child("svcOrchSpec", "env")
+							allErrs = append(allErrs, field.Invalid(fldPath, env.Value, "KAFKA_DELIVERY must be at-most-once, at-least-once or exactly-once"))
+						}
+					case ENV_KAFKA_BATCH_SIZE:
+						if n, err := strconv.Atoi(env.Value); err != nil || n < 1 {
+							fldPath := field.NewPath("spec").Child("predictors").Index(i).Child("svcOrchSpec", "env")
+							allErrs = append(allErrs, field.Invalid(fldPath, env.Value, "KAFKA_BATCH_SIZE must be a positive integer"))
+						}
+					case ENV_KAFKA_BATCH_WAIT:
+						if n, err := strconv.Atoi(env.Value); err != nil || n < 0 {
+							fldPath := field.NewPath("spec").Child("predictors").Index(i).Child("svcOrchSpec", "env")
+							allErrs = append(allErrs, field.Invalid(fldPath, env.Value, "KAFKA_BATCH_WAIT_MS must be a non-negative integer"))
+						}
+					case ENV_KAFKA_KEY_ORDERING:
+						if _, err := strconv.ParseBool(env.Value); err != nil {
+							fldPath := field.NewPath("spec").Child("predictors").Index(i).Child("svcOrchSpec", "env")
+							allErrs = append(allErrs, field.Invalid(fldPath, env.Value, "KAFKA_KEY_ORDERING must be true or false"))
+						}
 					}
 				}
 				if found < 3 {
 					fldPath := field.NewPath("spec").Child("predictors").Index(i)
 					allErrs = append(allErrs, field.Invalid(fldPath, p.Name, "For kafka please supply svcOrchSpec envs KAFKA_BROKER, KAFKA_INPUT_TOPIC, KAFKA_OUTPUT_TOPIC"))
 				}
+				if (delivery == "at-least-once" || delivery == "exactly-once") && dlqTopic == "" {
+					fldPath := field.NewPath("spec").Child("predictors").Index(i).Child("svcOrchSpec", "env")
+					allErrs = append(allErrs, field.Invalid(fldPath, delivery, "KAFKA_DELIVERY "+delivery+" needs a KAFKA_DLQ_TOPIC"))
+				}
+			}
+		}
+	}
+	return allErrs
+}
+
+const (
+	ENV_STREAM_URL     = "STREAM_URL"
+	ENV_STREAM_INPUT   = "STREAM_INPUT"
+	ENV_STREAM_OUTPUT  = "STREAM_OUTPUT"
+	ENV_STREAM_WORKERS = "STREAM_WORKERS"
+)
+
+func (r *SeldonDeploymentSpec) validateStream(allErrs field.ErrorList) field.ErrorList {
+	if r.ServerType == ServerStream {
+		for i, p := range r.Predictors {
+			found := 0
+			for _, env := range p.SvcOrchSpec.Env {
+				fldPath := field.NewPath("spec").Child("predictors").Index(i).Child("svcOrchSpec", "env")
+				switch env.Name {
+				case ENV_STREAM_URL:
+					found = found + 1
+					u, err := url.Parse(env.Value)
+					if err != nil {
+						allErrs = append(allErrs, field.Invalid(fldPath, env.Value, "STREAM_URL must be a URL"))
+						break
+					}
+					switch u.Scheme {
+					case "nats", "redis", "rediss", "amqp", "amqps":
+					default:
+						allErrs = append(allErrs, field.Invalid(fldPath, env.Value, "STREAM_URL scheme must be nats, redis, rediss, amqp or amqps"))
+					}
+				case ENV_STREAM_INPUT, ENV_STREAM_OUTPUT:
+					found = found + 1
+				case ENV_STREAM_WORKERS:
+					if n, err := strconv.Atoi(env.Value); err != nil || n < 1 {
+						allErrs = append(allErrs, field.Invalid(fldPath, env.Value, "STREAM_WORKERS must be a positive integer"))
+					}
+				}
+			}
+			if found < 3 {
+				fldPath := field.NewPath("spec").Child("predictors").Index(i)
+				allErrs = append(allErrs, field.Invalid(fldPath, p.Name, "For stream please supply svcOrchSpec envs STREAM_URL, STREAM_INPUT, STREAM_OUTPUT"))
 			}
 		}
 	}
@@ -216,12 +648,13 @@ func (r *SeldonDeploymentSpec) ValidateSeldonDeployment() error {
 		allErrs = append(allErrs, field.Invalid(fldPath, r.Transport, "Invalid transport"))
 	}
 
-	if r.ServerType != "" && !(r.ServerType == ServerRPC || r.ServerType == ServerKafka) {
+	if r.ServerType != "" && !(r.ServerType == ServerRPC || r.ServerType == ServerKafka || r.ServerType == ServerStream) {
 		fldPath := field.NewPath("spec")
 		allErrs = append(allErrs, field.Invalid(fldPath, r.ServerType, "Invalid serverType"))
 	}
 
 	allErrs = r.validateKafka(allErrs)
+	allErrs = r.validateStream(allErrs)
 	allErrs = r.validateShadow(allErrs)
 
 	transports := make(map[EndpointType]bool)
@@ -235,9 +668,12 @@ func (r *SeldonDeploymentSpec) ValidateSeldonDeployment() error {
 	for i, p := range r.Predictors {
 
 		collectTransports(&p.Graph, transports)
+		for j := range p.Nodes {
+			collectTransports(&p.Nodes[j], transports)
+		}
 
 		_, noEngine := p.Annotations[ANNOTATION_NO_ENGINE]
-		if noEngine && sizeOfGraph(&p.Graph) > 1 {
+		if noEngine && (sizeOfGraph(&p.Graph) > 1 || p.IsDAG()) {
 			fldPath := field.NewPath("spec").Child("predictors").Index(i)
 			allErrs = append(allErrs, field.Invalid(fldPath, p.Name, "Running without engine only valid for single element graphs"))
 		}
@@ -248,12 +684,21 @@ func (r *SeldonDeploymentSpec) ValidateSeldonDeployment() error {
 		}
 		predictorNames[p.Name] = true
 
+		allErrs = checkCachePolicy(p.Cache, field.NewPath("spec").Child("predictors").Index(i).Child("cache"), allErrs)
 		allErrs = r.checkPredictiveUnits(&p.Graph, &p, field.NewPath("spec").Child("predictors").Index(i).Child("graph"), allErrs)
+		for j := range p.Nodes {
+			allErrs = r.checkPredictiveUnits(&p.Nodes[j], &p, field.NewPath("spec").Child("predictors").Index(i).Child("nodes").Index(j), allErrs)
+		}
+		allErrs = checkDAG(&p, field.NewPath("spec").Child("predictors").Index(i), allErrs)
 	}
 
 	if len(transports) > 1 {
-		fldPath := field.NewPath("spec")
-		allErrs = append(allErrs, field.Invalid(fldPath, "", "Multiple endpoint.types found - can only have 1 type in graph. Please use spec.transport"))
+		// The executor calls each node over the transport of its endpoint but can only convert payloads between
+		// REST and gRPC for the Seldon and V2 protocols
+		if r.Protocol == ProtocolTensorflow {
+			fldPath := field.NewPath("spec")
+			allErrs = append(allErrs, field.Invalid(fldPath, r.Protocol, "Multiple endpoint.types found - mixed REST and gRPC graphs are not supported for the tensorflow protocol"))
+		}
 	} else if len(transports) == 1 && r.Transport != "" {
 		for k := range transports {
 			if (k == REST && r.Transport != TransportRest) || (k == GRPC && r.Transport != TransportGrpc) {
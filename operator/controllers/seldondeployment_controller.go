@@ -391,6 +391,23 @@ func getEngineGrpcPort() (engine_grpc_port int, err error) {
 }
 
 // Create all the components (Deployments, Services etc)
+// withoutExecutorContainers returns the componentSpec without the containers of predictive units run by the executor,
+// which get no Deployment or Service of their own.
+func withoutExecutorContainers(p *machinelearningv1.PredictorSpec, cSpec *machinelearningv1.SeldonPodSpec) *machinelearningv1.SeldonPodSpec {
+	var containers []corev1.Container
+	for _, con := range cSpec.Spec.Containers {
+		if !machinelearningv1.IsExecutorContainer(p, con.Name) {
+			containers = append(containers, con)
+		}
+	}
+	if len(containers) == len(cSpec.Spec.Containers) {
+		return cSpec
+	}
+	cSpec = cSpec.DeepCopy()
+	cSpec.Spec.Containers = containers
+	return cSpec
+}
+
 func (r *SeldonDeploymentReconciler) createComponents(ctx context.Context, mlDep *machinelearningv1.SeldonDeployment, securityContext *corev1.PodSecurityContext, log logr.Logger) (*components, error) {
 	c := components{}
 	c.serviceDetails = map[string]*machinelearningv1.ServiceStatus{}
@@ -445,7 +462,7 @@ func (r *SeldonDeploymentReconciler) createComponents(ctx context.Context, mlDep
 		}
 
 		for j := 0; j < len(p.ComponentSpecs); j++ {
-			cSpec := mlDep.Spec.Predictors[i].ComponentSpecs[j]
+			cSpec := withoutExecutorContainers(&p, mlDep.Spec.Predictors[i].ComponentSpecs[j])
 
 			// if no container spec then nothing to create at this point - prepackaged model server cases handled later
 			if len(cSpec.Spec.Containers) == 0 {
@@ -484,7 +501,7 @@ func (r *SeldonDeploymentReconciler) createComponents(ctx context.Context, mlDep
 				// get the container on the created deployment, as createDeploymentWithoutEngine will have created as a copy of the spec in the manifest and added defaults to it
 				// we need the reference as we may have to modify the container when creating the Service (e.g. to add probes)
 				con = utils.GetContainerForDeployment(deploy, cSpec.Spec.Containers[k].Name)
-				pu := machinelearningv1.GetPredictiveUnit(&p.Graph, con.Name)
+				pu := machinelearningv1.GetPredictiveUnitForPredictor(&p, con.Name)
 				deploy = addLabelsToDeployment(deploy, pu, &p)
 
 				// engine will later get a special predictor service as it is entrypoint for graph
@@ -535,6 +552,11 @@ func (r *SeldonDeploymentReconciler) createComponents(ctx context.Context, mlDep
 		if err != nil {
 			return nil, err
 		}
+		for j := range p.Nodes {
+			if err = pi.createStandaloneModelServers(mlDep, &p, &c, &p.Nodes[j], securityContext); err != nil {
+				return nil, err
+			}
+		}
 
 		if !noEngine {
 
@@ -544,7 +566,7 @@ func (r *SeldonDeploymentReconciler) createComponents(ctx context.Context, mlDep
 				found := false
 
 				// find the pu that the webhook marked as localhost as its corresponding deployment should get the engine
-				pu := machinelearningv1.GetEnginePredictiveUnit(&p.Graph)
+				pu := machinelearningv1.GetEnginePredictiveUnitForPredictor(&p)
 				if pu == nil {
 					// below should never happen - if it did would suggest problem in webhook
 					return nil, fmt.Errorf("engine not separate and no pu with localhost service - not clear where to inject engine")
@@ -690,7 +712,7 @@ func createContainerService(deploy *appsv1.Deployment,
 	containerServiceKey := machinelearningv1.Label_seldon_app_svc
 	containerServiceValue := machinelearningv1.GetContainerServiceName(mlDep.Name, p, con)
 	pSvcName := machinelearningv1.GetPredictorKey(mlDep, &p)
-	pu := machinelearningv1.GetPredictiveUnit(&p.Graph, con.Name)
+	pu := machinelearningv1.GetPredictiveUnitForPredictor(&p, con.Name)
 
 	// only create services for containers defined as pus in the graph
 	if pu == nil {
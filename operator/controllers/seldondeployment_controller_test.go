@@ -37,6 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/rand"
 	duckv1beta1 "knative.dev/pkg/apis/duck/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 const (
@@ -1275,3 +1276,89 @@ var _ = Describe("Create a Seldon Deployment with KEDA", func() {
 
 	})
 })
+
+func TestCreateComponentsSkipsExecutorImplementations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	envExecutorImage = "seldonio/executor:0.1"
+	name := "dep"
+	namespace := "default"
+	modelType := machinelearningv1.MODEL
+	combinerType := machinelearningv1.COMBINER
+	impl := machinelearningv1.AVERAGE_COMBINER
+	instance := &machinelearningv1.SeldonDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: machinelearningv1.SeldonDeploymentSpec{
+			Predictors: []machinelearningv1.PredictorSpec{
+				{
+					Name: "p1",
+					ComponentSpecs: []*machinelearningv1.SeldonPodSpec{
+						{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "seldonio/mock_classifier:1.0",
+										Name:  "classifier1",
+									},
+									{
+										Image: "seldonio/mock_classifier:1.0",
+										Name:  "classifier2",
+									},
+								},
+							},
+						},
+						{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "seldonio/mock_combiner:1.0",
+										Name:  "combiner",
+									},
+								},
+							},
+						},
+					},
+					Graph: machinelearningv1.PredictiveUnit{
+						Name:           "combiner",
+						Type:           &combinerType,
+						Implementation: &impl,
+						Children: []machinelearningv1.PredictiveUnit{
+							{
+								Name: "classifier1",
+								Type: &modelType,
+							},
+							{
+								Name: "classifier2",
+								Type: &modelType,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	instance.Spec.DefaultSeldonDeployment(name, namespace)
+	g.Expect(instance.Spec.Predictors[0].ComponentSpecs).To(HaveLen(1))
+
+	// Specs stored before defaulting removed them may still hold the combiner's container
+	cSpec := instance.Spec.Predictors[0].ComponentSpecs[0]
+	cSpec.Spec.Containers = append(cSpec.Spec.Containers, v1.Container{Image: "seldonio/mock_combiner:1.0", Name: "combiner"})
+
+	logger := ctrl.Log.WithName("controllers").WithName("SeldonDeployment")
+	reconciler := &SeldonDeploymentReconciler{
+		Log: logger,
+	}
+	c, err := reconciler.createComponents(context.TODO(), instance, nil, logger)
+	g.Expect(err).To(BeNil())
+	g.Expect(c.deployments).To(HaveLen(1))
+	for _, con := range c.deployments[0].Spec.Template.Spec.Containers {
+		g.Expect(con.Name).ToNot(Equal("combiner"))
+	}
+	var services []string
+	for _, svc := range c.services {
+		services = append(services, svc.Name)
+	}
+	g.Expect(services).To(ConsistOf("dep-p1-classifier1", "dep-p1-classifier2", "dep-p1"))
+}
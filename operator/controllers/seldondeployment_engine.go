@@ -220,21 +220,37 @@ func createExecutorContainer(mlDep *machinelearningv1.SeldonDeployment, p *machi
 		return nil, fmt.Errorf("Failed to parse %s as integer for %s. %w", executorReqLoggerWriteTimeoutMs, ENV_EXECUTOR_REQUEST_LOGGER_WRITE_TIMEOUT_MS, err)
 	}
 
+	args := []string{
+		"--sdep", mlDep.Name,
+		"--namespace", mlDep.Namespace,
+		"--predictor", p.Name,
+		"--http_port", strconv.Itoa(http_port),
+		"--grpc_port", strconv.Itoa(grpc_port),
+		"--protocol", string(protocol),
+		"--prometheus_path", getPrometheusPath(mlDep),
+		"--server_type", string(serverType),
+		"--log_work_buffer_size", loggerQSize,
+		"--log_write_timeout_ms", loggerWriteTimeout,
+	}
+	if spoolDir := getAnnotation(mlDep, machinelearningv1.ANNOTATION_LOGGER_SPOOL_DIR, ""); spoolDir != "" {
+		args = append(args, "--log_spool_dir", spoolDir)
+		for _, setting := range []struct{ annotation, arg string }{
+			{machinelearningv1.ANNOTATION_LOGGER_SPOOL_MAX_BYTES, "--log_spool_max_bytes"},
+			{machinelearningv1.ANNOTATION_LOGGER_SPOOL_SEGMENT, "--log_spool_segment_bytes"},
+		} {
+			if value := getAnnotation(mlDep, setting.annotation, ""); value != "" {
+				if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+					return nil, fmt.Errorf("Failed to parse %s as integer for %s. %w", value, setting.annotation, err)
+				}
+				args = append(args, setting.arg, value)
+			}
+		}
+	}
+
 	return &corev1.Container{
-		Name:  EngineContainerName,
-		Image: executorImage,
-		Args: []string{
-			"--sdep", mlDep.Name,
-			"--namespace", mlDep.Namespace,
-			"--predictor", p.Name,
-			"--http_port", strconv.Itoa(http_port),
-			"--grpc_port", strconv.Itoa(grpc_port),
-			"--protocol", string(protocol),
-			"--prometheus_path", getPrometheusPath(mlDep),
-			"--server_type", string(serverType),
-			"--log_work_buffer_size", loggerQSize,
-			"--log_write_timeout_ms", loggerWriteTimeout,
-		},
+		Name:                     EngineContainerName,
+		Image:                    executorImage,
+		Args:                     args,
 		ImagePullPolicy:          corev1.PullPolicy(utils.GetEnv("EXECUTOR_CONTAINER_IMAGE_PULL_POLICY", "IfNotPresent")),
 		TerminationMessagePath:   "/dev/termination-log",
 		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
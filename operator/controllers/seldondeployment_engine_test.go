@@ -167,3 +167,24 @@ func TestEngineCreateLoggerAnnotation(t *testing.T) {
 	}
 	cleanEnvImages()
 }
+
+func TestEngineCreateLoggerSpoolAnnotation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cleanEnvImages()
+	envExecutorImage = "executor"
+	mlDep := createTestSeldonDeployment()
+	con, err := createExecutorContainer(mlDep, &mlDep.Spec.Predictors[0], "", 1, 2, &v1.ResourceRequirements{})
+	g.Expect(err).To(BeNil())
+	g.Expect(con.Args).ToNot(ContainElement("--log_spool_dir"))
+
+	mlDep.Annotations[machinelearningv1.ANNOTATION_LOGGER_SPOOL_DIR] = "/spool"
+	mlDep.Annotations[machinelearningv1.ANNOTATION_LOGGER_SPOOL_MAX_BYTES] = "1000000"
+	con, err = createExecutorContainer(mlDep, &mlDep.Spec.Predictors[0], "", 1, 2, &v1.ResourceRequirements{})
+	g.Expect(err).To(BeNil())
+	g.Expect(con.Args[len(con.Args)-4:]).To(Equal([]string{"--log_spool_dir", "/spool", "--log_spool_max_bytes", "1000000"}))
+
+	mlDep.Annotations[machinelearningv1.ANNOTATION_LOGGER_SPOOL_SEGMENT] = "big"
+	_, err = createExecutorContainer(mlDep, &mlDep.Spec.Predictors[0], "", 1, 2, &v1.ResourceRequirements{})
+	g.Expect(err).ToNot(BeNil())
+	cleanEnvImages()
+}
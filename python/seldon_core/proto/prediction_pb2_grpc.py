@@ -644,6 +644,11 @@ class SeldonStub(object):
                 request_serializer=google_dot_protobuf_dot_empty__pb2.Empty.SerializeToString,
                 response_deserializer=proto_dot_prediction__pb2.SeldonGraphMetadata.FromString,
                 )
+        self.StreamPredict = channel.stream_stream(
+                '/seldon.protos.Seldon/StreamPredict',
+                request_serializer=proto_dot_prediction__pb2.SeldonMessage.SerializeToString,
+                response_deserializer=proto_dot_prediction__pb2.SeldonMessage.FromString,
+                )
 
 
 class SeldonServicer(object):
@@ -673,6 +678,12 @@ class SeldonServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def StreamPredict(self, request_iterator, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_SeldonServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -696,6 +707,11 @@ def add_SeldonServicer_to_server(servicer, server):
                     request_deserializer=google_dot_protobuf_dot_empty__pb2.Empty.FromString,
                     response_serializer=proto_dot_prediction__pb2.SeldonGraphMetadata.SerializeToString,
             ),
+            'StreamPredict': grpc.stream_stream_rpc_method_handler(
+                    servicer.StreamPredict,
+                    request_deserializer=proto_dot_prediction__pb2.SeldonMessage.FromString,
+                    response_serializer=proto_dot_prediction__pb2.SeldonMessage.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'seldon.protos.Seldon', rpc_method_handlers)
@@ -773,3 +789,20 @@ class Seldon(object):
             proto_dot_prediction__pb2.SeldonGraphMetadata.FromString,
             options, channel_credentials,
             insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
+
+    @staticmethod
+    def StreamPredict(request_iterator,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.stream_stream(request_iterator, target, '/seldon.protos.Seldon/StreamPredict',
+            proto_dot_prediction__pb2.SeldonMessage.SerializeToString,
+            proto_dot_prediction__pb2.SeldonMessage.FromString,
+            options, channel_credentials,
+            insecure, call_credentials, compression, wait_for_ready, timeout, metadata)